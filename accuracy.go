@@ -0,0 +1,305 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RecordForecast accepts a location, the name of the source it was
+// forecast by (e.g. "owm"), the time the forecast was fetched and the daily
+// forecasts in metric units, and stores a snapshot of them so they can
+// later be compared with what was observed. Only the first snapshot of each
+// day's forecast fetched on a given UTC day is kept; the number of
+// forecasts stored is returned. An error is returned if the location or
+// source are empty or if the forecasts cannot be stored.
+func (h *History) RecordForecast(location, source string, fetched time.Time, forecasts []DayForecast) (int, error) {
+	if location == "" {
+		return 0, errEmptyLocation
+	}
+	if source == "" {
+		return 0, errors.New("source must not be empty")
+	}
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var stored int
+	for _, f := range forecasts {
+		res, err := tx.Exec(`INSERT OR IGNORE INTO forecasts (
+				location, source, fetched_at, fetched_date, date,
+				summary, low, high, humidity, precip_prob, precip
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			location, source, fetched.Unix(), fetched.UTC().Format("2006-01-02"), f.Date.Format("2006-01-02"),
+			f.Summary, f.Low, f.High, f.Humidity, f.PrecipProb, f.Precip)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		stored += int(n)
+	}
+	return stored, tx.Commit()
+}
+
+// AccuracyQuery represents a filter on the forecasts and observations
+// compared by History.Accuracy. Empty fields do not filter.
+type AccuracyQuery struct {
+	Location string
+	// Source filters the forecasts compared, not the observations they
+	// are compared against.
+	Source string
+	Since  time.Time
+	Until  time.Time
+	// TZ is the time zone observations are grouped into days in, and that
+	// lead times are measured in. UTC is used if it is nil.
+	TZ *time.Location
+	// MinSamples is the fewest observations a day must have for its
+	// forecasts to be compared, so that partly recorded days do not skew
+	// the results.
+	MinSamples int
+	// RainProb is the precipitation probability, from 0 to 1, at or above
+	// which a forecast is taken to predict precipitation.
+	RainProb float64
+}
+
+// AccuracyStat represents how accurate the forecasts from one source were
+// at one lead time, in days between when the forecast was fetched and the
+// day it was for.
+type AccuracyStat struct {
+	Source   string `json:"source"`
+	LeadDays int    `json:"lead_days"`
+	Days     int    `json:"days"`
+	// HighMAE and LowMAE are the mean absolute errors of the forecast high
+	// and low temperatures in C, and HighBias and LowBias are their mean
+	// errors, positive when forecasts were too warm.
+	HighMAE  float64 `json:"high_mae"`
+	HighBias float64 `json:"high_bias"`
+	LowMAE   float64 `json:"low_mae"`
+	LowBias  float64 `json:"low_bias"`
+	// PrecipHitRate is the fraction of days on which whether it would
+	// precipitate was forecast correctly.
+	PrecipHitRate float64 `json:"precip_hit_rate"`
+}
+
+// observedDay represents what was observed at a location on one day.
+type observedDay struct {
+	samples   int
+	low, high float64
+	precip    bool
+}
+
+// Accuracy compares the recorded forecasts matching q with the observations
+// recorded for the days they were for, and returns statistics for each
+// source and lead time, ordered by source and then lead time. Days that
+// have fewer than q.MinSamples observations are skipped. An error is
+// returned if the database cannot be queried.
+func (h *History) Accuracy(q AccuracyQuery) ([]AccuracyStat, error) {
+	tz := q.TZ
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	records, err := h.Query(HistoryQuery{Location: q.Location, Since: q.Since, Until: q.Until})
+	if err != nil {
+		return nil, err
+	}
+	observed := map[string]*observedDay{}
+	for _, r := range records {
+		key := r.Location + "\x00" + r.Time.In(tz).Format("2006-01-02")
+		d, ok := observed[key]
+		if !ok {
+			d = &observedDay{low: r.Temp, high: r.Temp}
+			observed[key] = d
+		}
+		d.samples++
+		d.low = math.Min(d.low, r.Temp)
+		d.high = math.Max(d.high, r.Temp)
+		d.precip = d.precip || r.Precip > 0
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Location != "" {
+		where = append(where, "location = ?")
+		args = append(args, q.Location)
+	}
+	if q.Source != "" {
+		where = append(where, "source = ?")
+		args = append(args, q.Source)
+	}
+	query := `SELECT location, source, fetched_at, date, low, high, precip_prob FROM forecasts`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct {
+		source string
+		lead   int
+	}
+	type sums struct {
+		days                             int
+		highAbs, highErr, lowAbs, lowErr float64
+		hits                             int
+	}
+	totals := map[key]*sums{}
+	for rows.Next() {
+		var location, source, date string
+		var fetchedAt int64
+		var low, high, prob float64
+		if err := rows.Scan(&location, &source, &fetchedAt, &date, &low, &high, &prob); err != nil {
+			return nil, err
+		}
+		d, ok := observed[location+"\x00"+date]
+		if !ok || d.samples < q.MinSamples {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", date, tz)
+		if err != nil {
+			return nil, err
+		}
+		fetched := time.Unix(fetchedAt, 0).In(tz)
+		fetchedDay := time.Date(fetched.Year(), fetched.Month(), fetched.Day(), 0, 0, 0, 0, tz)
+		lead := int(math.Round(day.Sub(fetchedDay).Hours() / 24))
+		if lead < 0 {
+			continue
+		}
+
+		k := key{source, lead}
+		s, ok := totals[k]
+		if !ok {
+			s = &sums{}
+			totals[k] = s
+		}
+		s.days++
+		s.highAbs += math.Abs(high - d.high)
+		s.highErr += high - d.high
+		s.lowAbs += math.Abs(low - d.low)
+		s.lowErr += low - d.low
+		if (prob >= q.RainProb) == d.precip {
+			s.hits++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]AccuracyStat, 0, len(totals))
+	for k, s := range totals {
+		n := float64(s.days)
+		results = append(results, AccuracyStat{
+			Source:        k.source,
+			LeadDays:      k.lead,
+			Days:          s.days,
+			HighMAE:       s.highAbs / n,
+			HighBias:      s.highErr / n,
+			LowMAE:        s.lowAbs / n,
+			LowBias:       s.lowErr / n,
+			PrecipHitRate: float64(s.hits) / n,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Source != results[j].Source {
+			return results[i].Source < results[j].Source
+		}
+		return results[i].LeadDays < results[j].LeadDays
+	})
+	return results, nil
+}
+
+// writeAccuracy writes results to w as a table.
+func writeAccuracy(w io.Writer, results []AccuracyStat) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tLEAD (days)\tDAYS\tHIGH MAE (C)\tHIGH BIAS (C)\tLOW MAE (C)\tLOW BIAS (C)\tPRECIP HIT RATE")
+	for _, s := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%+.2f\t%.2f\t%+.2f\t%.0f%%\n",
+			s.Source, s.LeadDays, s.Days, s.HighMAE, s.HighBias, s.LowMAE, s.LowBias, s.PrecipHitRate*100)
+	}
+	return tw.Flush()
+}
+
+// AccuracyCLI accepts a slice of command line flags and arguments and
+// prints how accurate the forecasts recorded in the history database have
+// been, compared with the observations recorded there, for each provider
+// and lead time. An error is returned if the flags are invalid or if the
+// database cannot be opened or queried.
+func AccuracyCLI(args []string) error {
+	fs := flag.NewFlagSet("accuracy", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather accuracy [-db=weather.db] [-location=<location>] [-source=<provider>] [-since=<time|duration>] [-until=<time|duration>] [-tz=UTC] [-min-samples=8] [-format={text|json}]\n\n"))
+		fs.PrintDefaults()
+	}
+	path := fs.String("db", "weather.db", "the SQLite database forecasts and observations are recorded in")
+	location := fs.String("location", "", "only compare forecasts for this location")
+	source := fs.String("source", "", "only compare forecasts from this provider")
+	since := fs.String("since", "", "only use observations from this RFC 3339 time, or this long ago (e.g. '720h')")
+	until := fs.String("until", "", "only use observations up to this RFC 3339 time, or this long ago")
+	tz := fs.String("tz", "UTC", "the time zone days start and end in (e.g. 'America/New_York', 'Local')")
+	minSamples := fs.Int("min-samples", 8, "the fewest observations a day needs for its forecasts to be compared")
+	rainProb := fs.Float64("rain-prob", 0.5, "the precipitation probability, from 0 to 1, taken as forecasting precipitation")
+	format := fs.String("format", "text", "the output format, one of: text, json")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return errors.New("format flag must be one of: text, json")
+	}
+	if *minSamples < 1 {
+		return errors.New("min-samples flag must be at least 1")
+	}
+	if *rainProb < 0 || *rainProb > 1 {
+		return errors.New("rain-prob flag must be between 0 and 1")
+	}
+
+	q := AccuracyQuery{Location: *location, Source: *source, MinSamples: *minSamples, RainProb: *rainProb}
+	var err error
+	if q.TZ, err = time.LoadLocation(*tz); err != nil {
+		return err
+	}
+	now := time.Now()
+	if q.Since, err = parseTimeFlag(*since, now); err != nil {
+		return fmt.Errorf("since flag: %w", err)
+	}
+	if q.Until, err = parseTimeFlag(*until, now); err != nil {
+		return fmt.Errorf("until flag: %w", err)
+	}
+
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+	h, err := OpenHistory(*path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	results, err := h.Accuracy(q)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return writeAccuracy(os.Stdout, results)
+}
@@ -0,0 +1,88 @@
+package weather_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistoryAccuracy(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	day := func(d int) time.Time { return time.Date(2021, 5, d, 0, 0, 0, 0, time.UTC) }
+	record := func(source string, fetched time.Time, want int, forecasts ...weather.DayForecast) {
+		t.Helper()
+		n, err := h.RecordForecast("london", source, fetched, forecasts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != n {
+			t.Fatalf("want %d forecasts stored, got %d", want, n)
+		}
+	}
+	record("owm", day(17).Add(10*time.Hour), 2,
+		weather.DayForecast{Date: day(17).Add(12 * time.Hour), Low: 8, High: 15},
+		weather.DayForecast{Date: day(18).Add(12 * time.Hour), Low: 12, High: 18, PrecipProb: 0.2},
+	)
+	// A later snapshot on the same day is ignored.
+	record("owm", day(17).Add(15*time.Hour), 0,
+		weather.DayForecast{Date: day(18).Add(12 * time.Hour), Low: 0, High: 0},
+	)
+	record("owm", day(18).Add(10*time.Hour), 1,
+		weather.DayForecast{Date: day(18).Add(12 * time.Hour), Low: 9, High: 20, PrecipProb: 0.7},
+	)
+	record("openmeteo", day(17).Add(10*time.Hour), 1,
+		weather.DayForecast{Date: day(18), Low: 10, High: 21, PrecipProb: 0.6},
+	)
+
+	// May 17 has too few observations to be compared, May 18 ranges from
+	// 10 to 19 C with some rain.
+	for i := 0; i < 2; i++ {
+		if _, err := h.Record("london", "owm", weather.Observation{Time: day(17).Add(time.Duration(i) * time.Hour), Temp: 11}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		obs := weather.Observation{Time: day(18).Add(time.Duration(i*2) * time.Hour), Temp: float64(10 + i)}
+		if i == 5 {
+			obs.Precip = 0.4
+		}
+		if _, err := h.Record("london", "owm", obs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := h.Accuracy(weather.AccuracyQuery{MinSamples: 8, RainProb: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weather.AccuracyStat{
+		{Source: "openmeteo", LeadDays: 1, Days: 1, HighMAE: 2, HighBias: 2, LowMAE: 0, LowBias: 0, PrecipHitRate: 1},
+		{Source: "owm", LeadDays: 0, Days: 1, HighMAE: 1, HighBias: 1, LowMAE: 1, LowBias: -1, PrecipHitRate: 1},
+		{Source: "owm", LeadDays: 1, Days: 1, HighMAE: 1, HighBias: -1, LowMAE: 2, LowBias: 2, PrecipHitRate: 0},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+
+	got, err = h.Accuracy(weather.AccuracyQuery{Source: "openmeteo", MinSamples: 8, RainProb: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Source != "openmeteo" {
+		t.Fatalf("want only openmeteo statistics, got %+v", got)
+	}
+}
+
+func TestHistoryRecordForecastInvalidArgs(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	if _, err := h.RecordForecast("", "owm", time.Now(), nil); err == nil {
+		t.Fatal("want error for empty location")
+	}
+	if _, err := h.RecordForecast("london", "", time.Now(), nil); err == nil {
+		t.Fatal("want error for empty source")
+	}
+}
@@ -0,0 +1,294 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aculclasure/weather/astro"
+)
+
+// Almanac represents the rising and setting times of the sun and moon, and
+// the phase of the moon, at a location on one day. Times are in the
+// location's time zone, and the time of an event that does not happen on
+// the day, like a moonset that falls after midnight, is the zero time.
+type Almanac struct {
+	// Date is midnight at the start of the day in the location's time zone.
+	Date     time.Time
+	Lat      float64
+	Lon      float64
+	Sunrise  time.Time
+	Sunset   time.Time
+	Moonrise time.Time
+	Moonset  time.Time
+	// MoonPhase is the position in the lunar cycle, from 0 at the new moon
+	// through 0.25 at the first quarter, 0.5 at the full moon and 0.75 at
+	// the last quarter.
+	MoonPhase float64
+}
+
+// DayLength returns the time between sunrise and sunset, or zero if the
+// sun does not both rise and set on the day.
+func (a Almanac) DayLength() time.Duration {
+	if a.Sunrise.IsZero() || a.Sunset.IsZero() {
+		return 0
+	}
+	return a.Sunset.Sub(a.Sunrise)
+}
+
+// Almanac accepts a location (e.g. "london", "tampa,fl,us", "51.5,-0.12",
+// etc.), looks up the coordinates of the location unless they are given,
+// requests the daily forecasts for those coordinates from the One Call API
+// and returns the sun and moon times reported for each forecast day as a
+// slice of Almanac structs, in the location's time zone. An error is
+// returned if any API request fails or if an API response cannot be
+// decoded.
+func (c Client) Almanac(location string) ([]Almanac, error) {
+	loc, err := geocodeWith(c, location)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.OneCallData(loc.Lat, loc.Lon, canonicalUnits, "current", "minutely", "hourly", "alerts")
+	if err != nil {
+		return nil, err
+	}
+	var resp OneCallAPIResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("got error unmarshaling onecall API response: %v", err)
+	}
+
+	zone := timeZone(resp.Timezone, resp.TimezoneOffset)
+	days := make([]Almanac, 0, len(resp.Daily))
+	for _, d := range resp.Daily {
+		y, m, day := time.Unix(int64(d.Date), 0).In(zone).Date()
+		days = append(days, Almanac{
+			Date:      time.Date(y, m, day, 0, 0, 0, 0, zone),
+			Lat:       loc.Lat,
+			Lon:       loc.Lon,
+			Sunrise:   unixIn(d.Sunrise, zone),
+			Sunset:    unixIn(d.Sunset, zone),
+			Moonrise:  unixIn(d.Moonrise, zone),
+			Moonset:   unixIn(d.Moonset, zone),
+			MoonPhase: d.MoonPhase,
+		})
+	}
+	return days, nil
+}
+
+// timeZone returns the time zone with the given IANA name, or a fixed zone
+// offset from UTC by offset seconds if the name is not in the time zone
+// database.
+func timeZone(name string, offset int) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil && name != "" {
+		return loc
+	}
+	return time.FixedZone(name, offset)
+}
+
+// unixIn returns the Unix time sec in zone, or the zero time if sec is
+// zero, which the One Call API reports for events that do not happen.
+func unixIn(sec int64, zone *time.Location) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).In(zone)
+}
+
+// illumination returns the illuminated fraction of the moon's disk at a
+// position in the lunar cycle.
+func illumination(phase float64) float64 {
+	return (1 - math.Cos(2*math.Pi*phase)) / 2
+}
+
+// almanacCLI holds the settings shared by the sun and moon subcommands.
+type almanacCLI struct {
+	location string
+	// date is the day asked about, at midnight in zone.
+	date time.Time
+	zone *time.Location
+	// day holds the times reported by the One Call API for date, if the
+	// command is not offline and the API forecasts that far ahead.
+	day *Almanac
+	lat float64
+	lon float64
+}
+
+// parseAlmanacCLI parses the command line flags and arguments of the sun or
+// moon subcommand and, unless the offline flag is set, requests the sun and
+// moon times for the location. An error is returned if the flags and
+// arguments are invalid, if the OPENWEATHER_API_KEY environment variable
+// is not set when it is needed or if the request fails.
+func parseAlmanacCLI(name string, args []string) (almanacCLI, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "USAGE: weather %s [-date=YYYY-MM-DD] [-tz=<zone>] [-offline] <location|lat,lon>\n\n", name)
+		fs.PrintDefaults()
+	}
+	date := fs.String("date", "", "the day to show, as YYYY-MM-DD (default today)")
+	tz := fs.String("tz", "", "the time zone to show times in (e.g. 'Europe/London', 'Local'), by default the location's, or UTC when offline")
+	offline := fs.Bool("offline", false, "calculate the times without calling the OpenWeather API; the location must be given as lat,lon")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return almanacCLI{}, err
+	}
+	cfg := almanacCLI{location: fs.Arg(0), zone: time.UTC}
+	if cfg.location == "" {
+		return almanacCLI{}, errors.New("positional argument for location must be given (e.g. 'london', '51.5,-0.12', etc.)")
+	}
+
+	var days []Almanac
+	if *offline {
+		loc, ok := parseLatLon(cfg.location)
+		if !ok {
+			return almanacCLI{}, errors.New("location must be given as lat,lon (e.g. '51.5,-0.12') when offline")
+		}
+		cfg.lat, cfg.lon = loc.Lat, loc.Lon
+	} else {
+		apiKey := os.Getenv("OPENWEATHER_API_KEY")
+		if apiKey == "" {
+			return almanacCLI{}, errors.New("environment variable OPENWEATHER_API_KEY must be set, or the offline flag given")
+		}
+		c, err := NewClient(apiKey)
+		if err != nil {
+			return almanacCLI{}, err
+		}
+		if days, err = c.Almanac(cfg.location); err != nil {
+			return almanacCLI{}, err
+		}
+		if len(days) == 0 {
+			return almanacCLI{}, fmt.Errorf("no sun and moon times found for %q", cfg.location)
+		}
+		cfg.zone = days[0].Date.Location()
+		cfg.lat, cfg.lon = days[0].Lat, days[0].Lon
+	}
+	if *tz != "" {
+		var err error
+		if cfg.zone, err = time.LoadLocation(*tz); err != nil {
+			return almanacCLI{}, fmt.Errorf("tz flag: %w", err)
+		}
+	}
+
+	y, m, d := time.Now().In(cfg.zone).Date()
+	cfg.date = time.Date(y, m, d, 0, 0, 0, 0, cfg.zone)
+	if *date != "" {
+		var err error
+		if cfg.date, err = time.ParseInLocation("2006-01-02", *date, cfg.zone); err != nil {
+			return almanacCLI{}, fmt.Errorf("date flag: %q must be a date like '2021-05-18'", *date)
+		}
+	}
+	for i := range days {
+		if days[i].Date.Format("2006-01-02") == cfg.date.Format("2006-01-02") {
+			cfg.day = &days[i]
+			break
+		}
+	}
+	return cfg, nil
+}
+
+// SunCLI accepts a slice of command line flags and arguments, including the
+// subcommand name, and prints the times of sunrise and sunset at a location
+// on a day, along with the civil, nautical and astronomical twilight and
+// the golden hours, in the location's time zone. Sunrise and sunset are
+// those reported by the OpenWeather One Call API while it forecasts the
+// day; everything else, and every time when the offline flag is set, is
+// calculated. An error is returned if the command line flags and arguments
+// are invalid, if the OPENWEATHER_API_KEY environment variable is not set
+// when it is needed or if the request for the times fails.
+func SunCLI(args []string) error {
+	cfg, err := parseAlmanacCLI("sun", args)
+	if err != nil {
+		return err
+	}
+	sun, err := astro.Sun(cfg.date, cfg.lat, cfg.lon)
+	if err != nil {
+		return err
+	}
+	if cfg.day != nil && !cfg.day.Sunrise.IsZero() && !cfg.day.Sunset.IsZero() {
+		sun.Sunrise = cfg.day.Sunrise.In(cfg.zone)
+		sun.Sunset = cfg.day.Sunset.In(cfg.zone)
+		sun.DayLength = cfg.day.DayLength()
+	}
+	return writeSun(os.Stdout, cfg.location, cfg.date, sun)
+}
+
+// MoonCLI accepts a slice of command line flags and arguments, including
+// the subcommand name, and prints the phase and illumination of the moon
+// on a day and, while the OpenWeather One Call API forecasts the day, the
+// times of moonrise and moonset at a location in its time zone. When the
+// offline flag is set, or the day is beyond the forecast, the phase is
+// calculated and moonrise and moonset are not shown. An error is returned
+// if the command line flags and arguments are invalid, if the
+// OPENWEATHER_API_KEY environment variable is not set when it is needed or
+// if the request for the times fails.
+func MoonCLI(args []string) error {
+	cfg, err := parseAlmanacCLI("moon", args)
+	if err != nil {
+		return err
+	}
+	noon := cfg.date.Add(12 * time.Hour)
+	moon := astro.Moon(noon)
+	if cfg.day != nil {
+		moon = astro.MoonPhase{Phase: cfg.day.MoonPhase, Fraction: illumination(cfg.day.MoonPhase)}
+	}
+	return writeMoon(os.Stdout, cfg.location, cfg.date, moon, cfg.day)
+}
+
+// clockTime returns the time of day of t to the minute, or "none" if t is
+// the zero time.
+func clockTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.Format("15:04")
+}
+
+// writeSun writes the times of the sun's events on date at location to w
+// as a table, in the order they happen.
+func writeSun(w io.Writer, location string, date time.Time, s astro.SunTimes) error {
+	fmt.Fprintf(w, "Sun for %s on %s (%s)\n", location, date.Format("2006-01-02"), date.Location())
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := []struct {
+		name string
+		at   time.Time
+	}{
+		{"Astronomical dawn", s.AstronomicalDawn},
+		{"Nautical dawn", s.NauticalDawn},
+		{"Civil dawn", s.CivilDawn},
+		{"Sunrise", s.Sunrise},
+		{"Golden hour ends", s.GoldenHourEnd},
+		{"Solar noon", s.SolarNoon},
+		{"Golden hour starts", s.GoldenHourStart},
+		{"Sunset", s.Sunset},
+		{"Civil dusk", s.CivilDusk},
+		{"Nautical dusk", s.NauticalDusk},
+		{"Astronomical dusk", s.AstronomicalDusk},
+	}
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", r.name, clockTime(r.at))
+	}
+	fmt.Fprintf(tw, "Day length\t%dh%02dm\n", int(s.DayLength.Hours()), int(s.DayLength.Minutes())%60)
+	return tw.Flush()
+}
+
+// writeMoon writes the phase of the moon on date to w as a table, along
+// with the times of moonrise and moonset at location if day is not nil.
+func writeMoon(w io.Writer, location string, date time.Time, m astro.MoonPhase, day *Almanac) error {
+	fmt.Fprintf(w, "Moon for %s on %s (%s)\n", location, date.Format("2006-01-02"), date.Location())
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Phase\t%s (%.2f)\n", astro.PhaseName(m.Phase), m.Phase)
+	fmt.Fprintf(tw, "Illumination\t%.0f%%\n", m.Fraction*100)
+	if day != nil {
+		fmt.Fprintf(tw, "Moonrise\t%s\n", clockTime(day.Moonrise.In(date.Location())))
+		fmt.Fprintf(tw, "Moonset\t%s\n", clockTime(day.Moonset.In(date.Location())))
+	}
+	return tw.Flush()
+}
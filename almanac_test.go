@@ -0,0 +1,75 @@
+package weather_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientAlmanac(t *testing.T) {
+	t.Parallel()
+	oneCallData, err := ioutil.ReadFile("testdata/oneCallAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data/2.5/onecall":
+			w.Write(oneCallData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+
+	days, err := client.Almanac("33.44,-94.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 8 {
+		t.Fatalf("want 8 days, got %d", len(days))
+	}
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	want := weather.Almanac{
+		Date:      time.Date(2021, 5, 18, 0, 0, 0, 0, chicago),
+		Lat:       33.44,
+		Lon:       -94.04,
+		Sunrise:   time.Date(2021, 5, 18, 6, 13, 48, 0, chicago),
+		Sunset:    time.Date(2021, 5, 18, 20, 11, 39, 0, chicago),
+		Moonrise:  time.Date(2021, 5, 18, 11, 47, 0, 0, chicago),
+		Moonset:   time.Date(2021, 5, 18, 1, 26, 0, 0, chicago),
+		MoonPhase: 0.21,
+	}
+	got := days[0]
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+	if got.Date.Location().String() != "America/Chicago" {
+		t.Fatalf("want times in the location's time zone, got %s", got.Date.Location())
+	}
+	if want, got := 13*time.Hour+57*time.Minute+51*time.Second, got.DayLength(); want != got {
+		t.Fatalf("want day length %v, got %v", want, got)
+	}
+}
+
+func TestAlmanacDayLengthWithoutSunset(t *testing.T) {
+	t.Parallel()
+	a := weather.Almanac{Sunrise: time.Date(2021, 6, 21, 0, 30, 0, 0, time.UTC)}
+	if got := a.DayLength(); got != 0 {
+		t.Fatalf("want zero day length without a sunset, got %v", got)
+	}
+}
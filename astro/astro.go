@@ -0,0 +1,303 @@
+// Package astro calculates the times of sunrise, sunset, twilight and the
+// golden hour, and the phase of the moon, for any date and coordinates
+// without requesting them from a weather API.
+//
+// The calculations follow the low-precision formulas in Jean Meeus'
+// "Astronomical Algorithms", which are accurate to within a minute or two
+// away from the polar circles. Latitudes are in degrees north and
+// longitudes in degrees east.
+package astro
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	errInvalidLatitude  = errors.New("latitude must be between -90 and 90")
+	errInvalidLongitude = errors.New("longitude must be between -180 and 180")
+)
+
+// The altitudes of the center of the sun, in degrees, that mark the events
+// of a day. Sunrise and sunset allow for the radius of the sun and for
+// atmospheric refraction.
+const (
+	horizon              = -0.833
+	civilTwilight        = -6
+	nauticalTwilight     = -12
+	astronomicalTwilight = -18
+	goldenHour           = 6
+)
+
+const (
+	rad = math.Pi / 180
+
+	// julian1970 is the Julian date of the Unix epoch and julian2000 that
+	// of the J2000 epoch.
+	julian1970 = 2440587.5
+	julian2000 = 2451545.0
+
+	// obliquity is the tilt of the earth's axis.
+	obliquity = rad * 23.4397
+
+	// julianOffset corrects the time of the solar transit for the
+	// difference between the Julian cycle and the solar day.
+	julianOffset = 0.0009
+
+	// deltaT is the difference between terrestrial time, in which the
+	// orbital formulas are expressed, and universal time, in days. It
+	// drifts by about a second a year, which is well within the accuracy
+	// of the formulas for dates in this century.
+	deltaT = 69.2 / 86400
+)
+
+// SunTimes represents the times of the sun's events on a day at a location.
+// The time of an event that does not happen on that day, like sunset
+// during the polar day or astronomical dusk during a northern summer, is
+// the zero time.
+type SunTimes struct {
+	SolarNoon time.Time
+	Sunrise   time.Time
+	Sunset    time.Time
+	// DayLength is the time between sunrise and sunset, or 24 hours or
+	// zero if the sun does not set or does not rise.
+	DayLength time.Duration
+
+	// CivilDawn and CivilDusk are when the sun is 6 degrees below the
+	// horizon, NauticalDawn and NauticalDusk 12 degrees and
+	// AstronomicalDawn and AstronomicalDusk 18 degrees.
+	CivilDawn        time.Time
+	CivilDusk        time.Time
+	NauticalDawn     time.Time
+	NauticalDusk     time.Time
+	AstronomicalDawn time.Time
+	AstronomicalDusk time.Time
+
+	// GoldenHourEnd is when the sun climbs 6 degrees above the horizon,
+	// ending the morning golden hour that starts at sunrise, and
+	// GoldenHourStart when it falls back to 6 degrees, starting the
+	// evening golden hour that ends at sunset.
+	GoldenHourEnd   time.Time
+	GoldenHourStart time.Time
+}
+
+// Sun accepts a date and the latitude and longitude of a location and
+// returns the times of the sun's events at that location on that date,
+// in the date's time zone. An error is returned if the latitude or
+// longitude is out of range.
+func Sun(date time.Time, lat, lon float64) (SunTimes, error) {
+	if err := checkCoordinates(lat, lon); err != nil {
+		return SunTimes{}, err
+	}
+
+	// Start from the mean solar noon of the date at the location, so the
+	// Julian cycle found is that of the date rather than a neighbor.
+	y, m, d := date.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Add(time.Duration(-lon / 15 * float64(time.Hour)))
+	lw := rad * -lon
+	phi := rad * lat
+	n := math.Round(toDays(noon) - julianOffset - lw/(2*math.Pi))
+	ds := approxTransit(0, lw, n)
+	ma := solarMeanAnomaly(ds)
+	l := eclipticLongitude(ma)
+	dec := declination(l, 0)
+	transit := solarTransit(ds, ma, l)
+
+	// event returns the times the sun passes an altitude before and after
+	// noon, or zero times if it does not.
+	event := func(alt float64) (time.Time, time.Time) {
+		w := hourAngle(rad*alt, phi, dec)
+		if math.IsNaN(w) {
+			return time.Time{}, time.Time{}
+		}
+		set := solarTransit(approxTransit(w, lw, n), ma, l)
+		rise := transit - (set - transit)
+		return fromJulian(rise).In(date.Location()), fromJulian(set).In(date.Location())
+	}
+
+	s := SunTimes{SolarNoon: fromJulian(transit).In(date.Location())}
+	s.Sunrise, s.Sunset = event(horizon)
+	s.CivilDawn, s.CivilDusk = event(civilTwilight)
+	s.NauticalDawn, s.NauticalDusk = event(nauticalTwilight)
+	s.AstronomicalDawn, s.AstronomicalDusk = event(astronomicalTwilight)
+	s.GoldenHourEnd, s.GoldenHourStart = event(goldenHour)
+	switch {
+	case !s.Sunrise.IsZero():
+		s.DayLength = s.Sunset.Sub(s.Sunrise)
+	case altitude(0, phi, dec) > rad*horizon:
+		s.DayLength = 24 * time.Hour
+	}
+	return s, nil
+}
+
+// SunAltitude accepts a time and the latitude and longitude of a location
+// and returns the altitude of the sun above the horizon there, in degrees.
+// An error is returned if the latitude or longitude is out of range.
+func SunAltitude(t time.Time, lat, lon float64) (float64, error) {
+	if err := checkCoordinates(lat, lon); err != nil {
+		return 0, err
+	}
+	d := toDays(t)
+	c := sunCoords(d)
+	h := siderealTime(d, rad*-lon) - c.ra
+	return altitude(h, rad*lat, c.dec) / rad, nil
+}
+
+// MoonPhase represents the illuminated fraction and phase of the moon.
+type MoonPhase struct {
+	// Fraction is the illuminated fraction of the moon's disk, from 0 at
+	// the new moon to 1 at the full moon.
+	Fraction float64
+	// Phase is the position in the lunar cycle, from 0 at the new moon
+	// through 0.25 at the first quarter, 0.5 at the full moon and 0.75 at
+	// the last quarter, matching the moon phase reported by OpenWeather.
+	Phase float64
+}
+
+// Moon accepts a time and returns the phase of the moon at that time.
+func Moon(t time.Time) MoonPhase {
+	d := toDays(t)
+	s := sunCoords(d)
+	m := moonCoords(d)
+	const sunDistance = 149598000 // km
+
+	phi := math.Acos(math.Sin(s.dec)*math.Sin(m.dec) + math.Cos(s.dec)*math.Cos(m.dec)*math.Cos(s.ra-m.ra))
+	inc := math.Atan2(sunDistance*math.Sin(phi), m.dist-sunDistance*math.Cos(phi))
+	angle := math.Atan2(math.Cos(s.dec)*math.Sin(s.ra-m.ra),
+		math.Sin(s.dec)*math.Cos(m.dec)-math.Cos(s.dec)*math.Sin(m.dec)*math.Cos(s.ra-m.ra))
+	sign := 1.0
+	if angle < 0 {
+		sign = -1
+	}
+	return MoonPhase{
+		Fraction: (1 + math.Cos(inc)) / 2,
+		Phase:    0.5 + 0.5*inc*sign/math.Pi,
+	}
+}
+
+// phaseNames are the names of the eight phases of the moon, starting at
+// the new moon.
+var phaseNames = []string{
+	"new moon",
+	"waxing crescent",
+	"first quarter",
+	"waxing gibbous",
+	"full moon",
+	"waning gibbous",
+	"last quarter",
+	"waning crescent",
+}
+
+// PhaseName accepts a position in the lunar cycle, from 0 to 1 as in
+// MoonPhase.Phase, and returns the name of the nearest of the eight phases
+// of the moon (e.g. "waxing gibbous").
+func PhaseName(phase float64) string {
+	phase -= math.Floor(phase)
+	return phaseNames[int(math.Round(phase*8))%8]
+}
+
+// checkCoordinates returns an error if lat or lon is out of range.
+func checkCoordinates(lat, lon float64) error {
+	if lat < -90 || lat > 90 || math.IsNaN(lat) {
+		return errInvalidLatitude
+	}
+	if lon < -180 || lon > 180 || math.IsNaN(lon) {
+		return errInvalidLongitude
+	}
+	return nil
+}
+
+// toDays returns the number of days since the J2000 epoch.
+func toDays(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + julian1970 - julian2000
+}
+
+// fromJulian returns the time of a Julian date.
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, int64((j-julian1970)*float64(24*time.Hour))).UTC()
+}
+
+// declination returns the declination of a point at ecliptic longitude l
+// and latitude b.
+func declination(l, b float64) float64 {
+	return math.Asin(math.Sin(b)*math.Cos(obliquity) + math.Cos(b)*math.Sin(obliquity)*math.Sin(l))
+}
+
+// rightAscension returns the right ascension of a point at ecliptic
+// longitude l and latitude b.
+func rightAscension(l, b float64) float64 {
+	return math.Atan2(math.Sin(l)*math.Cos(obliquity)-math.Tan(b)*math.Sin(obliquity), math.Cos(l))
+}
+
+// siderealTime returns the local sidereal time d days after J2000 at west
+// longitude lw.
+func siderealTime(d, lw float64) float64 {
+	return rad*(280.16+360.9856235*d) - lw
+}
+
+// altitude returns the altitude of a body at hour angle h and declination
+// dec seen from latitude phi.
+func altitude(h, phi, dec float64) float64 {
+	return math.Asin(math.Sin(phi)*math.Sin(dec) + math.Cos(phi)*math.Cos(dec)*math.Cos(h))
+}
+
+// solarMeanAnomaly returns the mean anomaly of the sun d days after J2000.
+func solarMeanAnomaly(d float64) float64 {
+	return rad * (357.5291 + 0.98560028*d)
+}
+
+// eclipticLongitude returns the ecliptic longitude of the sun at mean
+// anomaly ma, allowing for the equation of the center and the perihelion
+// of the earth.
+func eclipticLongitude(ma float64) float64 {
+	center := rad * (1.9148*math.Sin(ma) + 0.02*math.Sin(2*ma) + 0.0003*math.Sin(3*ma))
+	perihelion := rad * 102.9372
+	return ma + center + perihelion + math.Pi
+}
+
+// equatorial represents a position in equatorial coordinates, along with a
+// distance in km for the moon.
+type equatorial struct {
+	ra, dec, dist float64
+}
+
+// sunCoords returns the position of the sun d days after J2000.
+func sunCoords(d float64) equatorial {
+	l := eclipticLongitude(solarMeanAnomaly(d))
+	return equatorial{ra: rightAscension(l, 0), dec: declination(l, 0)}
+}
+
+// moonCoords returns the position of the moon d days after J2000.
+func moonCoords(d float64) equatorial {
+	lon := rad * (218.316 + 13.176396*d)
+	ma := rad * (134.963 + 13.064993*d)
+	f := rad * (93.272 + 13.229350*d)
+
+	l := lon + rad*6.289*math.Sin(ma)
+	b := rad * 5.128 * math.Sin(f)
+	return equatorial{
+		ra:   rightAscension(l, b),
+		dec:  declination(l, b),
+		dist: 385001 - 20905*math.Cos(ma),
+	}
+}
+
+// approxTransit returns the approximate Julian day, relative to J2000, on
+// which the sun is at hour angle ht in Julian cycle n at west longitude lw.
+func approxTransit(ht, lw, n float64) float64 {
+	return julianOffset + (ht+lw)/(2*math.Pi) + n
+}
+
+// solarTransit returns the Julian date, in universal time, at which the sun
+// reaches the hour angle of approximate day ds, correcting for the equation
+// of time.
+func solarTransit(ds, ma, l float64) float64 {
+	return julian2000 + ds + 0.0053*math.Sin(ma) - 0.0069*math.Sin(2*l) - deltaT
+}
+
+// hourAngle returns the hour angle at which the sun, at declination dec,
+// reaches altitude h seen from latitude phi, or NaN if it never does.
+func hourAngle(h, phi, dec float64) float64 {
+	return math.Acos((math.Sin(h) - math.Sin(phi)*math.Sin(dec)) / (math.Cos(phi) * math.Cos(dec)))
+}
@@ -0,0 +1,255 @@
+package astro_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather/astro"
+)
+
+// Almanac times are published to the minute, so calculated times are
+// compared to within two minutes.
+const timeTolerance = 2 * time.Minute
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	return loc
+}
+
+func closeInTime(want, got time.Time) bool {
+	d := want.Sub(got)
+	return d > -timeTolerance && d < timeTolerance
+}
+
+func TestSunMatchesAlmanac(t *testing.T) {
+	t.Parallel()
+	london := mustLoadLocation(t, "Europe/London")
+	chicago := mustLoadLocation(t, "America/Chicago")
+	testCases := map[string]struct {
+		date     time.Time
+		lat, lon float64
+		event    func(astro.SunTimes) time.Time
+		want     time.Time
+	}{
+		"London sunrise at the solstice": {
+			date: time.Date(2021, 6, 21, 0, 0, 0, 0, london), lat: 51.5074, lon: -0.1278,
+			event: func(s astro.SunTimes) time.Time { return s.Sunrise },
+			want:  time.Date(2021, 6, 21, 4, 43, 0, 0, london),
+		},
+		"London sunset at the solstice": {
+			date: time.Date(2021, 6, 21, 0, 0, 0, 0, london), lat: 51.5074, lon: -0.1278,
+			event: func(s astro.SunTimes) time.Time { return s.Sunset },
+			want:  time.Date(2021, 6, 21, 21, 21, 0, 0, london),
+		},
+		"London solar noon at the solstice": {
+			date: time.Date(2021, 6, 21, 0, 0, 0, 0, london), lat: 51.5074, lon: -0.1278,
+			event: func(s astro.SunTimes) time.Time { return s.SolarNoon },
+			want:  time.Date(2021, 6, 21, 13, 2, 0, 0, london),
+		},
+		"sunrise reported by OpenWeather": {
+			date: time.Date(2021, 5, 18, 0, 0, 0, 0, chicago), lat: 33.44, lon: -94.04,
+			event: func(s astro.SunTimes) time.Time { return s.Sunrise },
+			want:  time.Unix(1621336428, 0),
+		},
+		"sunset reported by OpenWeather": {
+			date: time.Date(2021, 5, 18, 0, 0, 0, 0, chicago), lat: 33.44, lon: -94.04,
+			event: func(s astro.SunTimes) time.Time { return s.Sunset },
+			want:  time.Unix(1621386699, 0),
+		},
+		"date far east of its time zone": {
+			date: time.Date(2021, 3, 20, 0, 0, 0, 0, time.UTC), lat: -33.87, lon: 151.21,
+			event: func(s astro.SunTimes) time.Time { return s.SolarNoon },
+			want:  time.Date(2021, 3, 20, 2, 3, 0, 0, time.UTC),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s, err := astro.Sun(tc.date, tc.lat, tc.lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tc.event(s); !closeInTime(tc.want, got) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSunEventsAreInOrder(t *testing.T) {
+	t.Parallel()
+	s, err := astro.Sun(time.Date(2021, 3, 20, 0, 0, 0, 0, time.UTC), 40.71, -74.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []time.Time{
+		s.AstronomicalDawn, s.NauticalDawn, s.CivilDawn, s.Sunrise, s.GoldenHourEnd,
+		s.SolarNoon,
+		s.GoldenHourStart, s.Sunset, s.CivilDusk, s.NauticalDusk, s.AstronomicalDusk,
+	}
+	for i := 1; i < len(events); i++ {
+		if !events[i-1].Before(events[i]) {
+			t.Fatalf("event %d at %v is not before event %d at %v", i-1, events[i-1], i, events[i])
+		}
+	}
+	if s.DayLength < 12*time.Hour || s.DayLength > 12*time.Hour+15*time.Minute {
+		t.Fatalf("want about 12 hours of daylight at the equinox, got %v", s.DayLength)
+	}
+}
+
+func TestSunMissingEvents(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		date          time.Time
+		lat, lon      float64
+		wantDayLength time.Duration
+		missing       func(astro.SunTimes) time.Time
+	}{
+		"midnight sun in Tromsø": {
+			date: time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC), lat: 69.65, lon: 18.96,
+			wantDayLength: 24 * time.Hour,
+			missing:       func(s astro.SunTimes) time.Time { return s.Sunset },
+		},
+		"polar night in Tromsø": {
+			date: time.Date(2021, 12, 21, 0, 0, 0, 0, time.UTC), lat: 69.65, lon: 18.96,
+			wantDayLength: 0,
+			missing:       func(s astro.SunTimes) time.Time { return s.Sunrise },
+		},
+		"no astronomical night in a London summer": {
+			date: time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC), lat: 51.5074, lon: -0.1278,
+			wantDayLength: 16*time.Hour + 38*time.Minute,
+			missing:       func(s astro.SunTimes) time.Time { return s.AstronomicalDusk },
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s, err := astro.Sun(tc.date, tc.lat, tc.lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tc.missing(s); !got.IsZero() {
+				t.Fatalf("want no event, got %v", got)
+			}
+			if d := s.DayLength - tc.wantDayLength; d < -timeTolerance || d > timeTolerance {
+				t.Fatalf("want day length %v, got %v", tc.wantDayLength, s.DayLength)
+			}
+		})
+	}
+}
+
+func TestSunInvalidCoordinates(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		lat, lon float64
+	}{
+		"latitude too far north": {lat: 91, lon: 0},
+		"longitude too far west": {lat: 0, lon: -181},
+		"latitude not a number":  {lat: math.NaN(), lon: 0},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := astro.Sun(time.Now(), tc.lat, tc.lon); err == nil {
+				t.Fatalf("Sun(%v, %v) did not return an expected error", tc.lat, tc.lon)
+			}
+			if _, err := astro.SunAltitude(time.Now(), tc.lat, tc.lon); err == nil {
+				t.Fatalf("SunAltitude(%v, %v) did not return an expected error", tc.lat, tc.lon)
+			}
+		})
+	}
+}
+
+func TestSunAltitudeAtEvents(t *testing.T) {
+	t.Parallel()
+	const lat, lon = 40.71, -74.01
+	s, err := astro.Sun(time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC), lat, lon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]struct {
+		at   time.Time
+		want float64
+	}{
+		"sunrise":          {at: s.Sunrise, want: -0.833},
+		"civil dusk":       {at: s.CivilDusk, want: -6},
+		"golden hour end":  {at: s.GoldenHourEnd, want: 6},
+		"nautical dawn":    {at: s.NauticalDawn, want: -12},
+		"astronomical end": {at: s.AstronomicalDusk, want: -18},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := astro.SunAltitude(tc.at, lat, lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tc.want-got) > 0.5 {
+				t.Fatalf("want altitude %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMoonMatchesPublishedPhases(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		at           time.Time
+		wantPhase    float64
+		wantFraction float64
+		wantName     string
+	}{
+		"new moon":      {at: time.Date(2021, 5, 11, 19, 0, 0, 0, time.UTC), wantPhase: 0, wantFraction: 0, wantName: "new moon"},
+		"first quarter": {at: time.Date(2021, 5, 19, 19, 13, 0, 0, time.UTC), wantPhase: 0.25, wantFraction: 0.5, wantName: "first quarter"},
+		"full moon":     {at: time.Date(2021, 5, 26, 11, 14, 0, 0, time.UTC), wantPhase: 0.5, wantFraction: 1, wantName: "full moon"},
+		"last quarter":  {at: time.Date(2021, 6, 2, 7, 24, 0, 0, time.UTC), wantPhase: 0.75, wantFraction: 0.5, wantName: "last quarter"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := astro.Moon(tc.at)
+			// The phase wraps around at the new moon.
+			d := math.Abs(tc.wantPhase - got.Phase)
+			if d > 0.5 {
+				d = 1 - d
+			}
+			if d > 0.02 {
+				t.Fatalf("want phase %v, got %v", tc.wantPhase, got.Phase)
+			}
+			if math.Abs(tc.wantFraction-got.Fraction) > 0.03 {
+				t.Fatalf("want fraction %v, got %v", tc.wantFraction, got.Fraction)
+			}
+			if name := astro.PhaseName(got.Phase); tc.wantName != name {
+				t.Fatalf("want %q, got %q", tc.wantName, name)
+			}
+		})
+	}
+}
+
+func TestPhaseName(t *testing.T) {
+	t.Parallel()
+	testCases := map[float64]string{
+		0:     "new moon",
+		0.1:   "waxing crescent",
+		0.21:  "first quarter",
+		0.4:   "waxing gibbous",
+		0.5:   "full moon",
+		0.6:   "waning gibbous",
+		0.75:  "last quarter",
+		0.9:   "waning crescent",
+		0.97:  "new moon",
+		1:     "new moon",
+		-0.25: "last quarter",
+	}
+
+	for phase, want := range testCases {
+		if got := astro.PhaseName(phase); want != got {
+			t.Errorf("PhaseName(%v): want %q, got %q", phase, want, got)
+		}
+	}
+}
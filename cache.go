@@ -0,0 +1,152 @@
+package weather
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by a Client whose RateLimiter has no requests
+// left to allow.
+var ErrRateLimited = errors.New("rate limit exceeded, try again later")
+
+// Cache represents an in-memory store of API responses that expire after a
+// fixed time to live. It is safe for concurrent use, so a single Cache can
+// be shared by every user of a Client.
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+// cacheEntry represents a cached response and the time it expires.
+type cacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewCache accepts a time to live and returns a Cache whose entries expire
+// that long after they are stored.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+}
+
+// Get returns the data stored for key and true, or nil and false if nothing
+// is stored for key or if it has expired.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.data, true
+}
+
+// Set stores data for key until the Cache's time to live has elapsed.
+func (c *Cache) Set(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{data: data, expires: now.Add(c.ttl)}
+}
+
+// RateLimiter represents a token bucket limiting how many requests are made
+// to an API within a period of time. It is safe for concurrent use.
+type RateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewRateLimiter accepts a number of requests and a period of time, and
+// returns a RateLimiter allowing up to n requests per period. The full
+// allowance is available immediately and is replenished evenly over the
+// period.
+func NewRateLimiter(n int, per time.Duration) *RateLimiter {
+	r := &RateLimiter{
+		capacity: float64(n),
+		tokens:   float64(n),
+		rate:     float64(n) / per.Seconds(),
+		now:      time.Now,
+	}
+	r.last = r.now()
+	return r
+}
+
+// Allow reports whether a request may be made now, consuming one request
+// from the allowance if so.
+func (r *RateLimiter) Allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.capacity {
+		r.tokens = r.capacity
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// LimitProvider accepts a Provider and a RateLimiter and returns a Provider
+// that requests data from p only while r allows it, failing with
+// ErrRateLimited otherwise. It gives providers with no rate limit of their
+// own, like Fallback and Consensus, the same protection as a Client.
+func LimitProvider(p Provider, r *RateLimiter) Provider {
+	return limitedProvider{p, r}
+}
+
+// limitedProvider represents a Provider whose requests are limited by a
+// RateLimiter.
+type limitedProvider struct {
+	p Provider
+	r *RateLimiter
+}
+
+// CurrentObservation returns the current observation from the wrapped
+// Provider, or ErrRateLimited if the RateLimiter does not allow a request.
+func (l limitedProvider) CurrentObservation(location string, units Units) (Observation, error) {
+	if !l.r.Allow() {
+		return Observation{}, ErrRateLimited
+	}
+	return l.p.CurrentObservation(location, units)
+}
+
+// DailyForecast returns the daily forecasts from the wrapped Provider, or
+// ErrRateLimited if the RateLimiter does not allow a request.
+func (l limitedProvider) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	if !l.r.Allow() {
+		return nil, ErrRateLimited
+	}
+	return l.p.DailyForecast(location, units)
+}
+
+// Geocode returns the location from the wrapped Provider, or ErrRateLimited
+// if the RateLimiter does not allow a request.
+func (l limitedProvider) Geocode(location string) (Location, error) {
+	if !l.r.Allow() {
+		return Location{}, ErrRateLimited
+	}
+	return l.p.Geocode(location)
+}
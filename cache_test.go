@@ -0,0 +1,139 @@
+package weather_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+)
+
+func TestCacheExpiresEntriesAfterTTL(t *testing.T) {
+	t.Parallel()
+	c := weather.NewCache(50 * time.Millisecond)
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("want empty cache to miss")
+	}
+	c.Set("key", []byte("value"))
+	got, ok := c.Get("key")
+	if !ok || string(got) != "value" {
+		t.Fatalf("want cache to hit with %q, got %q (hit: %v)", "value", got, ok)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("want expired entry to miss")
+	}
+}
+
+func TestRateLimiterAllowsUpToItsLimit(t *testing.T) {
+	t.Parallel()
+	r := weather.NewRateLimiter(2, time.Hour)
+	for i := 0; i < 2; i++ {
+		if !r.Allow() {
+			t.Fatalf("want request %d to be allowed", i+1)
+		}
+	}
+	if r.Allow() {
+		t.Fatal("want request over the limit to be denied")
+	}
+}
+
+func TestClientWithCacheAndLimiter(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"weather": [{"description": "few clouds"}], "main": {"temp": 52.72}}`)
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	client.Cache = weather.NewCache(time.Hour)
+	client.Limiter = weather.NewRateLimiter(2, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Current("London", "imperial"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("want cached responses to be reused after 1 request, got %d requests", got)
+	}
+
+	if _, err := client.Current("Paris", "imperial"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Current("Tampa", "imperial")
+	if !errors.Is(err, weather.ErrRateLimited) {
+		t.Fatalf("want ErrRateLimited once the limit is reached, got %v", err)
+	}
+}
+
+func TestClientCacheServesEveryUnitSystem(t *testing.T) {
+	t.Parallel()
+	currentData, err := ioutil.ReadFile("testdata/currentWeatherAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	var requests int32
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if got := r.URL.Query().Get("units"); got != "metric" {
+			t.Errorf("want request in metric units, got %q", got)
+		}
+		w.Write(currentData)
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	client.Cache = weather.NewCache(time.Hour)
+
+	testCases := []struct {
+		units weather.Units
+		want  string
+	}{
+		{units: weather.Imperial, want: "few clouds, 52.72 F, humidity 47%"},
+		{units: weather.Metric, want: "few clouds, 11.51 C, humidity 47%"},
+		{units: weather.Standard, want: "few clouds, 284.66 K, humidity 47%"},
+	}
+	for _, tc := range testCases {
+		got, err := weather.CurrentConditions(client, "London", tc.units)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.want != got {
+			t.Errorf("%s: want %q, got %q", tc.units, tc.want, got)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("want 1 request for every unit system, got %d requests", got)
+	}
+}
+
+func TestLimitProvider(t *testing.T) {
+	t.Parallel()
+	p := weather.LimitProvider(fakeProvider{obs: weather.Observation{Summary: "few clouds"}}, weather.NewRateLimiter(2, time.Hour))
+	if _, err := p.CurrentObservation("London", weather.Metric); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.DailyForecast("London", weather.Metric); err != nil {
+		t.Fatal(err)
+	}
+	_, err := p.Geocode("London")
+	if !errors.Is(err, weather.ErrRateLimited) {
+		t.Fatalf("want ErrRateLimited once the limit is reached, got %v", err)
+	}
+}
@@ -0,0 +1,647 @@
+package weather
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The size of a chart in pixels, and the margins around its plot area.
+const (
+	chartWidth  = 800
+	chartHeight = 400
+	chartLeft   = 64
+	chartRight  = chartWidth - 64
+	chartTop    = 64
+	chartBottom = chartHeight - 56
+)
+
+// The colors charts are drawn in.
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartGrid       = color.RGBA{0xdd, 0xe1, 0xe6, 0xff}
+	chartInk        = color.RGBA{0x33, 0x3a, 0x44, 0xff}
+	chartTemp       = color.RGBA{0xe4, 0x57, 0x2e, 0xff}
+	chartPrecip     = color.RGBA{0x3a, 0x7b, 0xd5, 0xff}
+	chartRange      = color.RGBA{0xf0, 0xa3, 0x5e, 0xff}
+)
+
+// HourlyChart represents a chart of hourly forecasts, with a line for the
+// temperature and a bar for the precipitation expected each hour. Hours are
+// labeled in the time zone of their times. If no hour reports an amount of
+// precipitation, the bars show its probability instead.
+type HourlyChart struct {
+	Title     string
+	Forecasts []HourlyForecast
+	// Units are the measurement units the forecasts were requested in, and
+	// Preferences the units they are shown in, by default those of Units.
+	Units       Units
+	Preferences Preferences
+	// Locale is the number and date format the chart is labeled in.
+	Locale Locale
+}
+
+// EncodeSVG writes the HourlyChart to w as an SVG image. An error is
+// returned if writing to w fails.
+func (c HourlyChart) EncodeSVG(w io.Writer) error {
+	return c.chart().encodeSVG(w)
+}
+
+// EncodePNG writes the HourlyChart to w as a PNG image. An error is
+// returned if writing to w fails.
+func (c HourlyChart) EncodePNG(w io.Writer) error {
+	return c.chart().encodePNG(w)
+}
+
+// chart returns the shapes the HourlyChart is drawn with.
+func (c HourlyChart) chart() chart {
+	var ch chart
+	ch.begin(c.Title)
+	if len(c.Forecasts) == 0 {
+		ch.noData()
+		return ch
+	}
+	prefs := c.Preferences
+	if prefs == (Preferences{}) {
+		prefs = c.Units.Preferences()
+	}
+	temps := make([]float64, len(c.Forecasts))
+	precips := make([]float64, len(c.Forecasts))
+	amounts := false
+	for i, f := range c.Forecasts {
+		units := f.Units
+		if units == "" {
+			units = c.Units
+		}
+		temps[i] = Temperature{f.Temp, units.Temperature()}.In(prefs.Temperature).Value
+		precips[i] = Precipitation{f.Precip, Millimeters}.In(prefs.Precipitation).Value
+		if f.Precip > 0 {
+			amounts = true
+		}
+	}
+	precipLabel := fmt.Sprintf("precipitation (%s)", prefs.Precipitation)
+	if !amounts {
+		for i, f := range c.Forecasts {
+			precips[i] = f.PrecipProb * 100
+		}
+		precipLabel = "precipitation (%)"
+	}
+
+	min, max := minMax(temps)
+	tempAxis := ch.axis(chartTicks(min, max, 5), c.Locale, true)
+	_, maxPrecip := minMax(precips)
+	if !amounts {
+		maxPrecip = 100
+	}
+	precipAxis := ch.axis(chartTicks(0, maxPrecip, 4), c.Locale, false)
+
+	slot := float64(chartRight-chartLeft) / float64(len(c.Forecasts))
+	every := labelInterval(len(c.Forecasts), 12)
+	var line []chartPoint
+	for i, f := range c.Forecasts {
+		x := chartLeft + slot*(float64(i)+0.5)
+		if precips[i] > 0 {
+			ch.rect("precip", chartPrecip, x-slot*0.35, precipAxis(precips[i]), x+slot*0.35, chartBottom)
+		}
+		line = append(line, chartPoint{x, tempAxis(temps[i])})
+		if i%every == 0 {
+			ch.text("axis", chartInk, x, chartBottom+18, "middle", f.Time.Format("15:04"))
+			if i == 0 || f.Time.Hour() < every {
+				ch.text("axis", chartInk, x, chartBottom+34, "middle", c.Locale.FormatDate(f.Time))
+			}
+		}
+	}
+	ch.line("temp", chartTemp, 2, line...)
+	ch.text("legend temp", chartTemp, chartLeft, chartTop-14, "start",
+		fmt.Sprintf("temperature (%s)", prefs.Temperature))
+	ch.text("legend precip", chartPrecip, chartRight, chartTop-14, "end", precipLabel)
+	return ch
+}
+
+// DailyChart represents a chart of daily forecasts, with a bar for each day
+// from its low to its high temperature.
+type DailyChart struct {
+	Title     string
+	Forecasts []DayForecast
+	// Units are the measurement units the forecasts were requested in, and
+	// Preferences the units they are shown in, by default those of Units.
+	Units       Units
+	Preferences Preferences
+	// Locale is the number and date format the chart is labeled in.
+	Locale Locale
+}
+
+// EncodeSVG writes the DailyChart to w as an SVG image. An error is
+// returned if writing to w fails.
+func (c DailyChart) EncodeSVG(w io.Writer) error {
+	return c.chart().encodeSVG(w)
+}
+
+// EncodePNG writes the DailyChart to w as a PNG image. An error is
+// returned if writing to w fails.
+func (c DailyChart) EncodePNG(w io.Writer) error {
+	return c.chart().encodePNG(w)
+}
+
+// chart returns the shapes the DailyChart is drawn with.
+func (c DailyChart) chart() chart {
+	var ch chart
+	ch.begin(c.Title)
+	if len(c.Forecasts) == 0 {
+		ch.noData()
+		return ch
+	}
+	prefs := c.Preferences
+	if prefs == (Preferences{}) {
+		prefs = c.Units.Preferences()
+	}
+	highs := make([]float64, len(c.Forecasts))
+	lows := make([]float64, len(c.Forecasts))
+	for i, f := range c.Forecasts {
+		units := f.Units
+		if units == "" {
+			units = c.Units
+		}
+		highs[i] = Temperature{f.High, units.Temperature()}.In(prefs.Temperature).Value
+		lows[i] = Temperature{f.Low, units.Temperature()}.In(prefs.Temperature).Value
+	}
+	min, _ := minMax(lows)
+	_, max := minMax(highs)
+	axis := ch.axis(chartTicks(min, max, 5), c.Locale, true)
+
+	slot := float64(chartRight-chartLeft) / float64(len(c.Forecasts))
+	bar := math.Min(slot/3, 40)
+	for i, f := range c.Forecasts {
+		x := chartLeft + slot*(float64(i)+0.5)
+		ch.rect("range", chartRange, x-bar/2, axis(highs[i]), x+bar/2, axis(lows[i]))
+		ch.text("high", chartTemp, x, axis(highs[i])-6, "middle", c.Locale.FormatFloat(highs[i], 0))
+		ch.text("low", chartPrecip, x, axis(lows[i])+16, "middle", c.Locale.FormatFloat(lows[i], 0))
+		ch.text("axis", chartInk, x, chartBottom+18, "middle", c.Locale.FormatWeekday(f.Date))
+		ch.text("axis", chartInk, x, chartBottom+34, "middle", c.Locale.FormatDate(f.Date))
+	}
+	ch.text("legend", chartInk, chartLeft, chartTop-14, "start",
+		fmt.Sprintf("temperature (%s)", prefs.Temperature))
+	return ch
+}
+
+// chartPoint is a point on a chart, in pixels from its top left corner.
+type chartPoint struct {
+	x, y float64
+}
+
+// chartShapeKind is the kind of a chartShape.
+type chartShapeKind int
+
+const (
+	shapeRect chartShapeKind = iota
+	shapeLine
+	shapeText
+)
+
+// chartShape is a rectangle between two corners, a line through a number of
+// points, or text anchored at a point. Its class names what it shows, so
+// stylesheets can restyle SVG charts.
+type chartShape struct {
+	kind   chartShapeKind
+	class  string
+	color  color.RGBA
+	points []chartPoint
+	width  float64 // of lines
+	text   string
+	anchor string // "start", "middle" or "end", for text
+	scale  int    // of the PNG font, for text
+}
+
+// chart is a chart drawn as a list of shapes, in order, that can be encoded
+// as SVG or PNG.
+type chart struct {
+	shapes []chartShape
+}
+
+// begin draws the background of the chart and its title.
+func (ch *chart) begin(title string) {
+	ch.rect("background", chartBackground, 0, 0, chartWidth, chartHeight)
+	if title != "" {
+		ch.shapes = append(ch.shapes, chartShape{
+			kind: shapeText, class: "title", color: chartInk,
+			points: []chartPoint{{chartWidth / 2, 30}}, text: title, anchor: "middle", scale: 2,
+		})
+	}
+}
+
+// noData draws a note that there is nothing to chart.
+func (ch *chart) noData() {
+	ch.text("axis", chartInk, chartWidth/2, chartHeight/2, "middle", "no data")
+}
+
+func (ch *chart) rect(class string, c color.RGBA, x0, y0, x1, y1 float64) {
+	ch.shapes = append(ch.shapes, chartShape{kind: shapeRect, class: class, color: c, points: []chartPoint{{x0, y0}, {x1, y1}}})
+}
+
+func (ch *chart) line(class string, c color.RGBA, width float64, points ...chartPoint) {
+	ch.shapes = append(ch.shapes, chartShape{kind: shapeLine, class: class, color: c, points: points, width: width})
+}
+
+func (ch *chart) text(class string, c color.RGBA, x, y float64, anchor, text string) {
+	ch.shapes = append(ch.shapes, chartShape{kind: shapeText, class: class, color: c, points: []chartPoint{{x, y}}, text: text, anchor: anchor, scale: 1})
+}
+
+// axis draws a vertical axis with the given ticks on the left of the plot
+// area, with grid lines, or on its right, and returns the function mapping
+// a value to its height on the chart.
+func (ch *chart) axis(ticks []float64, l Locale, left bool) func(float64) float64 {
+	lo, hi := ticks[0], ticks[len(ticks)-1]
+	y := func(v float64) float64 {
+		return chartBottom - (v-lo)/(hi-lo)*(chartBottom-chartTop)
+	}
+	// Label with as many decimals as the step has, so that a step of 2.5
+	// is not rounded away.
+	prec := 0
+	if len(ticks) > 1 {
+		step := ticks[1] - ticks[0]
+		for prec < 6 && math.Abs(step-math.Round(step)) > 1e-9 {
+			step *= 10
+			prec++
+		}
+	}
+	for _, v := range ticks {
+		label := l.FormatFloat(v, prec)
+		if left {
+			ch.line("grid", chartGrid, 1, chartPoint{chartLeft, y(v)}, chartPoint{chartRight, y(v)})
+			ch.text("axis", chartInk, chartLeft-8, y(v)+4, "end", label)
+		} else {
+			ch.text("axis", chartInk, chartRight+8, y(v)+4, "start", label)
+		}
+	}
+	return y
+}
+
+// chartTicks returns about n evenly spaced round values, the first at or
+// below min and the last at or above max.
+func chartTicks(min, max float64, n int) []float64 {
+	if max-min < 1e-9 {
+		min, max = min-1, max+1
+	}
+	raw := (max - min) / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * mag
+	for _, m := range []float64{1, 2, 2.5, 5} {
+		if m*mag >= raw {
+			step = m * mag
+			break
+		}
+	}
+	start := math.Floor(min/step) * step
+	var ticks []float64
+	for i := 0; ; i++ {
+		v := start + float64(i)*step
+		if math.Abs(v) < step/1e6 {
+			v = 0
+		}
+		ticks = append(ticks, v)
+		if v >= max-step/1e6 {
+			return ticks
+		}
+	}
+}
+
+// labelInterval returns how many of n values to skip between labels so
+// there are at most max, preferring intervals that divide a day evenly.
+func labelInterval(n, max int) int {
+	for _, every := range []int{1, 2, 3, 4, 6, 12, 24} {
+		if n <= every*max {
+			return every
+		}
+	}
+	return (n + max - 1) / max
+}
+
+// minMax returns the smallest and largest of values.
+func minMax(values []float64) (float64, float64) {
+	min, max := values[0], values[0]
+	for _, v := range values[1:] {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	return min, max
+}
+
+// encodeSVG writes the chart to w as an SVG image.
+func (ch chart) encodeSVG(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	for _, s := range ch.shapes {
+		switch s.kind {
+		case shapeRect:
+			r := s.bounds()
+			fmt.Fprintf(&b, `<rect class="%s" x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+				s.class, svgNum(r.x0), svgNum(r.y0), svgNum(r.x1-r.x0), svgNum(r.y1-r.y0), svgColor(s.color))
+		case shapeLine:
+			points := make([]string, len(s.points))
+			for i, p := range s.points {
+				points[i] = svgNum(p.x) + "," + svgNum(p.y)
+			}
+			fmt.Fprintf(&b, `<polyline class="%s" points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round"/>`+"\n",
+				s.class, strings.Join(points, " "), svgColor(s.color), svgNum(s.width))
+		case shapeText:
+			size := ""
+			if s.scale > 1 {
+				size = fmt.Sprintf(` font-size="%d"`, 12*s.scale*3/4)
+			}
+			fmt.Fprintf(&b, `<text class="%s" x="%s" y="%s" text-anchor="%s" fill="%s"%s>%s</text>`+"\n",
+				s.class, svgNum(s.points[0].x), svgNum(s.points[0].y), s.anchor, svgColor(s.color), size, html.EscapeString(s.text))
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgNum formats a coordinate with at most one decimal place.
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// svgColor formats c as a hexadecimal color.
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// chartRect is a rectangle with its corners ordered.
+type chartRect struct {
+	x0, y0, x1, y1 float64
+}
+
+// bounds returns the rectangle a rect shape covers.
+func (s chartShape) bounds() chartRect {
+	a, b := s.points[0], s.points[1]
+	return chartRect{math.Min(a.x, b.x), math.Min(a.y, b.y), math.Max(a.x, b.x), math.Max(a.y, b.y)}
+}
+
+// encodePNG writes the chart to w as a PNG image, drawing text with a small
+// built-in bitmap font.
+func (ch chart) encodePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	for _, s := range ch.shapes {
+		switch s.kind {
+		case shapeRect:
+			r := s.bounds()
+			fill(img, int(math.Round(r.x0)), int(math.Round(r.y0)), int(math.Round(r.x1)), int(math.Round(r.y1)), s.color)
+		case shapeLine:
+			for i := 1; i < len(s.points); i++ {
+				drawSegment(img, s.points[i-1], s.points[i], s.width, s.color)
+			}
+		case shapeText:
+			drawText(img, s.points[0], s.anchor, s.scale, s.text, s.color)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// fill fills the rectangle from (x0, y0) to (x1, y1) of img with c.
+func fill(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Over)
+}
+
+// drawSegment draws a line of the given width from a to b on img.
+func drawSegment(img *image.RGBA, a, b chartPoint, width float64, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(b.x-a.x), math.Abs(b.y-a.y)) * 2))
+	if steps == 0 {
+		steps = 1
+	}
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := a.x + (b.x-a.x)*t
+		y := a.y + (b.y-a.y)*t
+		fill(img, int(math.Round(x-half)), int(math.Round(y-half)), int(math.Round(x+half)), int(math.Round(y+half)), c)
+	}
+}
+
+// drawText draws text on img with its baseline at p, aligned to p by
+// anchor, in the bitmap font magnified by scale. Lower case letters are
+// drawn as capitals, accented letters without their accents (so "févr."
+// reads "FEVR.") and other characters the font lacks as question marks.
+func drawText(img *image.RGBA, p chartPoint, anchor string, scale int, text string, c color.RGBA) {
+	runes := []rune(strings.ToUpper(text))
+	advance := (glyphWidth + 1) * scale
+	width := len(runes)*advance - scale
+	x := int(math.Round(p.x))
+	switch anchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+	top := int(math.Round(p.y)) - glyphHeight*scale
+	for _, r := range runes {
+		if base, ok := fontAccents[r]; ok {
+			r = base
+		}
+		g, ok := font[r]
+		if !ok {
+			g = font['?']
+		}
+		for row, bits := range g {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) != 0 {
+					px, py := x+col*scale, top+row*scale
+					fill(img, px, py, px+scale, py+scale, c)
+				}
+			}
+		}
+		x += advance
+	}
+}
+
+// fontAccents maps the accented capitals of the languages charts are
+// labeled in to the letters the bitmap font draws them as.
+var fontAccents = map[rune]rune{
+	'À': 'A', 'Á': 'A', 'Â': 'A', 'Ä': 'A',
+	'Ç': 'C',
+	'È': 'E', 'É': 'E', 'Ê': 'E', 'Ë': 'E',
+	'Ì': 'I', 'Í': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ñ': 'N',
+	'Ò': 'O', 'Ó': 'O', 'Ô': 'O', 'Ö': 'O',
+	'Ù': 'U', 'Ú': 'U', 'Û': 'U', 'Ü': 'U',
+}
+
+// The size of a glyph of the bitmap font, in pixels.
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// font is a 5x7 bitmap font of capital letters, digits and the punctuation
+// charts are labeled with. Each row of a glyph is a bit mask, with the
+// leftmost pixel the most significant bit.
+var font = map[rune][glyphHeight]uint8{
+	' ':  {},
+	'0':  {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1':  {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3':  {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4':  {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5':  {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6':  {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8':  {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9':  {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'A':  {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C':  {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D':  {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G':  {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H':  {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I':  {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J':  {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K':  {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L':  {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M':  {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N':  {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S':  {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T':  {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W':  {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X':  {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y':  {0b10001, 0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Z':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'-':  {0, 0, 0, 0b11111, 0, 0, 0},
+	'.':  {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	',':  {0, 0, 0, 0, 0b01100, 0b00100, 0b01000},
+	':':  {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'%':  {0b11000, 0b11001, 0b00010, 0b00100, 0b01000, 0b10011, 0b00011},
+	'/':  {0, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0},
+	'(':  {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')':  {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'+':  {0, 0b00100, 0b00100, 0b11111, 0b00100, 0b00100, 0},
+	'\'': {0b01100, 0b00100, 0b01000, 0, 0, 0, 0},
+	'?':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+}
+
+// ChartCLI accepts a slice of command line flags and arguments, including
+// the subcommand name, and writes a chart of the hourly or daily forecasts
+// for a location to the output file, as SVG or PNG depending on its
+// extension. Flags may be given before or after the location. An error is
+// returned if the command line flags and arguments are invalid, if the
+// provider cannot forecast hourly when an hourly chart is asked for, if
+// the forecasts cannot be retrieved or if the file cannot be written.
+func ChartCLI(args []string) error {
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather chart <location> [-o=<file>.{svg|png}] [-chart={hourly|daily}] [-units={standard|metric|imperial}[,<unit>...]] [-provider=<name>] [-lang=<language>] [-tz=<zone>]\n\n"))
+		fs.PrintDefaults()
+	}
+	out := fs.String("o", "forecast.svg", "the file to write the chart to, as SVG or PNG depending on its extension")
+	kind := fs.String("chart", "hourly", "the chart to draw, one of: hourly (temperature and precipitation), daily (high and low temperatures)")
+	unitsFlag := fs.String("units", "imperial", "the units to use, one of: standard, metric, imperial, optionally followed by units to show instead of the system's own (e.g. 'metric,mph')")
+	provider := fs.String("provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: (or consensus:, for daily charts only)")
+	lang := fs.String("lang", "", "the language to label the chart in (e.g. 'de', 'fr'), by default taken from the LANG environment variable")
+	tz := fs.String("tz", "Local", "the time zone to label hours in (e.g. 'America/Chicago', 'UTC')")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	location := fs.Arg(0)
+	if fs.NArg() > 1 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return fmt.Errorf("unexpected argument %q after location", fs.Arg(0))
+		}
+	}
+	if location == "" {
+		return errors.New("positional argument for location must be given (e.g. 'london', 'tampa,us', etc.)")
+	}
+	if *kind != "hourly" && *kind != "daily" {
+		return errors.New("chart flag must be one of: hourly, daily")
+	}
+	ext := strings.ToLower(filepath.Ext(*out))
+	if ext != ".svg" && ext != ".png" {
+		return errors.New("o flag must name a file ending in .svg or .png")
+	}
+	units, prefs, err := ParsePreferences(*unitsFlag)
+	if err != nil {
+		return fmt.Errorf("units flag: %w", err)
+	}
+	locale := LocaleFromEnv()
+	if *lang != "" {
+		if locale, err = ParseLocale(*lang); err != nil {
+			return fmt.Errorf("lang flag: %w", err)
+		}
+	}
+	zone, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("tz flag: %w", err)
+	}
+
+	p, err := providerFromName(*provider, locale.Lang)
+	if err != nil {
+		return err
+	}
+	var c interface {
+		EncodeSVG(io.Writer) error
+		EncodePNG(io.Writer) error
+	}
+	switch *kind {
+	case "hourly":
+		hf, ok := p.(HourlyForecaster)
+		if !ok {
+			return fmt.Errorf("provider %q does not forecast hourly, use -chart=daily", *provider)
+		}
+		forecasts, err := hf.HourlyForecast(location, units)
+		if err != nil {
+			return err
+		}
+		for i := range forecasts {
+			forecasts[i].Time = forecasts[i].Time.In(zone)
+		}
+		c = HourlyChart{
+			Title:       "Hourly forecast for " + location,
+			Forecasts:   forecasts,
+			Units:       units,
+			Preferences: prefs,
+			Locale:      locale,
+		}
+	case "daily":
+		forecasts, err := p.DailyForecast(location, units)
+		if err != nil {
+			return err
+		}
+		c = DailyChart{
+			Title:       "Daily forecast for " + location,
+			Forecasts:   forecasts,
+			Units:       units,
+			Preferences: prefs,
+			Locale:      locale,
+		}
+	}
+	if ext == ".png" {
+		return writeFileAtomic(*out, c.EncodePNG)
+	}
+	return writeFileAtomic(*out, c.EncodeSVG)
+}
@@ -0,0 +1,211 @@
+package weather_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testHourlyChart() weather.HourlyChart {
+	temps := []float64{22, 21.5, 21, 20.6, 20.2, 20, 20.4, 21.8, 23.5, 25.1, 26.4, 27.6,
+		28.5, 29.1, 29.4, 29.2, 28.6, 27.7, 26.5, 25.3, 24.4, 23.7, 23.1, 22.6}
+	precip := map[int]float64{13: 0.4, 14: 5.57, 15: 12.93, 16: 2.55, 17: 0.6}
+	var hours []weather.HourlyForecast
+	for i, temp := range temps {
+		hours = append(hours, weather.HourlyForecast{
+			Time:       time.Date(2021, 5, 18, 18+i, 0, 0, 0, time.UTC),
+			Temp:       temp,
+			PrecipProb: 0.5,
+			Precip:     precip[i],
+			Units:      weather.Metric,
+		})
+	}
+	return weather.HourlyChart{
+		Title:       "Hourly forecast for Tampa, FL",
+		Forecasts:   hours,
+		Units:       weather.Metric,
+		Preferences: weather.Imperial.Preferences(),
+	}
+}
+
+func testDailyChart() weather.DailyChart {
+	cal := testCalendar()
+	return weather.DailyChart{
+		Title:       "Daily forecast for " + cal.Location,
+		Forecasts:   cal.Forecasts,
+		Units:       cal.Units,
+		Preferences: cal.Preferences,
+	}
+}
+
+func TestChartEncodeSVG(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		encode func(*bytes.Buffer) error
+		golden string
+	}{
+		"hourly": {
+			encode: func(b *bytes.Buffer) error { return testHourlyChart().EncodeSVG(b) },
+			golden: "testdata/hourly.svg",
+		},
+		"daily": {
+			encode: func(b *bytes.Buffer) error { return testDailyChart().EncodeSVG(b) },
+			golden: "testdata/daily.svg",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			want, err := ioutil.ReadFile(tc.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := tc.encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			if !cmp.Equal(string(want), got) {
+				t.Error(cmp.Diff(string(want), got))
+			}
+		})
+	}
+}
+
+func TestChartEncodePNG(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		encode func(*bytes.Buffer) error
+		// ink is a color the chart must be drawn with somewhere.
+		ink color.RGBA
+	}{
+		"hourly": {
+			encode: func(b *bytes.Buffer) error { return testHourlyChart().EncodePNG(b) },
+			ink:    color.RGBA{0xe4, 0x57, 0x2e, 0xff},
+		},
+		"daily": {
+			encode: func(b *bytes.Buffer) error { return testDailyChart().EncodePNG(b) },
+			ink:    color.RGBA{0xf0, 0xa3, 0x5e, 0xff},
+		},
+		"empty": {
+			encode: func(b *bytes.Buffer) error { return weather.DailyChart{}.EncodePNG(b) },
+			ink:    color.RGBA{0x33, 0x3a, 0x44, 0xff},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := tc.encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("chart is not a valid PNG: %v", err)
+			}
+			if want, got := image.Rect(0, 0, 800, 400), img.Bounds(); want != got {
+				t.Fatalf("want bounds %v, got %v", want, got)
+			}
+			if got := color.RGBAModel.Convert(img.At(0, 0)); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+				t.Errorf("want white background, got %v", got)
+			}
+			if !hasColor(img, tc.ink) {
+				t.Errorf("want some pixels drawn in %v, got none", tc.ink)
+			}
+		})
+	}
+}
+
+// hasColor reports whether any pixel of img is c.
+func hasColor(img image.Image, c color.RGBA) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestHourlyChartShowsProbabilityWithoutAmounts(t *testing.T) {
+	t.Parallel()
+	c := testHourlyChart()
+	for i := range c.Forecasts {
+		c.Forecasts[i].Precip = 0
+	}
+	var buf bytes.Buffer
+	if err := c.EncodeSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "precipitation (%)") {
+		t.Errorf("want precipitation probability axis, got:\n%s", buf.String())
+	}
+}
+
+func TestHourlyChartLabelsFractionalTicks(t *testing.T) {
+	t.Parallel()
+	c := testHourlyChart()
+	c.Preferences = weather.Metric.Preferences()
+	for i := range c.Forecasts {
+		c.Forecasts[i].Precip = 0
+	}
+	c.Forecasts[0].Precip = 10
+	var buf bytes.Buffer
+	if err := c.EncodeSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{">2.5<", ">7.5<"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want tick label %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDailyChartLabelsWeekdaysInLocale(t *testing.T) {
+	t.Parallel()
+	c := testDailyChart()
+	l, err := weather.ParseLocale("es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Locale = l
+	var buf bytes.Buffer
+	if err := c.EncodeSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := c.Locale.FormatWeekday(c.Forecasts[0].Date); !strings.Contains(buf.String(), ">"+want+"<") {
+		t.Errorf("want weekday %q, got:\n%s", want, buf.String())
+	}
+}
+
+func TestChartPNGDrawsAccentsAsPlainLetters(t *testing.T) {
+	t.Parallel()
+	encode := func(title string) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := (weather.DailyChart{Title: title}).EncodePNG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	if bytes.Equal(encode("FEVR."), encode("MARS")) {
+		t.Fatal("titles are not drawn")
+	}
+	if !bytes.Equal(encode("FEVR."), encode("févr.")) {
+		t.Error("accented title is not drawn like its plain letters")
+	}
+}
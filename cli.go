@@ -5,63 +5,309 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
+// RunCLI accepts a slice of command line flags and arguments, including the
+// program name, and dispatches to the subcommand named by the first argument
+// (e.g. "current", "forecast", "rain", "serve"). If no known subcommand is
+// given, the arguments are handled by CurrentWeatherCLI. An error is returned
+// if the subcommand fails.
+func RunCLI(args []string) error {
+	if len(args) > 1 {
+		switch args[1] {
+		case "current":
+			return CurrentWeatherCLI(args[1:])
+		case "forecast":
+			return ForecastCLI(args[1:])
+		case "rain":
+			return RainCLI(args[1:])
+		case "serve":
+			return ServeCLI(args[1:])
+		case "exporter":
+			return ExporterCLI(args[1:])
+		case "rules":
+			return RulesCLI(args[1:])
+		case "record":
+			return RecordCLI(args[1:])
+		case "history":
+			return HistoryCLI(args[1:])
+		case "report":
+			return ReportCLI(args[1:])
+		case "accuracy":
+			return AccuracyCLI(args[1:])
+		case "sun":
+			return SunCLI(args[1:])
+		case "moon":
+			return MoonCLI(args[1:])
+		case "dashboard":
+			return DashboardCLI(args[1:])
+		case "chart":
+			return ChartCLI(args[1:])
+		}
+	}
+	return CurrentWeatherCLI(args)
+}
+
 // CurrentWeatherCLI accepts a slice of command line flags and arguments,
-// determines the location of interest and the measurement units to use
-//  (e.g. imperial, standard, metric) and prints the current weather conditions
-// for that location using the given measurement units. An error is returned if
-// the OPENWEATHER_API_KEY environment variable is not set, if the command line
-// flags and arguments are invalid, or if the call to get the weather conditions
-// has a problem.
+// determines the location of interest, the weather provider and the
+// measurement units to use (e.g. imperial, standard, metric, or a system
+// mixed with other units like metric,mph) and prints the current weather
+// conditions for that location using the given measurement units, refreshing
+// them on an interval if the watch flag is set. With GeoJSON output, the
+// conditions for every location given are printed as a FeatureCollection.
+// An error is returned if the command line flags and arguments are invalid,
+// if the OpenWeather provider is selected and the OPENWEATHER_API_KEY
+// environment variable is not set, or if the call to get the weather
+// conditions has a problem.
 func CurrentWeatherCLI(args []string) error {
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	var cfg cliEnv
+	if err := cfg.fromArgs(args); err != nil {
+		return err
+	}
+
+	p, err := providerFromName(cfg.provider, cfg.locale.Lang)
+	if err != nil {
+		return err
+	}
+	if cfg.watch > 0 {
+		return watchCLI(p, cfg)
+	}
+	if cfg.output == "geojson" {
+		fc, err := ConditionsFeatures(p, cfg.locations, cfg.units)
+		if err != nil {
+			return err
+		}
+		return fc.Encode(os.Stdout)
+	}
+	if cp, ok := p.(Consensus); ok {
+		co, err := cp.CurrentConsensus(cfg.location, cfg.units)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", cfg.locale.formatConsensus(co, cfg.prefs))
+		return nil
+	}
+	obs, err := p.CurrentObservation(cfg.location, cfg.units)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", cfg.locale.FormatConditions(obs, cfg.prefs))
+	return nil
+}
+
+// watchCLI refreshes the current weather conditions described by cfg every
+// cfg.watch until the process is interrupted. Refreshes are limited to 60 a
+// minute whatever the provider, which only holds back intervals shorter
+// than a second. When cfg.cacheTTL is set, an OpenWeather Client caches its
+// responses for that long, so that refreshes more frequent than the
+// conditions are worth requesting reuse the last reading. An error is
+// returned if the first refresh fails.
+func watchCLI(p Provider, cfg cliEnv) error {
+	if c, ok := p.(Client); ok && cfg.cacheTTL > 0 {
+		c.Cache = NewCache(cfg.cacheTTL)
+		p = c
+	}
+	p = LimitProvider(p, NewRateLimiter(60, time.Minute))
+	w, err := NewWatch(p, cfg.location, cfg.units)
+	if err != nil {
+		return err
+	}
+	w.Preferences = cfg.prefs
+	w.Locale = cfg.locale
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+	return w.Run(cfg.watch, stop)
+}
+
+// providerFromName accepts the name of a weather provider ("owm",
+// "openmeteo", "nws" or "metno") and the language to request weather
+// descriptions in, which may be empty for English, and returns a Provider for
+// it. A comma-separated list of provider names prefixed with "fallback:"
+// (e.g. "fallback:owm,openmeteo") returns a Fallback trying them in order,
+// and one prefixed with "consensus:" returns a Consensus combining them. An
+// error is returned if a name is unknown or if the provider is "owm" and the
+// OPENWEATHER_API_KEY environment variable is not set.
+func providerFromName(name, lang string) (Provider, error) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		var providers []Provider
+		for _, n := range strings.Split(name[i+1:], ",") {
+			p, err := providerFromName(strings.TrimSpace(n), lang)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, p)
+		}
+		switch name[:i] {
+		case "fallback":
+			f, err := NewFallback(providers...)
+			if err != nil {
+				return nil, err
+			}
+			f.Timeout = 15 * time.Second
+			return f, nil
+		case "consensus":
+			return NewConsensus(providers...)
+		}
+		return nil, fmt.Errorf("unknown provider mode %q, must be one of: fallback, consensus", name[:i])
+	}
+
+	switch name {
+	case "owm", "openweather":
+		apiKey := os.Getenv("OPENWEATHER_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("environment variable OPENWEATHER_API_KEY must be set")
+		}
+		c, err := NewClient(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		c.Lang = lang
+		return c, nil
+	case "openmeteo":
+		return NewOpenMeteo(), nil
+	case "nws":
+		return NewNWS(userAgent())
+	case "metno":
+		return NewMetNorway(userAgent())
+	}
+	return nil, fmt.Errorf("unknown provider %q, must be one of: owm, openmeteo, nws, metno", name)
+}
+
+// RainCLI accepts a slice of command line flags and arguments, determines the
+// location of interest and the language to use and prints a summary of the
+// precipitation expected there over the next hour. An error is returned if
+// the OPENWEATHER_API_KEY environment variable is not set, if the location
+// positional argument is missing, or if the call to get the precipitation
+// forecast has a problem.
+func RainCLI(args []string) error {
 	apiKey := os.Getenv("OPENWEATHER_API_KEY")
 	if apiKey == "" {
 		return errors.New("environment variable OPENWEATHER_API_KEY must be set")
 	}
 
-	var cfg cliEnv
-	if err := cfg.fromArgs(args[1:]); err != nil {
+	fs := flag.NewFlagSet("rain", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather rain [-lang=<language>] <location>\n\n"))
+		fs.PrintDefaults()
+	}
+	lang := fs.String("lang", "", "the language to show the summary in (e.g. 'de', 'fr'), by default taken from the LANG environment variable")
+	if err := fs.Parse(args[1:]); err != nil {
 		return err
 	}
+	locale := LocaleFromEnv()
+	if *lang != "" {
+		var err error
+		if locale, err = ParseLocale(*lang); err != nil {
+			return fmt.Errorf("lang flag: %w", err)
+		}
+	}
+	loc := fs.Arg(0)
+	if loc == "" {
+		return errors.New("positional argument for location must be given (e.g. 'london', 'tampa,us', etc.)")
+	}
 
-	c, err := Conditions(cfg.location, cfg.units, apiKey)
+	c, err := NewClient(apiKey)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s\n", c)
+	c.Lang = locale.Lang
+	n, err := c.Nowcast(loc)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", locale.FormatNowcast(n))
 	return nil
 }
 
+// userAgent returns the User-Agent to identify the CLI with to providers that
+// require one, taken from the WEATHER_USER_AGENT environment variable if it
+// is set.
+func userAgent() string {
+	if ua := os.Getenv("WEATHER_USER_AGENT"); ua != "" {
+		return ua
+	}
+	return "github.com/aculclasure/weather"
+}
+
 // cliEnv represents command line arguments and flags.
 type cliEnv struct {
-	units    string
+	units    Units
+	prefs    Preferences
+	locale   Locale
+	provider string
 	location string
+	// locations are all the locations given, of which only the first is
+	// used unless the output is GeoJSON.
+	locations []string
+	watch     time.Duration
+	// cacheTTL is how long a watched OpenWeather reading is reused for.
+	cacheTTL time.Duration
+	output   string
 }
 
 // fromArgs accepts a slice of strings representing command line flags and
 // positional arguments and tries to parse them into a cliEnv struct. An
-// error is returned if the units flag cannot be parsed correctly or if the
-// location positional parameter is not provided.
+// error is returned if the units or provider flags cannot be parsed
+// correctly or if the location positional parameter is not provided.
 func (c *cliEnv) fromArgs(args []string) error {
 	fs := flag.NewFlagSet("weather", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	fs.Usage = func() {
-		fs.Output().Write([]byte("USAGE: weather [-units={standard|metric|imperial}] <location>\n\n"))
+		fs.Output().Write([]byte("USAGE: weather [-units={standard|metric|imperial}[,<unit>...]] [-provider={owm|openmeteo|nws|metno|fallback:<names>|consensus:<names>}] [-watch=<interval> [-cache-ttl=<duration>]] [-lang=<language>] [-output={text|geojson}] <location> [<location>...]\n\n"))
 		fs.PrintDefaults()
 	}
-	fs.StringVar(&c.units, "units", "imperial", "the units to use, one of: standard, metric, imperial")
+	units := fs.String("units", "imperial", "the units to use, one of: standard, metric, imperial, optionally followed by units to show instead of the system's own (e.g. 'metric,mph')")
+	fs.StringVar(&c.provider, "provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: or consensus:")
+	fs.DurationVar(&c.watch, "watch", 0, "refresh the conditions on this interval (e.g. '10m') until interrupted")
+	fs.DurationVar(&c.cacheTTL, "cache-ttl", 0, "with -watch, how long to reuse an OpenWeather reading before requesting a new one (e.g. '30m')")
+	lang := fs.String("lang", "", "the language to show the conditions in (e.g. 'de', 'fr'), by default taken from the LANG environment variable")
+	fs.StringVar(&c.output, "output", "text", "the output format, one of: text, geojson (a FeatureCollection with a Point for each location given)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if c.units != "imperial" && c.units != "standard" && c.units != "metric" {
-		return errors.New("units flag must be set to one of: imperial, metric, standard")
+	if c.watch < 0 {
+		return errors.New("watch flag must not be negative")
+	}
+	if c.cacheTTL < 0 {
+		return errors.New("cache-ttl flag must not be negative")
+	}
+	if c.output != "text" && c.output != "geojson" {
+		return errors.New("output flag must be one of: text, geojson")
+	}
+	if c.output == "geojson" && c.watch > 0 {
+		return errors.New("watch flag cannot be used with geojson output")
+	}
+	var err error
+	if c.units, c.prefs, err = ParsePreferences(*units); err != nil {
+		return fmt.Errorf("units flag: %w", err)
+	}
+	c.locale = LocaleFromEnv()
+	if *lang != "" {
+		if c.locale, err = ParseLocale(*lang); err != nil {
+			return fmt.Errorf("lang flag: %w", err)
+		}
 	}
 	loc := fs.Arg(0)
 	if loc == "" {
 		return errors.New("positional argument for location must be given (e.g. 'london', 'tampa,us', etc.)")
 	}
 	c.location = loc
+	c.locations = fs.Args()
 
 	return nil
 }
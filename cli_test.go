@@ -2,6 +2,7 @@ package weather_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aculclasure/weather"
@@ -29,6 +30,56 @@ func TestCurrentWeatherCLI(t *testing.T) {
 			args:        []string{"weathercli", "--units=", "London"},
 			errExpected: true,
 		},
+		"unknown unit in units flag returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--units=metric,furlongs", "London"},
+			errExpected: true,
+		},
+		"missing OPENWEATHER_API_KEY for the owm provider returns an error": {
+			apiKey:      "",
+			args:        []string{"weathercli", "--provider=owm", "London"},
+			errExpected: true,
+		},
+		"unknown provider in a fallback list returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--provider=fallback:openmeteo,nope", "London"},
+			errExpected: true,
+		},
+		"unknown provider mode returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--provider=random:owm,openmeteo", "London"},
+			errExpected: true,
+		},
+		"negative watch interval returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--watch=-1m", "London"},
+			errExpected: true,
+		},
+		"negative cache TTL returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--watch=1m", "--cache-ttl=-1m", "London"},
+			errExpected: true,
+		},
+		"unknown provider returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--provider=nope", "London"},
+			errExpected: true,
+		},
+		"unsupported language returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--lang=tlh", "London"},
+			errExpected: true,
+		},
+		"unknown output format returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--provider=openmeteo", "--output=kml", "London"},
+			errExpected: true,
+		},
+		"watch with geojson output returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "--provider=openmeteo", "--output=geojson", "--watch=1m", "London"},
+			errExpected: true,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -43,3 +94,174 @@ func TestCurrentWeatherCLI(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCLIRain(t *testing.T) {
+	testCases := map[string]struct {
+		apiKey      string
+		args        []string
+		errExpected bool
+	}{
+		"missing OPENWEATHER_API_KEY environment variable returns an error": {
+			apiKey:      "",
+			args:        []string{"weathercli", "rain", "London"},
+			errExpected: true,
+		},
+		"missing weather location positional argument returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "rain"},
+			errExpected: true,
+		},
+		"unsupported language returns an error": {
+			apiKey:      "KEY",
+			args:        []string{"weathercli", "rain", "-lang=tlh", "London"},
+			errExpected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv("OPENWEATHER_API_KEY", tc.apiKey)
+			err := weather.RunCLI(tc.args)
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("RunCLI(%+v) returned unexpected error status: %v", tc.args, errReceived)
+			}
+		})
+	}
+}
+
+func TestRunCLIRules(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]string{
+		"missing rules subcommand returns an error": {"weathercli", "rules"},
+		"unknown rules subcommand returns an error": {"weathercli", "rules", "nope"},
+		"missing rules file returns an error":       {"weathercli", "rules", "check", "-rules=testdata/nope.json", "-provider=openmeteo"},
+		"invalid rules file returns an error":       {"weathercli", "rules", "check", "-rules=testdata/nwsPointsResp.json", "-provider=openmeteo"},
+		"run without notifiers returns an error":    {"weathercli", "rules", "run", "-rules=testdata/rules.json", "-provider=openmeteo"},
+		"run with invalid email returns an error":   {"weathercli", "rules", "run", "-rules=testdata/rules.json", "-provider=openmeteo", "-smtp-addr=localhost:25"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
+
+func TestRunCLIHistory(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]string{
+		"record without a location returns an error":       {"weathercli", "record", "-provider=openmeteo"},
+		"record with an unknown provider returns an error": {"weathercli", "record", "-provider=nope", "london"},
+		"missing history subcommand returns an error":      {"weathercli", "history"},
+		"unknown history subcommand returns an error":      {"weathercli", "history", "delete"},
+		"missing history database returns an error":        {"weathercli", "history", "query", "-db=testdata/nope.db"},
+		"invalid since flag returns an error":              {"weathercli", "history", "query", "-since=yesterday"},
+		"invalid format flag returns an error":             {"weathercli", "history", "query", "-format=xml"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
+
+func TestRunCLIAlmanac(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]string{
+		"sun without a location returns an error":            {"weathercli", "sun", "-offline"},
+		"offline sun for a place name returns an error":      {"weathercli", "sun", "-offline", "london"},
+		"offline sun with an invalid date returns an error":  {"weathercli", "sun", "-offline", "-date=18/05/2021", "51.5,-0.12"},
+		"offline moon with an unknown zone returns an error": {"weathercli", "moon", "-offline", "-tz=Nowhere/Special", "51.5,-0.12"},
+		"offline moon with out of range coordinates errors":  {"weathercli", "moon", "-offline", "91,0"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
+
+func TestRunCLIForecast(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]string{
+		"missing location returns an error":      {"weathercli", "forecast", "-provider=openmeteo"},
+		"unknown output format returns an error": {"weathercli", "forecast", "-provider=openmeteo", "-output=pdf", "london"},
+		"unknown unit returns an error":          {"weathercli", "forecast", "-provider=openmeteo", "-units=metric,furlongs", "london"},
+		"unsupported language returns an error":  {"weathercli", "forecast", "-provider=openmeteo", "-lang=tlh", "london"},
+		"unknown provider returns an error":      {"weathercli", "forecast", "-provider=nope", "london"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
+
+func TestRunCLIDashboard(t *testing.T) {
+	t.Parallel()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string][]string{
+		"missing location returns an error":                {"weathercli", "dashboard", "-provider=openmeteo"},
+		"negative refresh interval returns an error":       {"weathercli", "dashboard", "-provider=openmeteo", "-refresh=-1m", "london"},
+		"unknown unit returns an error":                    {"weathercli", "dashboard", "-provider=openmeteo", "-units=metric,furlongs", "london"},
+		"unsupported language returns an error":            {"weathercli", "dashboard", "-provider=openmeteo", "-lang=tlh", "london"},
+		"output directory that is a file returns an error": {"weathercli", "dashboard", "-provider=openmeteo", "-o", file, "london"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
+
+func TestRunCLIChart(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]string{
+		"missing location returns an error":                    {"weathercli", "chart", "-provider=openmeteo"},
+		"unsupported output extension returns an error":        {"weathercli", "chart", "-provider=openmeteo", "london", "-o", "forecast.gif"},
+		"unknown chart returns an error":                       {"weathercli", "chart", "-provider=openmeteo", "-chart=weekly", "london"},
+		"argument after the location's flags returns an error": {"weathercli", "chart", "london", "-provider=openmeteo", "paris"},
+		"unknown time zone returns an error":                   {"weathercli", "chart", "-provider=openmeteo", "-tz=Mars/Olympus", "london"},
+		"unknown unit returns an error":                        {"weathercli", "chart", "-provider=openmeteo", "-units=metric,furlongs", "london"},
+		"provider without hourly forecasts returns an error":   {"weathercli", "chart", "-provider=consensus:openmeteo,nws", "london"},
+	}
+
+	for name, args := range testCases {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := weather.RunCLI(args); err == nil {
+				t.Fatalf("RunCLI(%+v) want error, got nil", args)
+			}
+		})
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"os"
 
 	"github.com/aculclasure/weather"
+	"github.com/aculclasure/weather/weathergrpc"
 )
 
 func main() {
-	if err := weather.CurrentWeatherCLI(os.Args); err != nil {
+	run := weather.RunCLI
+	if len(os.Args) > 1 && os.Args[1] == "grpc" {
+		run = func(args []string) error { return weathergrpc.ServeCLI(args[1:]) }
+	}
+	if err := run(os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,346 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errNoProviders = errors.New("at least one provider must be given")
+
+// Fallback is a Provider that tries each of its Providers in order,
+// returning the result of the first one that succeeds. It fails over to the
+// next Provider when one returns an error or, if Timeout is set, takes longer
+// than Timeout to respond.
+type Fallback struct {
+	Providers []Provider
+	Timeout   time.Duration
+}
+
+// NewFallback accepts one or more Providers, in order of preference, and
+// returns a Fallback that tries them in that order. An error is returned if
+// no providers are given.
+func NewFallback(providers ...Provider) (Fallback, error) {
+	if len(providers) == 0 {
+		return Fallback{}, errNoProviders
+	}
+	return Fallback{Providers: providers}, nil
+}
+
+// CurrentObservation returns the current observation from the first of the
+// Fallback's Providers to return one. An error combining every Provider's
+// error is returned if they all fail.
+func (f Fallback) CurrentObservation(location string, units Units) (Observation, error) {
+	v, err := f.try(func(p Provider) (interface{}, error) {
+		return p.CurrentObservation(location, units)
+	})
+	if err != nil {
+		return Observation{}, err
+	}
+	return v.(Observation), nil
+}
+
+// DailyForecast returns the daily forecasts from the first of the Fallback's
+// Providers to return them. An error combining every Provider's error is
+// returned if they all fail.
+func (f Fallback) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	v, err := f.try(func(p Provider) (interface{}, error) {
+		return p.DailyForecast(location, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]DayForecast), nil
+}
+
+// HourlyForecast returns the hourly forecasts from the first of the
+// Fallback's Providers to return them, skipping those that are not
+// HourlyForecasters. An error combining every Provider's error is returned
+// if they all fail.
+func (f Fallback) HourlyForecast(location string, units Units) ([]HourlyForecast, error) {
+	v, err := f.try(func(p Provider) (interface{}, error) {
+		hf, ok := p.(HourlyForecaster)
+		if !ok {
+			return nil, errors.New("does not forecast hourly")
+		}
+		return hf.HourlyForecast(location, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]HourlyForecast), nil
+}
+
+// Geocode returns the location from the first of the Fallback's Providers to
+// geocode it. An error combining every Provider's error is returned if they
+// all fail.
+func (f Fallback) Geocode(location string) (Location, error) {
+	v, err := f.try(func(p Provider) (interface{}, error) {
+		return p.Geocode(location)
+	})
+	if err != nil {
+		return Location{}, err
+	}
+	return v.(Location), nil
+}
+
+// try calls fn with each Provider in turn and returns the result of the
+// first call that succeeds.
+func (f Fallback) try(fn func(Provider) (interface{}, error)) (interface{}, error) {
+	if len(f.Providers) == 0 {
+		return nil, errNoProviders
+	}
+
+	var msgs []string
+	for i, p := range f.Providers {
+		v, err := f.call(p, fn)
+		if err == nil {
+			return v, nil
+		}
+		msgs = append(msgs, fmt.Sprintf("provider %d: %v", i+1, err))
+	}
+	return nil, fmt.Errorf("all providers failed: %s", strings.Join(msgs, "; "))
+}
+
+// call calls fn with p. If the Fallback's Timeout is set and fn takes longer
+// than it to return, the call is abandoned and an error is returned.
+func (f Fallback) call(p Provider, fn func(Provider) (interface{}, error)) (interface{}, error) {
+	if f.Timeout <= 0 {
+		return fn(p)
+	}
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn(p)
+		done <- result{v, err}
+	}()
+	timer := time.NewTimer(f.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out after %s", f.Timeout)
+	}
+}
+
+// Consensus is a Provider that queries all of its Providers in parallel and
+// combines their results, reporting the median of each metric.
+type Consensus struct {
+	Providers []Provider
+}
+
+// NewConsensus accepts one or more Providers and returns a Consensus that
+// combines their results. An error is returned if no providers are given.
+func NewConsensus(providers ...Provider) (Consensus, error) {
+	if len(providers) == 0 {
+		return Consensus{}, errNoProviders
+	}
+	return Consensus{Providers: providers}, nil
+}
+
+// ConsensusObservation represents the median of the current observations
+// reported by several Providers.
+type ConsensusObservation struct {
+	Observation
+	// Sources is the number of providers that reported an observation.
+	Sources int
+	// Spread is the difference between the highest and lowest temperature
+	// reported by the providers.
+	Spread float64
+}
+
+// CurrentConsensus accepts a location (e.g. "london", "tampa,us", etc.) and a
+// measurement unit ("standard", "metric", or "imperial"), requests the
+// current observation from all of the Consensus's Providers in parallel and
+// returns their median, along with the number of providers that responded
+// and the spread between their temperatures. Providers that return an error
+// are left out, as are zero wind gusts and visibilities, which providers
+// report when they don't measure them; an error is returned only if every
+// provider fails.
+func (c Consensus) CurrentConsensus(location string, units Units) (ConsensusObservation, error) {
+	if len(c.Providers) == 0 {
+		return ConsensusObservation{}, errNoProviders
+	}
+
+	results := make([]Observation, len(c.Providers))
+	errs := make([]error, len(c.Providers))
+	var wg sync.WaitGroup
+	for i, p := range c.Providers {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+			results[i], errs[i] = p.CurrentObservation(location, units)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var obs []Observation
+	var msgs []string
+	for i, err := range errs {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("provider %d: %v", i+1, err))
+			continue
+		}
+		obs = append(obs, results[i])
+	}
+	if len(obs) == 0 {
+		return ConsensusObservation{}, fmt.Errorf("all providers failed: %s", strings.Join(msgs, "; "))
+	}
+
+	field := func(get func(Observation) float64) []float64 {
+		vs := make([]float64, len(obs))
+		for i, o := range obs {
+			vs[i] = get(o)
+		}
+		return vs
+	}
+	// Not every provider reports wind gusts or visibility, and those that
+	// don't leave them zero, so only non-zero values count towards their
+	// medians.
+	reported := func(get func(Observation) float64) []float64 {
+		var vs []float64
+		for _, v := range field(get) {
+			if v != 0 {
+				vs = append(vs, v)
+			}
+		}
+		return vs
+	}
+	temps := field(func(o Observation) float64 { return o.Temp })
+	lo, hi := temps[0], temps[0]
+	for _, t := range temps {
+		if t < lo {
+			lo = t
+		}
+		if t > hi {
+			hi = t
+		}
+	}
+	latest := obs[0].Time
+	for _, o := range obs {
+		if o.Time.After(latest) {
+			latest = o.Time
+		}
+	}
+
+	return ConsensusObservation{
+		Observation: Observation{
+			Time:       latest,
+			Summary:    obs[0].Summary,
+			Temp:       median(temps),
+			FeelsLike:  median(field(func(o Observation) float64 { return o.FeelsLike })),
+			Humidity:   int(median(field(func(o Observation) float64 { return float64(o.Humidity) })) + 0.5),
+			Pressure:   median(field(func(o Observation) float64 { return o.Pressure })),
+			WindSpeed:  median(field(func(o Observation) float64 { return o.WindSpeed })),
+			WindGust:   median(reported(func(o Observation) float64 { return o.WindGust })),
+			WindDeg:    obs[0].WindDeg,
+			Clouds:     int(median(field(func(o Observation) float64 { return float64(o.Clouds) })) + 0.5),
+			Visibility: median(reported(func(o Observation) float64 { return o.Visibility })),
+			Precip:     median(field(func(o Observation) float64 { return o.Precip })),
+			Units:      units,
+		},
+		Sources: len(obs),
+		Spread:  hi - lo,
+	}, nil
+}
+
+// CurrentObservation returns the median of the current observations
+// reported by the Consensus's Providers. The summary and wind direction are
+// taken from the first provider to respond successfully, in provider order.
+func (c Consensus) CurrentObservation(location string, units Units) (Observation, error) {
+	co, err := c.CurrentConsensus(location, units)
+	if err != nil {
+		return Observation{}, err
+	}
+	return co.Observation, nil
+}
+
+// DailyForecast returns the daily forecasts of the first of the Consensus's
+// Providers that succeeds, with each day's low, high, precipitation
+// probability and precipitation replaced by the median of the values that
+// all of the providers forecast for that date. An error is returned if every
+// provider fails.
+func (c Consensus) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	if len(c.Providers) == 0 {
+		return nil, errNoProviders
+	}
+
+	results := make([][]DayForecast, len(c.Providers))
+	errs := make([]error, len(c.Providers))
+	var wg sync.WaitGroup
+	for i, p := range c.Providers {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+			results[i], errs[i] = p.DailyForecast(location, units)
+		}(i, p)
+	}
+	wg.Wait()
+
+	byDate := map[string][]DayForecast{}
+	var base []DayForecast
+	var msgs []string
+	for i, err := range errs {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("provider %d: %v", i+1, err))
+			continue
+		}
+		if base == nil {
+			base = results[i]
+		}
+		for _, f := range results[i] {
+			d := f.Date.Format("2006-01-02")
+			byDate[d] = append(byDate[d], f)
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("all providers failed: %s", strings.Join(msgs, "; "))
+	}
+
+	forecasts := make([]DayForecast, len(base))
+	for i, f := range base {
+		days := byDate[f.Date.Format("2006-01-02")]
+		field := func(get func(DayForecast) float64) float64 {
+			vs := make([]float64, len(days))
+			for i, d := range days {
+				vs[i] = get(d)
+			}
+			return median(vs)
+		}
+		f.Low = field(func(d DayForecast) float64 { return d.Low })
+		f.High = field(func(d DayForecast) float64 { return d.High })
+		f.PrecipProb = field(func(d DayForecast) float64 { return d.PrecipProb })
+		f.Precip = field(func(d DayForecast) float64 { return d.Precip })
+		forecasts[i] = f
+	}
+	return forecasts, nil
+}
+
+// Geocode returns the location from the first of the Consensus's Providers
+// to geocode it.
+func (c Consensus) Geocode(location string) (Location, error) {
+	return Fallback{Providers: c.Providers}.Geocode(location)
+}
+
+// median returns the median of a slice of values, or zero if it is empty,
+// without modifying the slice.
+func median(vs []float64) float64 {
+	s := append([]float64(nil), vs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
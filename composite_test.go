@@ -0,0 +1,230 @@
+package weather_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+// slowProvider is a weather.Provider that takes delay to return the
+// observation of the embedded fakeProvider.
+type slowProvider struct {
+	fakeProvider
+	delay time.Duration
+}
+
+func (s slowProvider) CurrentObservation(location string, units weather.Units) (weather.Observation, error) {
+	time.Sleep(s.delay)
+	return s.fakeProvider.CurrentObservation(location, units)
+}
+
+// hourlyProvider is a weather.HourlyForecaster returning hours, or the
+// error of the embedded fakeProvider.
+type hourlyProvider struct {
+	fakeProvider
+	hours []weather.HourlyForecast
+}
+
+func (h hourlyProvider) HourlyForecast(location string, units weather.Units) ([]weather.HourlyForecast, error) {
+	return h.hours, h.err
+}
+
+func TestNewFallbackAndNewConsensusWithoutProvidersReturnError(t *testing.T) {
+	t.Parallel()
+	if _, err := weather.NewFallback(); err == nil {
+		t.Fatal("NewFallback() did not return an expected error")
+	}
+	if _, err := weather.NewConsensus(); err == nil {
+		t.Fatal("NewConsensus() did not return an expected error")
+	}
+}
+
+func TestFallbackCurrentObservation(t *testing.T) {
+	t.Parallel()
+	failing := fakeProvider{err: errors.New("quota exhausted")}
+	first := fakeProvider{obs: weather.Observation{Summary: "first", Temp: 10}}
+	second := fakeProvider{obs: weather.Observation{Summary: "second", Temp: 20}}
+	testCases := map[string]struct {
+		fallback    weather.Fallback
+		want        weather.Observation
+		errExpected bool
+	}{
+		"first successful provider is used": {
+			fallback: weather.Fallback{Providers: []weather.Provider{first, second}},
+			want:     first.obs,
+		},
+		"failing provider is skipped": {
+			fallback: weather.Fallback{Providers: []weather.Provider{failing, second}},
+			want:     second.obs,
+		},
+		"slow provider is skipped after the timeout": {
+			fallback: weather.Fallback{
+				Providers: []weather.Provider{slowProvider{first, time.Second}, second},
+				Timeout:   10 * time.Millisecond,
+			},
+			want: second.obs,
+		},
+		"all providers failing returns an error": {
+			fallback:    weather.Fallback{Providers: []weather.Provider{failing, failing}},
+			errExpected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.fallback.CurrentObservation("London", "metric")
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestFallbackHourlyForecast(t *testing.T) {
+	t.Parallel()
+	hours := []weather.HourlyForecast{{Summary: "light rain", Temp: 12}}
+	testCases := map[string]struct {
+		providers   []weather.Provider
+		want        []weather.HourlyForecast
+		errExpected bool
+	}{
+		"provider without hourly forecasts is skipped": {
+			providers: []weather.Provider{fakeProvider{}, hourlyProvider{hours: hours}},
+			want:      hours,
+		},
+		"failing provider is skipped": {
+			providers: []weather.Provider{
+				hourlyProvider{fakeProvider: fakeProvider{err: errors.New("unavailable")}},
+				hourlyProvider{hours: hours},
+			},
+			want: hours,
+		},
+		"no provider forecasting hourly returns an error": {
+			providers:   []weather.Provider{fakeProvider{}, fakeProvider{}},
+			errExpected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var hf weather.HourlyForecaster = weather.Fallback{Providers: tc.providers}
+			got, err := hf.HourlyForecast("London", "metric")
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestConsensusCurrentConsensus(t *testing.T) {
+	t.Parallel()
+	c, err := weather.NewConsensus(
+		fakeProvider{obs: weather.Observation{Summary: "few clouds", Temp: 10, Humidity: 50}},
+		fakeProvider{err: errors.New("unavailable")},
+		fakeProvider{obs: weather.Observation{Summary: "partly cloudy", Temp: 13, Humidity: 60}},
+		fakeProvider{obs: weather.Observation{Summary: "sunny", Temp: 11, Humidity: 40}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.CurrentConsensus("London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.ConsensusObservation{
+		Observation: weather.Observation{Summary: "few clouds", Temp: 11, Humidity: 50, Units: weather.Metric},
+		Sources:     3,
+		Spread:      3,
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+
+	gotString, err := weather.ConsensusConditions(c, "London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantString := "few clouds, 11.00 C, humidity 50% (median of 3 sources, spread 3.00 C)"
+	if wantString != gotString {
+		t.Fatalf("want %q, got %q", wantString, gotString)
+	}
+}
+
+func TestConsensusCurrentConsensusIgnoresUnreportedGustAndVisibility(t *testing.T) {
+	t.Parallel()
+	c, err := weather.NewConsensus(
+		fakeProvider{obs: weather.Observation{Temp: 10, WindGust: 8, Visibility: 10000}},
+		fakeProvider{obs: weather.Observation{Temp: 11}},
+		fakeProvider{obs: weather.Observation{Temp: 12}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.CurrentObservation("London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.Observation{Temp: 11, WindGust: 8, Visibility: 10000, Units: weather.Metric}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestConsensusWithAllProvidersFailingReturnsError(t *testing.T) {
+	t.Parallel()
+	c, err := weather.NewConsensus(fakeProvider{err: errors.New("unavailable")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CurrentObservation("London", "metric"); err == nil {
+		t.Fatal("CurrentObservation did not return an expected error")
+	}
+	if _, err := c.DailyForecast("London", "metric"); err == nil {
+		t.Fatal("DailyForecast did not return an expected error")
+	}
+}
+
+func TestConsensusDailyForecast(t *testing.T) {
+	t.Parallel()
+	day1 := time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	c, err := weather.NewConsensus(
+		fakeProvider{forecasts: []weather.DayForecast{
+			{Date: day1, Summary: "rain", Low: 10, High: 20, PrecipProb: 0.8},
+			{Date: day2, Summary: "sun", Low: 12, High: 24},
+		}},
+		fakeProvider{forecasts: []weather.DayForecast{
+			{Date: day1, Summary: "showers", Low: 12, High: 22, PrecipProb: 0.6},
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.DailyForecast("London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weather.DayForecast{
+		{Date: day1, Summary: "rain", Low: 11, High: 21, PrecipProb: 0.7},
+		{Date: day2, Summary: "sun", Low: 12, High: 24},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,406 @@
+package weather
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dashboardDays is the most forecast days shown for each site of a
+// Dashboard.
+const dashboardDays = 7
+
+// Dashboard represents a self-contained HTML page showing the current
+// conditions, daily forecast and alerts for each of a number of sites, like
+// on a wall display. The page embeds its styles and charts, so it can be
+// served or opened without fetching anything else.
+type Dashboard struct {
+	Title string
+	Sites []DashboardSite
+	// Units are the measurement units the data was requested in, and
+	// Preferences the units it is shown in, by default those of Units.
+	Units       Units
+	Preferences Preferences
+	// Locale is the language and number format the page is written in.
+	Locale Locale
+	// Generated is when the data was fetched. The current time is used if
+	// it is zero.
+	Generated time.Time
+	// Refresh is how often a browser showing the page reloads it, or never
+	// if it is zero.
+	Refresh time.Duration
+}
+
+// DashboardSite represents the weather at one location of a Dashboard. Err
+// is set if it could not all be fetched, in which case the page shows a
+// generic notice alongside whatever was. The error itself is not shown, as
+// provider errors can contain request details.
+type DashboardSite struct {
+	Location  string
+	Current   *Observation
+	Forecasts []DayForecast
+	Alerts    []Alert
+	Err       error
+}
+
+// FetchDashboardSites accepts a Provider, the measurement units to use and
+// any number of locations and returns a DashboardSite for each location, in
+// the order given, with its current conditions, daily forecasts and, if p
+// is an AlertProvider, alerts. A site whose weather cannot be fetched has
+// its Err set rather than failing the others.
+func FetchDashboardSites(p Provider, units Units, locations ...string) []DashboardSite {
+	sites := make([]DashboardSite, 0, len(locations))
+	for _, location := range locations {
+		site := DashboardSite{Location: location}
+		obs, err := p.CurrentObservation(location, units)
+		if err == nil {
+			if obs.Units == "" {
+				obs.Units = units
+			}
+			site.Current = &obs
+			site.Forecasts, err = p.DailyForecast(location, units)
+		}
+		if err == nil {
+			for i := range site.Forecasts {
+				if site.Forecasts[i].Units == "" {
+					site.Forecasts[i].Units = units
+				}
+			}
+			if ap, ok := p.(AlertProvider); ok {
+				site.Alerts, err = ap.Alerts(location)
+			}
+		}
+		site.Err = err
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+// Render writes the Dashboard to w as an HTML page. An error is returned if
+// writing to w fails.
+func (d Dashboard) Render(w io.Writer) error {
+	return dashboardTemplate.Execute(w, d.view())
+}
+
+// dashboardView is the data the dashboard template is executed with, with
+// every quantity already converted and formatted.
+type dashboardView struct {
+	Lang      string
+	Title     string
+	Generated string
+	Refresh   int // seconds
+	Sites     []siteView
+}
+
+type siteView struct {
+	Location string
+	Error    string
+	Current  *currentView
+	Days     []dayView
+	Chart    template.HTML
+	Alerts   []alertView
+}
+
+type currentView struct {
+	Temp    string
+	Summary string
+	Details []string
+	Time    string
+}
+
+type dayView struct {
+	Date    string
+	Summary string
+	High    string
+	Low     string
+	Precip  string
+}
+
+type alertView struct {
+	Title    string
+	Severity string
+	Period   string
+	Details  string
+}
+
+// view returns the dashboardView of the Dashboard.
+func (d Dashboard) view() dashboardView {
+	l := d.Locale
+	prefs := d.Preferences
+	if prefs == (Preferences{}) {
+		prefs = d.Units.Preferences()
+	}
+	generated := d.Generated
+	if generated.IsZero() {
+		generated = time.Now()
+	}
+	title := d.Title
+	if title == "" {
+		title = "Weather"
+	}
+	v := dashboardView{
+		Lang:      l.Lang,
+		Title:     title,
+		Generated: l.FormatDate(generated) + " " + l.FormatTime(generated),
+		Refresh:   int(d.Refresh / time.Second),
+	}
+	for _, s := range d.Sites {
+		site := siteView{Location: s.Location}
+		if s.Err != nil {
+			site.Error = l.T("weather data unavailable")
+			if errors.Is(s.Err, ErrRateLimited) {
+				site.Error = l.T("rate limited, try again later")
+			}
+		}
+		if obs := s.Current; obs != nil {
+			o := *obs
+			if o.Units == "" {
+				o.Units = d.Units
+			}
+			q := o.Quantities().In(prefs)
+			site.Current = &currentView{
+				Temp:    l.FormatFloat(q.Temp.Value, 0) + " " + string(q.Temp.Unit),
+				Summary: strings.TrimSpace(o.Summary),
+				Details: []string{
+					l.Sprintf("feels like %s", l.FormatFloat(q.FeelsLike.Value, 0)+" "+string(q.FeelsLike.Unit)),
+					l.Sprintf("humidity %d%%", o.Humidity),
+					l.Sprintf("wind %s", l.quantity(q.WindSpeed.Value, string(q.WindSpeed.Unit))),
+					l.Sprintf("pressure %s", l.quantity(q.Pressure.Value, string(q.Pressure.Unit))),
+				},
+				Time: l.FormatTime(o.Time),
+			}
+		}
+		days := s.Forecasts
+		if len(days) > dashboardDays {
+			days = days[:dashboardDays]
+		}
+		for _, f := range days {
+			units := f.Units
+			if units == "" {
+				units = d.Units
+			}
+			high := Temperature{f.High, units.Temperature()}.In(prefs.Temperature)
+			low := Temperature{f.Low, units.Temperature()}.In(prefs.Temperature)
+			site.Days = append(site.Days, dayView{
+				Date:    l.FormatDate(f.Date),
+				Summary: strings.TrimSpace(f.Summary),
+				High:    l.FormatFloat(high.Value, 0) + " " + string(high.Unit),
+				Low:     l.FormatFloat(low.Value, 0) + " " + string(low.Unit),
+				Precip:  fmt.Sprintf("%.0f%%", f.PrecipProb*100),
+			})
+		}
+		if len(days) > 0 {
+			var chart strings.Builder
+			DailyChart{Forecasts: days, Units: d.Units, Preferences: prefs, Locale: l}.EncodeSVG(&chart)
+			site.Chart = template.HTML(chart.String())
+		}
+		for _, a := range s.Alerts {
+			period := l.FormatDate(a.Start) + " " + l.FormatTime(a.Start)
+			if !a.End.IsZero() {
+				period += " – " + l.FormatDate(a.End) + " " + l.FormatTime(a.End)
+			}
+			site.Alerts = append(site.Alerts, alertView{
+				Title:    alertTitle(a),
+				Severity: strings.ToLower(a.Severity),
+				Period:   period,
+				Details:  alertDetails(a),
+			})
+		}
+		v.Sites = append(v.Sites, site)
+	}
+	return v
+}
+
+// dashboardTemplate is the HTML page a Dashboard is rendered as.
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{- if .Refresh}}
+<meta http-equiv="refresh" content="{{.Refresh}}">
+{{- end}}
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; padding: 1.5rem; background: #10151c; color: #e6e9ee; font: 16px/1.4 system-ui, sans-serif; }
+header { display: flex; justify-content: space-between; align-items: baseline; margin-bottom: 1rem; }
+h1 { margin: 0; font-size: 1.75rem; }
+h2 { margin: 0 0 .5rem; font-size: 1.35rem; }
+.generated { color: #8a94a3; }
+main { display: grid; grid-template-columns: repeat(auto-fill, minmax(30rem, 1fr)); gap: 1.5rem; }
+section { background: #1a222d; border-radius: .5rem; padding: 1rem 1.25rem; }
+.error { color: #ffb4a8; }
+.current { display: flex; gap: 1.25rem; align-items: center; }
+.temp { font-size: 3rem; font-weight: 600; }
+.summary { font-size: 1.2rem; text-transform: capitalize; }
+.details { color: #b8c0cc; font-size: .9rem; }
+table { width: 100%; border-collapse: collapse; margin-top: .75rem; font-size: .9rem; }
+th, td { padding: .2rem .4rem; text-align: left; }
+th { color: #8a94a3; font-weight: normal; }
+td.num, th.num { text-align: right; }
+svg { width: 100%; height: auto; margin-top: .75rem; }
+svg .background { fill: none; }
+svg .grid { stroke: #2c3642; }
+svg text { fill: #b8c0cc; }
+svg .high { fill: #ffd2a8; }
+svg .low { fill: #a8c8ff; }
+.alert { margin-top: .75rem; padding: .5rem .75rem; border-left: 4px solid #e0b400; background: #2a2a1a; }
+.alert.severe, .alert.extreme { border-color: #e0453a; background: #2d1a1a; }
+.alert h3 { margin: 0; font-size: 1rem; }
+.alert .period { color: #b8c0cc; font-size: .85rem; }
+.alert p { margin: .4rem 0 0; white-space: pre-line; font-size: .85rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<span class="generated">{{.Generated}}</span>
+</header>
+<main>
+{{- range .Sites}}
+<section>
+<h2>{{.Location}}</h2>
+{{- if .Error}}
+<p class="error">{{.Error}}</p>
+{{- end}}
+{{- with .Current}}
+<div class="current">
+<span class="temp">{{.Temp}}</span>
+<div>
+<div class="summary">{{.Summary}}</div>
+<div class="details">{{range $i, $d := .Details}}{{if $i}} · {{end}}{{$d}}{{end}}</div>
+<div class="details">{{.Time}}</div>
+</div>
+</div>
+{{- end}}
+{{- range .Alerts}}
+<div class="alert {{.Severity}}">
+<h3>{{.Title}}</h3>
+<div class="period">{{.Period}}</div>
+{{- if .Details}}
+<p>{{.Details}}</p>
+{{- end}}
+</div>
+{{- end}}
+{{- if .Days}}
+{{.Chart}}
+<table>
+<tr><th></th><th></th><th class="num">↑</th><th class="num">↓</th><th class="num">☂</th></tr>
+{{- range .Days}}
+<tr><td>{{.Date}}</td><td>{{.Summary}}</td><td class="num">{{.High}}</td><td class="num">{{.Low}}</td><td class="num">{{.Precip}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+// DashboardCLI accepts a slice of command line flags and arguments,
+// including the subcommand name, fetches the weather for each location
+// argument and writes a dashboard of them to index.html in the output
+// directory, creating it if needed. The page is replaced in a single step,
+// so it can be regenerated from cron while being displayed. Sites whose
+// weather cannot be fetched show a notice that it is unavailable, while the
+// errors are written to standard error and the first of them is returned
+// after the page is written. An error is also returned if the command line
+// flags and arguments are invalid, if the provider cannot be created or if
+// the page cannot be written.
+func DashboardCLI(args []string) error {
+	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather dashboard [-o=<directory>] [-units={standard|metric|imperial}[,<unit>...]] [-provider=<name>] [-lang=<language>] [-title=<title>] [-refresh=<interval>] <location> [<location>...]\n\n"))
+		fs.PrintDefaults()
+	}
+	dir := fs.String("o", ".", "the directory to write index.html to")
+	unitsFlag := fs.String("units", "imperial", "the units to use, one of: standard, metric, imperial, optionally followed by units to show instead of the system's own (e.g. 'metric,mph')")
+	provider := fs.String("provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: or consensus:")
+	lang := fs.String("lang", "", "the language to write the page in (e.g. 'de', 'fr'), by default taken from the LANG environment variable")
+	title := fs.String("title", "", "the title of the page")
+	refresh := fs.Duration("refresh", 5*time.Minute, "how often a browser showing the page reloads it, or 0 for never")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one location positional argument must be given (e.g. 'london', 'tampa,us', etc.)")
+	}
+	if *refresh < 0 {
+		return errors.New("refresh flag must not be negative")
+	}
+	units, prefs, err := ParsePreferences(*unitsFlag)
+	if err != nil {
+		return fmt.Errorf("units flag: %w", err)
+	}
+	locale := LocaleFromEnv()
+	if *lang != "" {
+		if locale, err = ParseLocale(*lang); err != nil {
+			return fmt.Errorf("lang flag: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+
+	p, err := providerFromName(*provider, locale.Lang)
+	if err != nil {
+		return err
+	}
+	d := Dashboard{
+		Title:       *title,
+		Sites:       FetchDashboardSites(p, units, fs.Args()...),
+		Units:       units,
+		Preferences: prefs,
+		Locale:      locale,
+		Generated:   time.Now(),
+		Refresh:     *refresh,
+	}
+	if err := writeFileAtomic(filepath.Join(*dir, "index.html"), d.Render); err != nil {
+		return err
+	}
+	var firstErr error
+	for _, s := range d.Sites {
+		if s.Err != nil {
+			fmt.Fprintf(os.Stderr, "error fetching %s: %v\n", s.Location, s.Err)
+			if firstErr == nil {
+				firstErr = s.Err
+			}
+		}
+	}
+	return firstErr
+}
+
+// writeFileAtomic writes a file at path with the contents written by write,
+// replacing any existing file only once they are complete, so readers never
+// see a partly written file.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
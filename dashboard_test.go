@@ -0,0 +1,193 @@
+package weather_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testDashboard() weather.Dashboard {
+	feed := testFeed()
+	return weather.Dashboard{
+		Title: "Site <Weather>",
+		Sites: []weather.DashboardSite{
+			{
+				Location:  feed.Location,
+				Current:   feed.Current,
+				Forecasts: feed.Forecasts,
+				Alerts:    feed.Alerts,
+			},
+			{
+				Location: "Nowhere",
+				Err:      errors.New("location not found"),
+			},
+		},
+		Units:       feed.Units,
+		Preferences: feed.Preferences,
+		Generated:   time.Date(2021, 5, 18, 12, 5, 0, 0, time.UTC),
+		Refresh:     5 * time.Minute,
+	}
+}
+
+func TestDashboardRender(t *testing.T) {
+	t.Parallel()
+	want, err := ioutil.ReadFile("testdata/dashboard.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := testDashboard().Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !cmp.Equal(string(want), got) {
+		t.Error(cmp.Diff(string(want), got))
+	}
+}
+
+func TestDashboardRenderIsSelfContained(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := testDashboard().Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, s := range []string{"<script", "<link", "src=", "@import", "url("} {
+		if strings.Contains(page, s) {
+			t.Errorf("page contains %q, want no external resources", s)
+		}
+	}
+	if !strings.Contains(page, "<svg") {
+		t.Error("page has no inline SVG chart")
+	}
+	if strings.Contains(page, "<Weather>") {
+		t.Error("title is not escaped")
+	}
+}
+
+func TestDashboardShowsAtMostSevenDays(t *testing.T) {
+	t.Parallel()
+	var forecasts []weather.DayForecast
+	for i := 0; i < 8; i++ {
+		forecasts = append(forecasts, weather.DayForecast{
+			Date:  time.Date(2021, 5, 18+i, 12, 0, 0, 0, time.UTC),
+			High:  30,
+			Low:   20,
+			Units: weather.Metric,
+		})
+	}
+	d := weather.Dashboard{
+		Sites: []weather.DashboardSite{{Location: "Tampa", Forecasts: forecasts}},
+		Units: weather.Metric,
+	}
+	var buf bytes.Buffer
+	if err := d.Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if !strings.Contains(page, "2021-05-24") {
+		t.Error("seventh day is missing")
+	}
+	if strings.Contains(page, "2021-05-25") {
+		t.Error("eighth day is shown")
+	}
+}
+
+func TestFetchDashboardSites(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{Summary: "clear sky", Temp: 20}
+	forecasts := []weather.DayForecast{{Summary: "clear sky", High: 25, Low: 15}}
+	alerts := []weather.Alert{{Event: "Heat Advisory"}}
+	testCases := map[string]struct {
+		provider weather.Provider
+		want     []weather.DashboardSite
+	}{
+		"provider without alerts fills in units": {
+			provider: fakeProvider{obs: obs, forecasts: forecasts},
+			want: []weather.DashboardSite{{
+				Location:  "tampa",
+				Current:   &weather.Observation{Summary: "clear sky", Temp: 20, Units: weather.Metric},
+				Forecasts: []weather.DayForecast{{Summary: "clear sky", High: 25, Low: 15, Units: weather.Metric}},
+			}},
+		},
+		"alerts are fetched from an alert provider": {
+			provider: alertingProvider{fakeProvider: fakeProvider{obs: obs, forecasts: forecasts}, alerts: alerts},
+			want: []weather.DashboardSite{{
+				Location:  "tampa",
+				Current:   &weather.Observation{Summary: "clear sky", Temp: 20, Units: weather.Metric},
+				Forecasts: []weather.DayForecast{{Summary: "clear sky", High: 25, Low: 15, Units: weather.Metric}},
+				Alerts:    alerts,
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := weather.FetchDashboardSites(tc.provider, weather.Metric, "tampa")
+			if !cmp.Equal(tc.want, got) {
+				t.Error(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestFetchDashboardSitesRecordsErrors(t *testing.T) {
+	t.Parallel()
+	sites := weather.FetchDashboardSites(fakeProvider{err: errors.New("boom")}, weather.Metric, "tampa", "london")
+	if len(sites) != 2 {
+		t.Fatalf("want 2 sites, got %d", len(sites))
+	}
+	for _, s := range sites {
+		if s.Err == nil {
+			t.Errorf("site %s: want error, got nil", s.Location)
+		}
+	}
+}
+
+func TestDashboardDoesNotShowErrorDetails(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		err  error
+		want string
+	}{
+		"provider error": {
+			err:  errors.New("error getting data from https://api.openweathermap.org/?appid=SECRET"),
+			want: "weather data unavailable",
+		},
+		"rate limit": {
+			err:  fmt.Errorf("tampa: %w", weather.ErrRateLimited),
+			want: "rate limited, try again later",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d := weather.Dashboard{
+				Sites: []weather.DashboardSite{{Location: "Tampa", Err: tc.err}},
+				Units: weather.Metric,
+			}
+			var buf bytes.Buffer
+			if err := d.Render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			page := buf.String()
+			if strings.Contains(page, "SECRET") || strings.Contains(page, "tampa:") {
+				t.Errorf("page shows error details:\n%s", page)
+			}
+			if !strings.Contains(page, tc.want) {
+				t.Errorf("page does not contain %q:\n%s", tc.want, page)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package derive calculates derived meteorological quantities, like the heat
+// index, wind chill and dew point, from raw measurements.
+//
+// Functions accept and return values in the weather.Units they are given,
+// weather.Standard (Kelvin and meters per second), weather.Metric (Celsius
+// and meters per second) or weather.Imperial (Fahrenheit and miles per
+// hour), matching the units weather data is requested in. Relative humidity
+// is always a percentage from 0 to 100.
+package derive
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/aculclasure/weather"
+)
+
+var (
+	errInvalidHumidity = errors.New("relative humidity must be between 0 and 100")
+	errInvalidSpeed    = errors.New("wind speed must not be negative")
+)
+
+// toCelsius converts a temperature in the given units to Celsius.
+func toCelsius(t float64, units weather.Units) (float64, error) {
+	if !units.Valid() {
+		return 0, weather.ErrInvalidUnits
+	}
+	return weather.Temperature{Value: t, Unit: units.Temperature()}.In(weather.Celsius).Value, nil
+}
+
+// fromCelsius converts a temperature in Celsius to the given units, which
+// must be valid.
+func fromCelsius(c float64, units weather.Units) float64 {
+	return weather.Temperature{Value: c, Unit: weather.Celsius}.In(units.Temperature()).Value
+}
+
+// toMetersPerSecond converts a speed in the given units to meters per
+// second.
+func toMetersPerSecond(v float64, units weather.Units) (float64, error) {
+	if v < 0 {
+		return 0, errInvalidSpeed
+	}
+	if !units.Valid() {
+		return 0, weather.ErrInvalidUnits
+	}
+	return weather.Speed{Value: v, Unit: units.Speed()}.In(weather.MetersPerSecond).Value, nil
+}
+
+// checkHumidity returns an error if rh is not a percentage.
+func checkHumidity(rh float64) error {
+	if rh < 0 || rh > 100 || math.IsNaN(rh) {
+		return errInvalidHumidity
+	}
+	return nil
+}
+
+// vaporPressure returns the actual vapor pressure in hPa of air at a
+// temperature in Celsius and relative humidity, using the Magnus formula.
+func vaporPressure(c, rh float64) float64 {
+	return rh / 100 * 6.1094 * math.Exp(17.625*c/(c+243.04))
+}
+
+// HeatIndex accepts a temperature and relative humidity and returns the
+// heat index, how hot it feels when humidity is taken into account, using
+// the regression and adjustments the US National Weather Service uses. The
+// heat index is meant for temperatures above 80 F (26.7 C); below that
+// Steadman's simpler formula is used, which stays close to the temperature.
+// An error is returned if the units or humidity are invalid.
+func HeatIndex(t, rh float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	f := c*9/5 + 32
+
+	hi := 0.5 * (f + 61 + (f-68)*1.2 + rh*0.094)
+	if (hi+f)/2 >= 80 {
+		hi = -42.379 + 2.04901523*f + 10.14333127*rh - 0.22475541*f*rh -
+			0.00683783*f*f - 0.05481717*rh*rh + 0.00122874*f*f*rh +
+			0.00085282*f*rh*rh - 0.00000199*f*f*rh*rh
+		switch {
+		case rh < 13 && f >= 80 && f <= 112:
+			hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(f-95))/17)
+		case rh > 85 && f >= 80 && f <= 87:
+			hi += (rh - 85) / 10 * (87 - f) / 5
+		}
+	}
+	return fromCelsius((hi-32)*5/9, units), nil
+}
+
+// WindChill accepts a temperature and wind speed and returns the wind
+// chill, how cold it feels on exposed skin, using the formula the US
+// National Weather Service and Environment Canada use. Wind chill is only
+// defined at or below 50 F (10 C) with winds of at least 3 mph (1.34 m/s);
+// outside those conditions the temperature itself is returned. An error is
+// returned if the units are invalid or the speed is negative.
+func WindChill(t, windSpeed float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	ms, err := toMetersPerSecond(windSpeed, units)
+	if err != nil {
+		return 0, err
+	}
+	f, mph := c*9/5+32, ms/0.44704
+	if f > 50 || mph < 3 {
+		return t, nil
+	}
+	v := math.Pow(mph, 0.16)
+	wc := 35.74 + 0.6215*f - 35.75*v + 0.4275*f*v
+	return fromCelsius((wc-32)*5/9, units), nil
+}
+
+// ApparentTemperature accepts a temperature, relative humidity and wind
+// speed and returns the apparent temperature, how hot or cold it feels in
+// the shade, using Steadman's formula as adopted by the Australian Bureau
+// of Meteorology. An error is returned if the units, humidity or speed are
+// invalid.
+func ApparentTemperature(t, rh, windSpeed float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	ms, err := toMetersPerSecond(windSpeed, units)
+	if err != nil {
+		return 0, err
+	}
+	e := rh / 100 * 6.105 * math.Exp(17.27*c/(237.7+c))
+	return fromCelsius(c+0.33*e-0.70*ms-4.00, units), nil
+}
+
+// DewPoint accepts a temperature and relative humidity and returns the dew
+// point, the temperature the air would have to cool to for water vapor to
+// condense, using the Magnus formula. An error is returned if the units are
+// invalid or the humidity is invalid or zero.
+func DewPoint(t, rh float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	if rh == 0 {
+		return 0, errors.New("dew point is undefined for 0% relative humidity")
+	}
+	g := math.Log(rh/100) + 17.625*c/(243.04+c)
+	return fromCelsius(243.04*g/(17.625-g), units), nil
+}
+
+// Humidex accepts a temperature and relative humidity and returns the
+// humidex, the Canadian index of how hot humid weather feels. An error is
+// returned if the units or humidity are invalid.
+func Humidex(t, rh float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	return fromCelsius(c+0.5555*(vaporPressure(c, rh)-10), units), nil
+}
+
+// WetBulb accepts a temperature and relative humidity and returns the
+// wet-bulb temperature, the lowest temperature evaporation can cool the air
+// to, using Stull's 2011 approximation. The approximation is accurate to
+// within 1 C for humidities from 5% to 99% and temperatures from -20 C to
+// 50 C. An error is returned if the units or humidity are invalid.
+func WetBulb(t, rh float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	tw := c*math.Atan(0.151977*math.Sqrt(rh+8.313659)) +
+		math.Atan(c+rh) - math.Atan(rh-1.676331) +
+		0.00391838*math.Pow(rh, 1.5)*math.Atan(0.023101*rh) - 4.686035
+	return fromCelsius(tw, units), nil
+}
+
+// AbsoluteHumidity accepts a temperature and relative humidity and returns
+// the absolute humidity, the mass of water vapor in the air, in grams per
+// cubic meter whatever the units. An error is returned if the units or
+// humidity are invalid.
+func AbsoluteHumidity(t, rh float64, units weather.Units) (float64, error) {
+	c, err := toCelsius(t, units)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkHumidity(rh); err != nil {
+		return 0, err
+	}
+	// By the ideal gas law, with water vapor's specific gas constant of
+	// 461.5 J/(kg K), the vapor pressure in Pa gives kg per cubic meter.
+	return vaporPressure(c, rh) * 100 / (461.5 * (c + 273.15)) * 1000, nil
+}
+
+// beaufortLimits are the upper wind speeds, in meters per second, of
+// Beaufort forces 0 to 11. Anything faster is force 12.
+var beaufortLimits = []float64{0.2, 1.5, 3.3, 5.4, 7.9, 10.7, 13.8, 17.1, 20.7, 24.4, 28.4, 32.6}
+
+var beaufortDescriptions = []string{
+	"calm", "light air", "light breeze", "gentle breeze", "moderate breeze",
+	"fresh breeze", "strong breeze", "near gale", "gale", "strong gale",
+	"storm", "violent storm", "hurricane force",
+}
+
+// Beaufort accepts a wind speed and returns its force on the Beaufort
+// scale, from 0 (calm) to 12 (hurricane force). An error is returned if the
+// units are invalid or the speed is negative.
+func Beaufort(windSpeed float64, units weather.Units) (int, error) {
+	ms, err := toMetersPerSecond(windSpeed, units)
+	if err != nil {
+		return 0, err
+	}
+	// Limits are given to a tenth of a meter per second.
+	ms = math.Round(ms*10) / 10
+	for force, limit := range beaufortLimits {
+		if ms <= limit {
+			return force, nil
+		}
+	}
+	return len(beaufortLimits), nil
+}
+
+// BeaufortDescription accepts a Beaufort force and returns its description
+// (e.g. "gentle breeze"). An error is returned if the force is not between
+// 0 and 12.
+func BeaufortDescription(force int) (string, error) {
+	if force < 0 || force >= len(beaufortDescriptions) {
+		return "", fmt.Errorf("Beaufort force must be between 0 and %d, got %d", len(beaufortDescriptions)-1, force)
+	}
+	return beaufortDescriptions[force], nil
+}
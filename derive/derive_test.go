@@ -0,0 +1,187 @@
+package derive_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/aculclasure/weather"
+	"github.com/aculclasure/weather/derive"
+)
+
+// The NOAA heat index and wind chill charts give values rounded to whole
+// degrees Fahrenheit, so results are compared to within half a degree.
+const tableTolerance = 0.5
+
+func TestHeatIndexMatchesNOAATable(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		temp, rh, want float64
+	}{
+		"80 F at 40%":  {temp: 80, rh: 40, want: 80},
+		"80 F at 80%":  {temp: 80, rh: 80, want: 84},
+		"86 F at 90%":  {temp: 86, rh: 90, want: 105},
+		"90 F at 50%":  {temp: 90, rh: 50, want: 95},
+		"96 F at 65%":  {temp: 96, rh: 65, want: 121},
+		"100 F at 40%": {temp: 100, rh: 40, want: 109},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := derive.HeatIndex(tc.temp, tc.rh, weather.Imperial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tc.want-got) > tableTolerance {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWindChillMatchesNOAATable(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		temp, speed, want float64
+	}{
+		"40 F at 5 mph":   {temp: 40, speed: 5, want: 36},
+		"30 F at 10 mph":  {temp: 30, speed: 10, want: 21},
+		"5 F at 25 mph":   {temp: 5, speed: 25, want: -17},
+		"0 F at 15 mph":   {temp: 0, speed: 15, want: -19},
+		"-10 F at 20 mph": {temp: -10, speed: 20, want: -35},
+		"-20 F at 60 mph": {temp: -20, speed: 60, want: -62},
+		"too warm":        {temp: 60, speed: 20, want: 60},
+		"too calm":        {temp: 20, speed: 2, want: 20},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := derive.WindChill(tc.temp, tc.speed, weather.Imperial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tc.want-got) > tableTolerance {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFunctionsAreUnitAware(t *testing.T) {
+	t.Parallel()
+	// 90 F is 32.22 C or 305.37 K and feels like 94.6 F, 34.78 C or
+	// 307.93 K at 50% humidity; 0 F with 15 mph winds feels like -19.4 F,
+	// -28.55 C or 244.60 K.
+	testCases := map[string]struct {
+		f    func() (float64, error)
+		want float64
+	}{
+		"heat index in metric":   {f: func() (float64, error) { return derive.HeatIndex(32.2222, 50, weather.Metric) }, want: 34.78},
+		"heat index in standard": {f: func() (float64, error) { return derive.HeatIndex(305.3722, 50, weather.Standard) }, want: 307.93},
+		"wind chill in metric":   {f: func() (float64, error) { return derive.WindChill(-17.7778, 6.7056, weather.Metric) }, want: -28.55},
+		"wind chill in standard": {f: func() (float64, error) { return derive.WindChill(255.3722, 6.7056, weather.Standard) }, want: 244.60},
+		"dew point in imperial":  {f: func() (float64, error) { return derive.DewPoint(68, 50, weather.Imperial) }, want: 48.67},
+		"units in any case":      {f: func() (float64, error) { return derive.HeatIndex(32.2222, 50, "Metric") }, want: 34.78},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.f()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tc.want-got) > 0.01 {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMoistureCalculations(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		f         func(t, rh float64, units weather.Units) (float64, error)
+		temp, rh  float64
+		want, tol float64
+	}{
+		// NOAA dew point calculator: 20 C at 50% gives 9.3 C.
+		"dew point": {f: derive.DewPoint, temp: 20, rh: 50, want: 9.3, tol: 0.05},
+		// Environment Canada humidex table: 30 C at 70% gives 41.
+		"humidex": {f: derive.Humidex, temp: 30, rh: 70, want: 41, tol: 0.5},
+		// Stull (2011): 20 C at 50% gives a 13.7 C wet-bulb temperature.
+		"wet-bulb": {f: derive.WetBulb, temp: 20, rh: 50, want: 13.7, tol: 0.05},
+		// Saturated air holds 17.3 g/m³ at 20 C, so half that at 50%.
+		"absolute humidity": {f: derive.AbsoluteHumidity, temp: 20, rh: 50, want: 8.65, tol: 0.05},
+		// Bureau of Meteorology: 30 C at 50% with a 2 m/s wind feels like 31.6 C.
+		"apparent temperature": {f: func(t, rh float64, units weather.Units) (float64, error) {
+			return derive.ApparentTemperature(t, rh, 2, units)
+		}, temp: 30, rh: 50, want: 31.6, tol: 0.05},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.f(tc.temp, tc.rh, weather.Metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(tc.want-got) > tc.tol {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBeaufort(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		speed float64
+		units weather.Units
+		want  int
+		desc  string
+	}{
+		"calm":            {speed: 0.2, units: weather.Metric, want: 0, desc: "calm"},
+		"light air":       {speed: 0.3, units: weather.Metric, want: 1, desc: "light air"},
+		"gentle breeze":   {speed: 5.4, units: weather.Metric, want: 3, desc: "gentle breeze"},
+		"moderate breeze": {speed: 5.5, units: weather.Standard, want: 4, desc: "moderate breeze"},
+		"gale in mph":     {speed: 40, units: weather.Imperial, want: 8, desc: "gale"},
+		"hurricane force": {speed: 32.7, units: weather.Metric, want: 12, desc: "hurricane force"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := derive.Beaufort(tc.speed, tc.units)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.want != got {
+				t.Fatalf("want force %d, got %d", tc.want, got)
+			}
+			desc, err := derive.BeaufortDescription(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.desc != desc {
+				t.Fatalf("want description %q, got %q", tc.desc, desc)
+			}
+		})
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]func() error{
+		"unknown units":               func() error { _, err := derive.HeatIndex(90, 50, "kelvin"); return err },
+		"humidity over 100":           func() error { _, err := derive.Humidex(30, 101, weather.Metric); return err },
+		"negative humidity":           func() error { _, err := derive.WetBulb(30, -1, weather.Metric); return err },
+		"zero humidity dew point":     func() error { _, err := derive.DewPoint(30, 0, weather.Metric); return err },
+		"negative wind speed":         func() error { _, err := derive.WindChill(0, -5, weather.Metric); return err },
+		"Beaufort force out of range": func() error { _, err := derive.BeaufortDescription(13); return err },
+	}
+
+	for name, f := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := f(); err == nil {
+				t.Fatal("want error, got nil")
+			}
+		})
+	}
+}
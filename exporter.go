@@ -0,0 +1,315 @@
+package weather
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Exporter represents a Prometheus exporter that periodically polls the
+// current weather and air quality for a list of locations through a Client
+// and exposes the latest readings as gauges in the Prometheus text format.
+// Readings are always requested in metric units.
+type Exporter struct {
+	Client    Client
+	Locations []string
+
+	mu      sync.Mutex
+	coords  map[string]Location
+	stats   map[string]*exporterStats
+	scrapes float64
+}
+
+// exporterStats represents the latest readings and poll statistics for a
+// single location.
+type exporterStats struct {
+	obs         *Observation
+	aqi         *AirQuality
+	polls       float64
+	errors      map[string]float64
+	lastSuccess time.Time
+}
+
+// NewExporter accepts a Client and one or more locations (e.g. "london",
+// "tampa,fl,us", etc.) and returns an Exporter for those locations. An error
+// is returned if no locations are given.
+func NewExporter(c Client, locations ...string) (*Exporter, error) {
+	if len(locations) == 0 {
+		return nil, errors.New("at least one location must be given")
+	}
+
+	e := &Exporter{
+		Client:    c,
+		Locations: locations,
+		coords:    map[string]Location{},
+		stats:     map[string]*exporterStats{},
+	}
+	for _, l := range locations {
+		e.stats[l] = &exporterStats{errors: map[string]float64{}}
+	}
+	return e, nil
+}
+
+// Poll requests the current weather and air quality for each of the
+// Exporter's locations once, recording the readings of the requests that
+// succeed and counting the errors of those that fail.
+func (e *Exporter) Poll() {
+	for _, l := range e.Locations {
+		e.poll(l)
+	}
+}
+
+// poll requests the current weather and air quality for a location.
+func (e *Exporter) poll(location string) {
+	obs, obsErr := e.Client.CurrentObservation(location, Metric)
+
+	var aq AirQuality
+	loc, aqErr := e.geocode(location)
+	if aqErr == nil {
+		var data []byte
+		data, aqErr = e.Client.AirPollutionData(loc.Lat, loc.Lon)
+		if aqErr == nil {
+			aq, aqErr = DecodeAirPollution(data)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.stats[location]
+	s.polls++
+	if obsErr != nil {
+		s.errors["current"]++
+		log.Printf("error polling current weather for %s: %v", location, obsErr)
+	} else {
+		s.obs = &obs
+	}
+	if aqErr != nil {
+		s.errors["air_pollution"]++
+		log.Printf("error polling air pollution for %s: %v", location, aqErr)
+	} else {
+		s.aqi = &aq
+	}
+	if obsErr == nil && aqErr == nil {
+		s.lastSuccess = time.Now()
+	}
+}
+
+// geocode returns the coordinates of a location, looking them up only once.
+func (e *Exporter) geocode(location string) (Location, error) {
+	e.mu.Lock()
+	loc, ok := e.coords[location]
+	e.mu.Unlock()
+	if ok {
+		return loc, nil
+	}
+
+	loc, err := e.Client.Geocode(location)
+	if err != nil {
+		return Location{}, err
+	}
+	e.mu.Lock()
+	e.coords[location] = loc
+	e.mu.Unlock()
+	return loc, nil
+}
+
+// Run polls the Exporter's locations immediately and then every interval
+// until stop is closed.
+func (e *Exporter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.Poll()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ServeHTTP writes the Exporter's latest readings and poll statistics in the
+// Prometheus text exposition format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scrapes++
+	if err := e.write(w); err != nil {
+		log.Printf("error writing metrics: %v", err)
+	}
+}
+
+// exporterGauge describes a gauge exposed for each location that has a
+// reading for it.
+type exporterGauge struct {
+	name  string
+	help  string
+	value func(s *exporterStats) (float64, bool)
+}
+
+// observationGauge returns an exporterGauge reading a value from the latest
+// Observation.
+func observationGauge(name, help string, value func(o *Observation) float64) exporterGauge {
+	return exporterGauge{name, help, func(s *exporterStats) (float64, bool) {
+		if s.obs == nil {
+			return 0, false
+		}
+		return value(s.obs), true
+	}}
+}
+
+var exporterGauges = []exporterGauge{
+	observationGauge("weather_temperature_celsius", "Current temperature.",
+		func(o *Observation) float64 { return o.Temp }),
+	observationGauge("weather_feels_like_celsius", "Current apparent temperature.",
+		func(o *Observation) float64 { return o.FeelsLike }),
+	observationGauge("weather_humidity_percent", "Current relative humidity.",
+		func(o *Observation) float64 { return float64(o.Humidity) }),
+	observationGauge("weather_pressure_hpa", "Current atmospheric pressure.",
+		func(o *Observation) float64 { return o.Pressure }),
+	observationGauge("weather_wind_speed_meters_per_second", "Current wind speed.",
+		func(o *Observation) float64 { return o.WindSpeed }),
+	observationGauge("weather_wind_gust_meters_per_second", "Current wind gust speed.",
+		func(o *Observation) float64 { return o.WindGust }),
+	observationGauge("weather_wind_direction_degrees", "Current meteorological wind direction.",
+		func(o *Observation) float64 { return float64(o.WindDeg) }),
+	observationGauge("weather_clouds_percent", "Current cloudiness.",
+		func(o *Observation) float64 { return float64(o.Clouds) }),
+	observationGauge("weather_precipitation_mm", "Precipitation over the last hour.",
+		func(o *Observation) float64 { return o.Precip }),
+	{"weather_air_quality_index", "Current air quality index, from 1 (good) to 5 (very poor).",
+		func(s *exporterStats) (float64, bool) {
+			if s.aqi == nil {
+				return 0, false
+			}
+			return float64(s.aqi.Main.AQI), true
+		}},
+	{"weather_last_success_timestamp_seconds", "Unix time of the last fully successful poll.",
+		func(s *exporterStats) (float64, bool) {
+			if s.lastSuccess.IsZero() {
+				return 0, false
+			}
+			return float64(s.lastSuccess.UnixNano()) / 1e9, true
+		}},
+}
+
+// write writes the metrics in the Prometheus text exposition format. The
+// caller must hold e.mu.
+func (e *Exporter) write(w io.Writer) error {
+	var b strings.Builder
+	for _, g := range exporterGauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, l := range e.Locations {
+			if v, ok := g.value(e.stats[l]); ok {
+				fmt.Fprintf(&b, "%s{location=\"%s\"} %g\n", g.name, escapeLabel(l), v)
+			}
+		}
+	}
+
+	b.WriteString("# HELP weather_polls_total Polls of the weather APIs.\n# TYPE weather_polls_total counter\n")
+	for _, l := range e.Locations {
+		fmt.Fprintf(&b, "weather_polls_total{location=\"%s\"} %g\n", escapeLabel(l), e.stats[l].polls)
+	}
+	b.WriteString("# HELP weather_poll_errors_total Failed requests to the weather APIs.\n# TYPE weather_poll_errors_total counter\n")
+	for _, l := range e.Locations {
+		for _, api := range []string{"current", "air_pollution"} {
+			fmt.Fprintf(&b, "weather_poll_errors_total{location=\"%s\",api=\"%s\"} %g\n",
+				escapeLabel(l), api, e.stats[l].errors[api])
+		}
+	}
+	b.WriteString("# HELP weather_exporter_scrapes_total Scrapes of the exporter's metrics.\n# TYPE weather_exporter_scrapes_total counter\n")
+	fmt.Fprintf(&b, "weather_exporter_scrapes_total %g\n", e.scrapes)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// stringsFlag represents a flag that may be repeated to give several values.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, "; ")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// ExporterCLI accepts a slice of command line flags and arguments, and
+// serves Prometheus metrics for the configured locations, polling them on
+// an interval, until the process is interrupted. An error is returned if
+// the OPENWEATHER_API_KEY environment variable is not set, if the command
+// line flags are invalid, or if the server fails.
+func ExporterCLI(args []string) error {
+	var locations stringsFlag
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather exporter [-addr=:9090] [-interval=5m] -location=<location> [-location=<location>...]\n\n"))
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", ":9090", "the address to serve metrics on")
+	interval := fs.Duration("interval", 5*time.Minute, "how often to poll the weather APIs")
+	fs.Var(&locations, "location", "a location to export weather for (e.g. 'london', 'tampa,us'); may be repeated")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return errors.New("interval flag must be positive")
+	}
+
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		return errors.New("environment variable OPENWEATHER_API_KEY must be set")
+	}
+	client, err := NewClient(apiKey)
+	if err != nil {
+		return err
+	}
+	e, err := NewExporter(client, locations...)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go e.Run(*interval, stop)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving metrics on %s/metrics", *addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	select {
+	case err := <-errs:
+		return err
+	case <-sig:
+	}
+	return srv.Close()
+}
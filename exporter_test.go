@@ -0,0 +1,124 @@
+package weather_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aculclasure/weather"
+)
+
+func TestNewExporterWithoutLocationsReturnsError(t *testing.T) {
+	t.Parallel()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	if _, err := weather.NewExporter(client); err == nil {
+		t.Fatal("NewExporter(client) did not return an expected error")
+	}
+}
+
+func TestExporterServesPolledReadings(t *testing.T) {
+	t.Parallel()
+	fixtures := map[string]string{
+		"/data/2.5/weather":       "testdata/currentWeatherAPIRespMetric.json",
+		"/geo/1.0/direct":         "testdata/geocodeAPIResp.json",
+		"/data/2.5/air_pollution": "testdata/airPollutionAPIResp.json",
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == `Nowhere "Land"` {
+			http.Error(w, `{"cod": 404, "message": "city not found"}`, http.StatusNotFound)
+			return
+		}
+		data, err := ioutil.ReadFile(fixtures[r.URL.Path])
+		if err != nil {
+			t.Errorf("unable to read test data file: %v", err)
+		}
+		w.Write(data)
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	e, err := weather.NewExporter(client, "London", `Nowhere "Land"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Poll()
+	e.Poll()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	got := rec.Body.String()
+
+	wantLines := []string{
+		"# TYPE weather_temperature_celsius gauge",
+		`weather_temperature_celsius{location="London"} 11.51`,
+		`weather_feels_like_celsius{location="London"} 9.94`,
+		`weather_humidity_percent{location="London"} 47`,
+		`weather_pressure_hpa{location="London"} 1009`,
+		`weather_wind_speed_meters_per_second{location="London"} 9.26`,
+		`weather_wind_gust_meters_per_second{location="London"} 17.49`,
+		`weather_wind_direction_degrees{location="London"} 220`,
+		`weather_clouds_percent{location="London"} 20`,
+		`weather_precipitation_mm{location="London"} 0`,
+		`weather_air_quality_index{location="London"} 2`,
+		`weather_polls_total{location="London"} 2`,
+		`weather_polls_total{location="Nowhere \"Land\""} 2`,
+		`weather_poll_errors_total{location="London",api="current"} 0`,
+		`weather_poll_errors_total{location="Nowhere \"Land\"",api="current"} 2`,
+		`weather_poll_errors_total{location="Nowhere \"Land\"",api="air_pollution"} 2`,
+		"weather_exporter_scrapes_total 1",
+	}
+	for _, l := range wantLines {
+		if !strings.Contains(got, l+"\n") {
+			t.Errorf("want metrics to contain line %q", l)
+		}
+	}
+	if !strings.Contains(got, `weather_last_success_timestamp_seconds{location="London"} `) {
+		t.Error("want metrics to contain the last success timestamp for London")
+	}
+	if strings.Contains(got, `weather_temperature_celsius{location="Nowhere \"Land\""}`) {
+		t.Error("want no temperature for a location that was never polled successfully")
+	}
+	if t.Failed() {
+		t.Logf("got metrics:\n%s", got)
+	}
+}
+
+// TestExporterLogsDoNotRevealAPIKey is not parallel as it redirects the
+// standard logger.
+func TestExporterLogsDoNotRevealAPIKey(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.NotFoundHandler())
+	unreachable := testServer.URL
+	testServer.Close()
+	client, err := weather.NewClient("SECRETKEY")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.BaseURL = unreachable
+	e, err := weather.NewExporter(client, "London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e.Poll()
+	if logs.Len() == 0 {
+		t.Fatal("want poll errors logged, got nothing")
+	}
+	if strings.Contains(logs.String(), "SECRETKEY") {
+		t.Fatalf("logs reveal the API key:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,272 @@
+package weather
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// feedGenerator names the program that generated a feed.
+const feedGenerator = "github.com/aculclasure/weather"
+
+// feedTagDate is the date in the tag URIs (RFC 4151) identifying feeds and
+// their entries. It must never change, or every entry would appear new.
+const feedTagDate = "2021"
+
+// Feed represents a syndication feed of the weather at a location, with an
+// entry for the current conditions, one for each forecast day and one for
+// each alert. Entry IDs and updated times are derived from the times the
+// data is reported for, so feed readers show a new entry for each new
+// observation and alert and update the entries of forecast days in place.
+type Feed struct {
+	Location string
+	// Link is the URL the feed is served from, if known.
+	Link      string
+	Current   *Observation
+	Forecasts []DayForecast
+	Alerts    []Alert
+	// Units are the measurement units the data was requested in, and
+	// Preferences the units it is shown in, by default those of Units.
+	Units       Units
+	Preferences Preferences
+	// Locale is the language and number format the conditions are shown in.
+	Locale Locale
+}
+
+// feedEntry represents an entry of a Feed independent of its format.
+type feedEntry struct {
+	id        string
+	title     string
+	content   string
+	category  string
+	author    string
+	updated   time.Time
+	published time.Time
+}
+
+// id returns the tag URI identifying the Feed, or one of its entries if
+// parts are given.
+func (f Feed) id(parts ...string) string {
+	return fmt.Sprintf("tag:%s,%s:%s", idDomain, feedTagDate, strings.Join(append([]string{slug(f.Location)}, parts...), "/"))
+}
+
+// title returns the title of the Feed.
+func (f Feed) title() string {
+	return "Weather for " + f.Location
+}
+
+// entries returns the entries of the Feed, most recently updated first.
+// Forecast entries are updated when the current conditions are, since the
+// forecasts are fetched with them.
+func (f Feed) entries() []feedEntry {
+	prefs := f.Preferences
+	if prefs == (Preferences{}) {
+		prefs = f.Units.Preferences()
+	}
+	var entries []feedEntry
+	var asOf time.Time
+	if obs := f.Current; obs != nil {
+		o := *obs
+		if o.Units == "" {
+			o.Units = f.Units
+		}
+		q := o.Quantities().In(prefs)
+		content := fmt.Sprintf("Feels like %s. Wind %s, gusting %s. Pressure %s. Visibility %s.",
+			q.FeelsLike, q.WindSpeed, q.WindGust, q.Pressure, q.Visibility)
+		asOf = o.Time
+		entries = append(entries, feedEntry{
+			id:        f.id("conditions", fmt.Sprint(o.Time.Unix())),
+			title:     "Current conditions: " + f.Locale.FormatConditions(o, prefs),
+			content:   content,
+			category:  "conditions",
+			updated:   o.Time,
+			published: o.Time,
+		})
+	}
+	for _, d := range f.Forecasts {
+		updated := asOf
+		if updated.IsZero() {
+			updated = d.Date
+		}
+		entries = append(entries, feedEntry{
+			id:       f.id("forecast", d.Date.Format("2006-01-02")),
+			title:    fmt.Sprintf("Forecast for %s: %s", f.Locale.FormatDate(d.Date), forecastSummary(d, f.Units, prefs)),
+			content:  forecastDetails(d, f.Units, prefs),
+			category: "forecast",
+			updated:  updated,
+		})
+	}
+	for _, a := range f.Alerts {
+		entries = append(entries, feedEntry{
+			id:        f.id("alert", alertKey(a)),
+			title:     alertTitle(a),
+			content:   alertDetails(a),
+			category:  "alert",
+			author:    a.Sender,
+			updated:   a.Start,
+			published: a.Start,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].updated.After(entries[j].updated)
+	})
+	return entries
+}
+
+// feedUpdated returns when the most recently updated entry was updated, or
+// the current time if there are no entries.
+func feedUpdated(entries []feedEntry) time.Time {
+	if len(entries) == 0 || entries[0].updated.IsZero() {
+		return time.Now()
+	}
+	return entries[0].updated
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published,omitempty"`
+	Author    *atomPerson  `xml:"author,omitempty"`
+	Category  atomCategory `xml:"category"`
+	Content   atomText     `xml:"content"`
+}
+
+// EncodeAtom writes the Feed to w as an Atom (RFC 4287) feed. An error is
+// returned if writing to w fails.
+func (f Feed) EncodeAtom(w io.Writer) error {
+	entries := f.entries()
+	feed := atomFeed{
+		ID:        f.id(),
+		Title:     f.title(),
+		Updated:   feedUpdated(entries).UTC().Format(time.RFC3339),
+		Author:    atomPerson{Name: feedGenerator},
+		Generator: feedGenerator,
+	}
+	if f.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "self", Type: "application/atom+xml", Href: f.Link})
+	}
+	for _, e := range entries {
+		entry := atomEntry{
+			ID:       e.id,
+			Title:    e.title,
+			Updated:  e.updated.UTC().Format(time.RFC3339),
+			Category: atomCategory{Term: e.category},
+			Content:  atomText{Type: "text", Body: e.content},
+		}
+		if !e.published.IsZero() {
+			entry.Published = e.published.UTC().Format(time.RFC3339)
+		}
+		if e.author != "" {
+			entry.Author = &atomPerson{Name: e.author}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return encodeXML(w, feed)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// EncodeRSS writes the Feed to w as an RSS 2.0 feed. RSS has no updated
+// time for items, so each item's publication date is when it was last
+// updated. An error is returned if writing to w fails.
+func (f Feed) EncodeRSS(w io.Writer) error {
+	entries := f.entries()
+	link := f.Link
+	if link == "" {
+		link = "https://" + feedGenerator
+	}
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.title(),
+			Link:          link,
+			Description:   "Current conditions, daily forecasts and weather alerts for " + f.Location,
+			LastBuildDate: feedUpdated(entries).UTC().Format(time.RFC1123Z),
+			Generator:     feedGenerator,
+		},
+	}
+	for _, e := range entries {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       e.title,
+			Description: e.content,
+			Category:    e.category,
+			GUID:        rssGUID{Value: e.id},
+			PubDate:     e.updated.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return encodeXML(w, feed)
+}
+
+// encodeXML writes v to w as an indented XML document.
+func encodeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
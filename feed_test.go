@@ -0,0 +1,120 @@
+package weather_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testFeed() weather.Feed {
+	cal := testCalendar()
+	return weather.Feed{
+		Location: cal.Location,
+		Link:     "https://weather.example.com/feed/Tampa%2C%20FL.atom",
+		Current: &weather.Observation{
+			Time:       time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+			Summary:    "few clouds",
+			Temp:       28.5,
+			FeelsLike:  31.2,
+			Humidity:   70,
+			Pressure:   1013.25,
+			WindSpeed:  4.4704,
+			WindGust:   8.9408,
+			Visibility: 16093.44,
+			Units:      weather.Metric,
+		},
+		Forecasts:   cal.Forecasts,
+		Alerts:      cal.Alerts,
+		Units:       cal.Units,
+		Preferences: cal.Preferences,
+	}
+}
+
+func TestFeedEncode(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		encode func(weather.Feed) func(*bytes.Buffer) error
+		golden string
+	}{
+		"Atom": {
+			encode: func(f weather.Feed) func(*bytes.Buffer) error {
+				return func(b *bytes.Buffer) error { return f.EncodeAtom(b) }
+			},
+			golden: "testdata/feed.atom",
+		},
+		"RSS": {
+			encode: func(f weather.Feed) func(*bytes.Buffer) error {
+				return func(b *bytes.Buffer) error { return f.EncodeRSS(b) }
+			},
+			golden: "testdata/feed.rss",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			want, err := ioutil.ReadFile(tc.golden)
+			if err != nil {
+				t.Fatalf("unable to read test data file: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := tc.encode(testFeed())(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.Bytes(); !bytes.Equal(want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+func TestFeedEntryIDsFollowReportedTimes(t *testing.T) {
+	t.Parallel()
+	type entry struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+	}
+	entries := func(f weather.Feed) map[string]string {
+		var buf bytes.Buffer
+		if err := f.EncodeAtom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var feed struct {
+			Entries []entry `xml:"entry"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+			t.Fatalf("got invalid XML %s: %v", buf.String(), err)
+		}
+		m := make(map[string]string)
+		for _, e := range feed.Entries {
+			m[e.ID] = e.Updated
+		}
+		return m
+	}
+	first := entries(testFeed())
+	later := testFeed()
+	obs := *later.Current
+	obs.Time = obs.Time.Add(time.Hour)
+	obs.Temp = 29
+	later.Current = &obs
+	later.Forecasts[0].High = 31
+	got := entries(later)
+
+	const tag = "tag:weather.aculclasure.github.com,2021:tampa-fl/"
+	want := map[string]string{
+		tag + "conditions/1621342800":  "2021-05-18T13:00:00Z",
+		tag + "forecast/2021-05-18":    "2021-05-18T13:00:00Z",
+		tag + "forecast/2021-05-19":    "2021-05-18T13:00:00Z",
+		tag + "alert/79d510cb5cabfc17": "2021-05-18T15:00:00Z",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+	if _, ok := first[tag+"conditions/1621339200"]; !ok {
+		t.Fatalf("want the earlier observation to have its own entry, got %v", first)
+	}
+}
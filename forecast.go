@@ -0,0 +1,243 @@
+package weather
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// ForecastCLI accepts a slice of command line flags and arguments, including
+// the subcommand name, determines the location of interest, the weather
+// provider, the measurement units and the output format and prints the daily
+// forecasts for that location, along with any government weather alerts if
+// the provider reports them. The output is a table, JSON, an iCalendar
+// calendar, an Atom or RSS feed, which also includes the current conditions,
+// or a GeoJSON FeatureCollection locating them. An error is returned if the
+// command line flags and arguments are invalid, if the OpenWeather provider
+// is selected and the OPENWEATHER_API_KEY environment variable is not set, or
+// if the call to get the forecasts or alerts has a problem.
+func ForecastCLI(args []string) error {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather forecast [-units={standard|metric|imperial}[,<unit>...]] [-provider=<name>] [-lang=<language>] [-output={text|json|ics|atom|rss|geojson}] <location>\n\n"))
+		fs.PrintDefaults()
+	}
+	unitsFlag := fs.String("units", "imperial", "the units to use, one of: standard, metric, imperial, optionally followed by units to show instead of the system's own (e.g. 'metric,mph')")
+	provider := fs.String("provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: or consensus:")
+	lang := fs.String("lang", "", "the language to show the forecasts in (e.g. 'de', 'fr'), by default taken from the LANG environment variable")
+	output := fs.String("output", "text", "the output format, one of: text, json, ics, atom, rss, geojson")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *output {
+	case "text", "json", "ics", "atom", "rss", "geojson":
+	default:
+		return errors.New("output flag must be one of: text, json, ics, atom, rss, geojson")
+	}
+	units, prefs, err := ParsePreferences(*unitsFlag)
+	if err != nil {
+		return fmt.Errorf("units flag: %w", err)
+	}
+	locale := LocaleFromEnv()
+	if *lang != "" {
+		if locale, err = ParseLocale(*lang); err != nil {
+			return fmt.Errorf("lang flag: %w", err)
+		}
+	}
+	location := fs.Arg(0)
+	if location == "" {
+		return errors.New("positional argument for location must be given (e.g. 'london', 'tampa,us', etc.)")
+	}
+
+	p, err := providerFromName(*provider, locale.Lang)
+	if err != nil {
+		return err
+	}
+	forecasts, err := p.DailyForecast(location, units)
+	if err != nil {
+		return err
+	}
+	for i := range forecasts {
+		if forecasts[i].Units == "" {
+			forecasts[i].Units = units
+		}
+	}
+	var alerts []Alert
+	if ap, ok := p.(AlertProvider); ok {
+		if alerts, err = ap.Alerts(location); err != nil {
+			return err
+		}
+	}
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Forecasts []DayForecast `json:"forecasts"`
+			Alerts    []Alert       `json:"alerts"`
+		}{forecasts, alerts})
+	case "ics":
+		cal := Calendar{
+			Location:    location,
+			Forecasts:   forecasts,
+			Alerts:      alerts,
+			Units:       units,
+			Preferences: prefs,
+		}
+		return cal.Encode(os.Stdout)
+	case "atom", "rss":
+		obs, err := p.CurrentObservation(location, units)
+		if err != nil {
+			return err
+		}
+		feed := Feed{
+			Location:    location,
+			Current:     &obs,
+			Forecasts:   forecasts,
+			Alerts:      alerts,
+			Units:       units,
+			Preferences: prefs,
+			Locale:      locale,
+		}
+		if *output == "rss" {
+			return feed.EncodeRSS(os.Stdout)
+		}
+		return feed.EncodeAtom(os.Stdout)
+	case "geojson":
+		loc, err := geocodeWith(p, location)
+		if err != nil {
+			return err
+		}
+		if loc.Name == "" {
+			loc.Name = location
+		}
+		return NewFeatureCollection(ForecastFeature(loc, forecasts, alerts)).Encode(os.Stdout)
+	}
+	return writeForecast(os.Stdout, locale, prefs, forecasts, alerts)
+}
+
+// writeForecast writes forecasts to w as a table shown in prefs, followed by
+// a line for each alert.
+func writeForecast(w io.Writer, l Locale, prefs Preferences, forecasts []DayForecast, alerts []Alert) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "DATE\tSUMMARY\tHIGH (%s)\tLOW (%s)\tPRECIP (%%)\n", prefs.Temperature, prefs.Temperature)
+	for _, f := range forecasts {
+		high := Temperature{f.High, f.Units.Temperature()}.In(prefs.Temperature)
+		low := Temperature{f.Low, f.Units.Temperature()}.In(prefs.Temperature)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.0f\n",
+			l.FormatDate(f.Date), strings.TrimSpace(f.Summary),
+			l.FormatFloat(high.Value, 1), l.FormatFloat(low.Value, 1), f.PrecipProb*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, a := range alerts {
+		fmt.Fprintf(w, "\nALERT: %s (%s to %s)\n", a.Event,
+			a.Start.Local().Format(time.RFC3339), a.End.Local().Format(time.RFC3339))
+		if a.Headline != "" && a.Headline != a.Event {
+			fmt.Fprintln(w, a.Headline)
+		}
+	}
+	return nil
+}
+
+// forecastSummary returns a one-line summary of the forecast day f shown in
+// prefs, like "moderate rain, high 86 F, low 72 F". A forecast that does not
+// report its units is taken to be in units, and empty prefs default to the
+// preferences of its units.
+func forecastSummary(f DayForecast, units Units, prefs Preferences) string {
+	if f.Units != "" {
+		units = f.Units
+	}
+	if prefs == (Preferences{}) {
+		prefs = units.Preferences()
+	}
+	high := Temperature{f.High, units.Temperature()}.In(prefs.Temperature)
+	low := Temperature{f.Low, units.Temperature()}.In(prefs.Temperature)
+	summary := fmt.Sprintf("high %.0f %s, low %.0f %s", high.Value, high.Unit, low.Value, low.Unit)
+	if s := strings.TrimSpace(f.Summary); s != "" {
+		summary = s + ", " + summary
+	}
+	return summary
+}
+
+// forecastDetails returns the chance and amount of precipitation and the
+// humidity forecast for the day f, with the amount shown in prefs.
+func forecastDetails(f DayForecast, units Units, prefs Preferences) string {
+	if f.Units != "" {
+		units = f.Units
+	}
+	if prefs == (Preferences{}) {
+		prefs = units.Preferences()
+	}
+	precip := Precipitation{f.Precip, Millimeters}.In(prefs.Precipitation)
+	return fmt.Sprintf("Chance of precipitation %.0f%%, %s expected. Humidity %d%%.",
+		f.PrecipProb*100, precip, f.Humidity)
+}
+
+// alertTitle returns a one-line title for the alert a, preferring its
+// headline to its event.
+func alertTitle(a Alert) string {
+	if a.Headline != "" {
+		return "Weather alert: " + a.Headline
+	}
+	return "Weather alert: " + a.Event
+}
+
+// alertDetails returns the description and instructions of the alert a,
+// followed by who issued it.
+func alertDetails(a Alert) string {
+	details := strings.TrimSpace(a.Description)
+	if a.Instruction != "" {
+		details += "\n\n" + strings.TrimSpace(a.Instruction)
+	}
+	if a.Sender != "" {
+		details += "\n\nIssued by " + a.Sender
+	}
+	return strings.TrimSpace(details)
+}
+
+// alertKey returns a short identifier for the alert a that stays the same
+// as long as its ID does, or its sender, event and start time if it has no
+// ID.
+func alertKey(a Alert) string {
+	id := a.ID
+	if id == "" {
+		id = a.Sender + "/" + a.Event + "/" + a.Start.UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// slug returns s in lower case with each run of characters other than
+// letters and digits replaced by a hyphen (e.g. "tampa-fl-us").
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
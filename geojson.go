@@ -0,0 +1,131 @@
+package weather
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Feature represents a GeoJSON (RFC 7946) Feature locating weather data at
+// a Point. Properties holds the data, typically one of the package's types
+// along with the name of the location.
+type Feature struct {
+	Type       string      `json:"type"`
+	ID         string      `json:"id,omitempty"`
+	Geometry   Point       `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+// Point represents a GeoJSON Point geometry. Coordinates are longitude then
+// latitude, in that order, as GeoJSON requires.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// FeatureCollection represents a GeoJSON FeatureCollection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// NewPoint accepts a latitude and longitude and returns a Point at them.
+func NewPoint(lat, lon float64) Point {
+	return Point{Type: "Point", Coordinates: [2]float64{lon, lat}}
+}
+
+// NewFeatureCollection accepts any number of Features and returns a
+// FeatureCollection of them.
+func NewFeatureCollection(features ...Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// Encode writes the FeatureCollection to w as indented JSON. An error is
+// returned if writing to w fails.
+func (fc FeatureCollection) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
+
+// locationProperties holds the properties describing a Location.
+type locationProperties struct {
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+}
+
+// newFeature returns a Feature at loc with the given properties, identified
+// by the location's name.
+func newFeature(loc Location, properties interface{}) Feature {
+	return Feature{
+		Type:       "Feature",
+		ID:         slug(loc.Name),
+		Geometry:   NewPoint(loc.Lat, loc.Lon),
+		Properties: properties,
+	}
+}
+
+// LocationFeature accepts a Location, such as one returned by a Geocoder,
+// and returns a Feature at it whose properties are its name and country.
+func LocationFeature(loc Location) Feature {
+	return newFeature(loc, locationProperties{Name: loc.Name, Country: loc.Country})
+}
+
+// ObservationFeature accepts a Location and the Observation made there and
+// returns a Feature at the location whose properties are its name and
+// country followed by the fields of the observation.
+func ObservationFeature(loc Location, obs Observation) Feature {
+	return newFeature(loc, struct {
+		locationProperties
+		Observation
+	}{locationProperties{Name: loc.Name, Country: loc.Country}, obs})
+}
+
+// ForecastFeature accepts a Location, its daily forecasts and the alerts in
+// effect there, either of which may be empty, and returns a Feature at the
+// location whose properties are its name and country, the forecasts and
+// the alerts.
+func ForecastFeature(loc Location, forecasts []DayForecast, alerts []Alert) Feature {
+	if forecasts == nil {
+		forecasts = []DayForecast{}
+	}
+	if alerts == nil {
+		alerts = []Alert{}
+	}
+	return newFeature(loc, struct {
+		locationProperties
+		Forecasts []DayForecast `json:"forecasts"`
+		Alerts    []Alert       `json:"alerts"`
+	}{locationProperties{Name: loc.Name, Country: loc.Country}, forecasts, alerts})
+}
+
+// ConditionsFeatures accepts a Provider, any number of locations and the
+// measurement units to use and returns a FeatureCollection with a Feature
+// for the current conditions at each location, in the order given.
+// Locations are geocoded with the Provider, and a location that resolves
+// without a name, such as one given as "lat,lon", is named as given. An
+// error is returned if a location cannot be geocoded or its conditions
+// cannot be retrieved.
+func ConditionsFeatures(p Provider, locations []string, units Units) (FeatureCollection, error) {
+	features := []Feature{}
+	for _, location := range locations {
+		loc, err := geocodeWith(p, location)
+		if err != nil {
+			return FeatureCollection{}, err
+		}
+		if loc.Name == "" {
+			loc.Name = location
+		}
+		obs, err := p.CurrentObservation(location, units)
+		if err != nil {
+			return FeatureCollection{}, err
+		}
+		if obs.Units == "" {
+			obs.Units = units
+		}
+		features = append(features, ObservationFeature(loc, obs))
+	}
+	return NewFeatureCollection(features...), nil
+}
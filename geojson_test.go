@@ -0,0 +1,189 @@
+package weather_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+// decodeGeoJSON encodes fc and decodes it again into generic JSON values,
+// so tests compare exactly what a GeoJSON consumer would see.
+func decodeGeoJSON(t *testing.T, fc weather.FeatureCollection) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := fc.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("encoded FeatureCollection is not valid JSON: %v\n%s", err, buf.String())
+	}
+	return got
+}
+
+func TestFeatureCollectionEncode(t *testing.T) {
+	t.Parallel()
+	tampa := weather.Location{Name: "Tampa", Country: "US", Lat: 27.9477595, Lon: -82.458444}
+	testCases := map[string]struct {
+		fc   weather.FeatureCollection
+		want map[string]interface{}
+	}{
+		"empty collection has an empty features array": {
+			fc: weather.NewFeatureCollection(),
+			want: map[string]interface{}{
+				"type":     "FeatureCollection",
+				"features": []interface{}{},
+			},
+		},
+		"location is a Point at longitude then latitude": {
+			fc: weather.NewFeatureCollection(weather.LocationFeature(tampa)),
+			want: map[string]interface{}{
+				"type": "FeatureCollection",
+				"features": []interface{}{
+					map[string]interface{}{
+						"type": "Feature",
+						"id":   "tampa",
+						"geometry": map[string]interface{}{
+							"type":        "Point",
+							"coordinates": []interface{}{-82.458444, 27.9477595},
+						},
+						"properties": map[string]interface{}{
+							"name":    "Tampa",
+							"country": "US",
+						},
+					},
+				},
+			},
+		},
+		"observation fields are properties alongside the name": {
+			fc: weather.NewFeatureCollection(weather.ObservationFeature(
+				weather.Location{Name: "51.5,-0.12", Lat: 51.5, Lon: -0.12},
+				weather.Observation{
+					Time:    time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+					Summary: "light rain",
+					Temp:    12.5,
+					Units:   weather.Metric,
+				},
+			)),
+			want: map[string]interface{}{
+				"type": "FeatureCollection",
+				"features": []interface{}{
+					map[string]interface{}{
+						"type": "Feature",
+						"id":   "51-5-0-12",
+						"geometry": map[string]interface{}{
+							"type":        "Point",
+							"coordinates": []interface{}{-0.12, 51.5},
+						},
+						"properties": map[string]interface{}{
+							"name":       "51.5,-0.12",
+							"time":       "2021-05-18T12:00:00Z",
+							"summary":    "light rain",
+							"temp":       12.5,
+							"feels_like": 0.0,
+							"humidity":   0.0,
+							"pressure":   0.0,
+							"wind_speed": 0.0,
+							"wind_gust":  0.0,
+							"wind_deg":   0.0,
+							"clouds":     0.0,
+							"visibility": 0.0,
+							"precip":     0.0,
+							"units":      "metric",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := decodeGeoJSON(t, tc.fc)
+			if !cmp.Equal(tc.want, got) {
+				t.Error(cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestForecastFeatureHasEmptyArraysWithoutData(t *testing.T) {
+	t.Parallel()
+	loc := weather.Location{Name: "Tampa", Lat: 27.9, Lon: -82.4}
+	got := decodeGeoJSON(t, weather.NewFeatureCollection(weather.ForecastFeature(loc, nil, nil)))
+	props := got["features"].([]interface{})[0].(map[string]interface{})["properties"]
+	want := map[string]interface{}{
+		"name":      "Tampa",
+		"forecasts": []interface{}{},
+		"alerts":    []interface{}{},
+	}
+	if !cmp.Equal(want, props) {
+		t.Error(cmp.Diff(want, props))
+	}
+}
+
+func TestConditionsFeatures(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		provider    weather.Provider
+		locations   []string
+		want        weather.FeatureCollection
+		errExpected bool
+	}{
+		"geocoded location is used for the point and name": {
+			provider: fakeProvider{
+				obs: weather.Observation{Summary: "clear sky", Temp: 20},
+				loc: weather.Location{Name: "London", Country: "GB", Lat: 51.5073219, Lon: -0.1276474},
+			},
+			locations: []string{"london"},
+			want: weather.NewFeatureCollection(weather.ObservationFeature(
+				weather.Location{Name: "London", Country: "GB", Lat: 51.5073219, Lon: -0.1276474},
+				weather.Observation{Summary: "clear sky", Temp: 20, Units: weather.Metric},
+			)),
+		},
+		"coordinates are named as given": {
+			provider: fakeProvider{
+				obs: weather.Observation{Summary: "clear sky", Temp: 20, Units: weather.Metric},
+			},
+			locations: []string{"51.5,-0.12"},
+			want: weather.NewFeatureCollection(weather.ObservationFeature(
+				weather.Location{Name: "51.5,-0.12", Lat: 51.5, Lon: -0.12},
+				weather.Observation{Summary: "clear sky", Temp: 20, Units: weather.Metric},
+			)),
+		},
+		"no locations gives an empty collection": {
+			provider: fakeProvider{},
+			want:     weather.NewFeatureCollection(),
+		},
+		"provider error is returned": {
+			provider:    fakeProvider{err: errors.New("boom")},
+			locations:   []string{"london"},
+			errExpected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := weather.ConditionsFeatures(tc.provider, tc.locations, weather.Metric)
+			if tc.errExpected != (err != nil) {
+				t.Fatalf("ConditionsFeatures(%v) returned unexpected error status: %v", tc.locations, err)
+			}
+			if tc.errExpected {
+				return
+			}
+			want, gotJSON := decodeGeoJSON(t, tc.want), decodeGeoJSON(t, got)
+			if !cmp.Equal(want, gotJSON) {
+				t.Error(cmp.Diff(want, gotJSON))
+			}
+		})
+	}
+}
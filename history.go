@@ -0,0 +1,434 @@
+package weather
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// historyMigrations are the SQL statements that bring a History database's
+// schema up to date, in order. The number of migrations already applied to
+// a database is kept in its user_version pragma, so new migrations must
+// only ever be appended.
+var historyMigrations = []string{
+	`CREATE TABLE observations (
+		location    TEXT    NOT NULL,
+		source      TEXT    NOT NULL,
+		time        INTEGER NOT NULL,
+		summary     TEXT    NOT NULL,
+		temp        REAL    NOT NULL,
+		feels_like  REAL    NOT NULL,
+		humidity    INTEGER NOT NULL,
+		pressure    REAL    NOT NULL,
+		wind_speed  REAL    NOT NULL,
+		wind_gust   REAL    NOT NULL,
+		wind_deg    INTEGER NOT NULL,
+		clouds      INTEGER NOT NULL,
+		visibility  REAL    NOT NULL,
+		precip      REAL    NOT NULL,
+		recorded_at INTEGER NOT NULL,
+		UNIQUE (location, source, time)
+	)`,
+	`CREATE INDEX observations_location_time ON observations (location, time)`,
+	`CREATE TABLE forecasts (
+		location     TEXT    NOT NULL,
+		source       TEXT    NOT NULL,
+		fetched_at   INTEGER NOT NULL,
+		fetched_date TEXT    NOT NULL,
+		date         TEXT    NOT NULL,
+		summary      TEXT    NOT NULL,
+		low          REAL    NOT NULL,
+		high         REAL    NOT NULL,
+		humidity     INTEGER NOT NULL,
+		precip_prob  REAL    NOT NULL,
+		precip       REAL    NOT NULL,
+		UNIQUE (location, source, fetched_date, date)
+	)`,
+}
+
+// History represents a local SQLite database of observations recorded over
+// time, in metric units, for one or more locations.
+type History struct {
+	db *sql.DB
+}
+
+// OpenHistory accepts the path of a SQLite database file, creating it if it
+// does not exist, applies any schema migrations it is missing and returns a
+// History backed by it. An error is returned if the database cannot be
+// opened or migrated.
+func OpenHistory(path string) (*History, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows only one writer at a time, so share one connection
+	// rather than failing with "database is locked".
+	db.SetMaxOpenConns(1)
+	h := &History{db: db}
+	if err := h.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating history database %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// migrate applies the migrations the database has not yet had, each in its
+// own transaction.
+func (h *History) migrate() error {
+	var version int
+	if err := h.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(historyMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this program supports (%d)", version, len(historyMigrations))
+	}
+	for i := version; i < len(historyMigrations); i++ {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(historyMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA statements cannot take parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the History's database.
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+// HistoryRecord represents an observation recorded in a History.
+type HistoryRecord struct {
+	Location string `json:"location"`
+	Source   string `json:"source"`
+	Observation
+}
+
+// Record accepts a location, the name of the source it was observed by
+// (e.g. "owm") and an Observation, and stores the observation in metric
+// units, converting it if it was reported in others. An observation without
+// units is taken to be metric. Observations are deduplicated by location,
+// source and observation time, so recording the same observation twice only
+// stores it once; the returned bool reports whether the observation was
+// new. An observation without a time is recorded at the current minute. An
+// error is returned if the location or source are empty, if the
+// observation's units are invalid or if the observation cannot be stored.
+func (h *History) Record(location, source string, obs Observation) (bool, error) {
+	if location == "" {
+		return false, errEmptyLocation
+	}
+	if source == "" {
+		return false, errors.New("source must not be empty")
+	}
+	if obs.Units != "" {
+		if !obs.Units.Valid() {
+			return false, ErrInvalidUnits
+		}
+		obs = obs.In(Metric)
+	}
+	now := time.Now()
+	if obs.Time.IsZero() {
+		obs.Time = now.Truncate(time.Minute)
+	}
+	res, err := h.db.Exec(`INSERT OR IGNORE INTO observations (
+			location, source, time, summary, temp, feels_like, humidity, pressure,
+			wind_speed, wind_gust, wind_deg, clouds, visibility, precip, recorded_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		location, source, obs.Time.Unix(), obs.Summary, obs.Temp, obs.FeelsLike, obs.Humidity, obs.Pressure,
+		obs.WindSpeed, obs.WindGust, obs.WindDeg, obs.Clouds, obs.Visibility, obs.Precip, now.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// HistoryQuery represents a filter on the records of a History. Empty fields
+// do not filter.
+type HistoryQuery struct {
+	Location string
+	Source   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Query returns the records matching q, oldest first. An error is returned
+// if the database cannot be queried.
+func (h *History) Query(q HistoryQuery) ([]HistoryRecord, error) {
+	var where []string
+	var args []interface{}
+	if q.Location != "" {
+		where = append(where, "location = ?")
+		args = append(args, q.Location)
+	}
+	if q.Source != "" {
+		where = append(where, "source = ?")
+		args = append(args, q.Source)
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, q.Since.Unix())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "time <= ?")
+		args = append(args, q.Until.Unix())
+	}
+	query := `SELECT location, source, time, summary, temp, feels_like, humidity, pressure,
+		wind_speed, wind_gust, wind_deg, clouds, visibility, precip FROM observations`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY time, location, source"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []HistoryRecord
+	for rows.Next() {
+		var r HistoryRecord
+		var ts int64
+		if err := rows.Scan(&r.Location, &r.Source, &ts, &r.Summary, &r.Temp, &r.FeelsLike, &r.Humidity, &r.Pressure,
+			&r.WindSpeed, &r.WindGust, &r.WindDeg, &r.Clouds, &r.Visibility, &r.Precip); err != nil {
+			return nil, err
+		}
+		r.Time = time.Unix(ts, 0).UTC()
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// RecordCLI accepts a slice of command line flags and arguments and records
+// the current observation for each location argument in the history
+// database, along with a snapshot of the daily forecast if the forecast flag
+// is set, once or, if the interval flag is set, on that interval until the
+// process is interrupted. An error is returned if the flags are
+// invalid, if no location is given, if the provider or database cannot be
+// opened, or if recording fails when not running on an interval.
+func RecordCLI(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather record [-db=weather.db] [-provider=<name>] [-interval=<interval>] [-forecast] <location> [<location>...]\n\n"))
+		fs.PrintDefaults()
+	}
+	path := fs.String("db", "weather.db", "the SQLite database to record observations in")
+	source := fs.String("provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: or consensus:")
+	interval := fs.Duration("interval", 0, "record on this interval (e.g. '10m') until interrupted, instead of once")
+	forecast := fs.Bool("forecast", false, "also record the daily forecast, to track its accuracy with 'weather accuracy'")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one location positional argument must be given (e.g. 'london', 'tampa,us', etc.)")
+	}
+	if *interval < 0 {
+		return errors.New("interval flag must not be negative")
+	}
+
+	p, err := providerFromName(*source, "")
+	if err != nil {
+		return err
+	}
+	h, err := OpenHistory(*path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	record := func() error {
+		var firstErr error
+		for _, loc := range fs.Args() {
+			err := recordOnce(os.Stdout, h, p, loc, *source)
+			if err == nil && *forecast {
+				err = recordForecastOnce(os.Stdout, h, p, loc, *source)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error recording %s: %v\n", loc, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return firstErr
+	}
+	if *interval == 0 {
+		return record()
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	for {
+		record()
+		select {
+		case <-ticker.C:
+		case <-sig:
+			return nil
+		}
+	}
+}
+
+// recordOnce records the current observation for a location from p,
+// writing a line to w describing what was recorded.
+func recordOnce(w io.Writer, h *History, p Provider, location, source string) error {
+	obs, err := p.CurrentObservation(location, Metric)
+	if err != nil {
+		return err
+	}
+	added, err := h.Record(location, source, obs)
+	if err != nil {
+		return err
+	}
+	status := "recorded"
+	if !added {
+		status = "already recorded"
+	}
+	fmt.Fprintf(w, "%s %s observation at %s: %s, %.2f C\n", status, location, obs.Time.Format(time.RFC3339), obs.Summary, obs.Temp)
+	return nil
+}
+
+// recordForecastOnce records a snapshot of the daily forecast for a
+// location from p, writing a line to w describing what was recorded.
+func recordForecastOnce(w io.Writer, h *History, p Provider, location, source string) error {
+	fetched := time.Now()
+	forecasts, err := p.DailyForecast(location, Metric)
+	if err != nil {
+		return err
+	}
+	n, err := h.RecordForecast(location, source, fetched, forecasts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "recorded %d of %d new daily forecasts for %s\n", n, len(forecasts), location)
+	return nil
+}
+
+// HistoryCLI accepts a slice of command line flags and arguments and runs
+// the history subcommand named by the first argument. The "query"
+// subcommand prints the recorded observations matching the location, since
+// and until flags, as a table or as JSON. An error is returned if the
+// subcommand is unknown, if the flags are invalid or if the database cannot
+// be opened or queried.
+func HistoryCLI(args []string) error {
+	const usage = "USAGE: weather history query [-db=weather.db] [-location=<location>] [-since=<time|duration>] [-until=<time|duration>] [-limit=<n>] [-format={text|json}]"
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if len(args) == 0 || args[0] != "query" {
+		return errors.New(usage)
+	}
+
+	fs := flag.NewFlagSet("history query", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte(usage + "\n\n"))
+		fs.PrintDefaults()
+	}
+	path := fs.String("db", "weather.db", "the SQLite database observations are recorded in")
+	location := fs.String("location", "", "only show observations for this location")
+	since := fs.String("since", "", "only show observations from this RFC 3339 time, or this long ago (e.g. '24h')")
+	until := fs.String("until", "", "only show observations up to this RFC 3339 time, or this long ago")
+	limit := fs.Int("limit", 0, "show at most this many observations")
+	format := fs.String("format", "text", "the output format, one of: text, json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return errors.New("format flag must be one of: text, json")
+	}
+
+	q := HistoryQuery{Location: *location, Limit: *limit}
+	var err error
+	now := time.Now()
+	if q.Since, err = parseTimeFlag(*since, now); err != nil {
+		return fmt.Errorf("since flag: %w", err)
+	}
+	if q.Until, err = parseTimeFlag(*until, now); err != nil {
+		return fmt.Errorf("until flag: %w", err)
+	}
+
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+	h, err := OpenHistory(*path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	records, err := h.Query(q)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	return writeHistory(os.Stdout, records)
+}
+
+// parseTimeFlag accepts the value of a time flag, either an RFC 3339 time
+// or a duration before now, and returns the time it refers to. An empty
+// value returns the zero time. An error is returned if the value is neither.
+func parseTimeFlag(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q must be an RFC 3339 time (e.g. '2021-05-18T12:00:00Z') or a duration (e.g. '24h')", v)
+	}
+	return now.Add(-d), nil
+}
+
+// writeHistory writes records to w as a table.
+func writeHistory(w io.Writer, records []HistoryRecord) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tLOCATION\tSOURCE\tSUMMARY\tTEMP (C)\tHUMIDITY (%)\tPRESSURE (hPa)\tWIND (m/s)\tPRECIP (mm)")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\t%d\t%.0f\t%.2f\t%.2f\n",
+			r.Time.Format(time.RFC3339), r.Location, r.Source, r.Summary,
+			r.Temp, r.Humidity, r.Pressure, r.WindSpeed, r.Precip)
+	}
+	return tw.Flush()
+}
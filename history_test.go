@@ -0,0 +1,199 @@
+package weather_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func openTestHistory(t *testing.T) (*weather.History, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "weather.db")
+	h, err := weather.OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h, path
+}
+
+func TestHistoryRecordDeduplicatesByObservationTime(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	obs := weather.Observation{
+		Time:     time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+		Summary:  "few clouds",
+		Temp:     11.51,
+		Humidity: 47,
+	}
+
+	for i, want := range []bool{true, false} {
+		added, err := h.Record("london", "owm", obs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != added {
+			t.Fatalf("record %d: want added %v, got %v", i+1, want, added)
+		}
+	}
+	added, err := h.Record("london", "openmeteo", obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added {
+		t.Fatal("want the same time from another source to be recorded")
+	}
+
+	got, err := h.Query(weather.HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weather.HistoryRecord{
+		{Location: "london", Source: "openmeteo", Observation: obs},
+		{Location: "london", Source: "owm", Observation: obs},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestHistoryRecordInvalidArgs(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	if _, err := h.Record("", "owm", weather.Observation{}); err == nil {
+		t.Fatal("want error for empty location")
+	}
+	if _, err := h.Record("london", "", weather.Observation{}); err == nil {
+		t.Fatal("want error for empty source")
+	}
+	if _, err := h.Record("london", "owm", weather.Observation{Units: "martian"}); err == nil {
+		t.Fatal("want error for invalid units")
+	}
+}
+
+func TestHistoryRecordConvertsToMetric(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	obs := weather.Observation{
+		Time:      time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+		Temp:      52.72,
+		FeelsLike: 49.89,
+		WindSpeed: 20.71,
+		WindGust:  39.12,
+		Pressure:  1009,
+		Units:     weather.Imperial,
+	}
+	if _, err := h.Record("london", "owm", obs); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := h.Query(weather.HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("want 1 record, got %d", len(got))
+	}
+	want := weather.Observation{
+		Time:      obs.Time,
+		Temp:      11.51,
+		FeelsLike: 9.94,
+		WindSpeed: 9.26,
+		WindGust:  17.49,
+		Pressure:  1009,
+	}
+	if !cmp.Equal(want, got[0].Observation, cmpopts.EquateApprox(0, 0.01)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got[0].Observation))
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	start := time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		for _, loc := range []string{"london", "tampa"} {
+			obs := weather.Observation{Time: start.Add(time.Duration(i) * time.Hour), Temp: float64(i)}
+			if _, err := h.Record(loc, "owm", obs); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	testCases := map[string]struct {
+		query weather.HistoryQuery
+		want  []string
+	}{
+		"location": {
+			query: weather.HistoryQuery{Location: "tampa"},
+			want:  []string{"tampa 00:00", "tampa 01:00", "tampa 02:00", "tampa 03:00"},
+		},
+		"time range": {
+			query: weather.HistoryQuery{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)},
+			want:  []string{"london 01:00", "tampa 01:00", "london 02:00", "tampa 02:00"},
+		},
+		"location, since and limit": {
+			query: weather.HistoryQuery{Location: "london", Since: start.Add(time.Hour), Limit: 2},
+			want:  []string{"london 01:00", "london 02:00"},
+		},
+		"no matches": {
+			query: weather.HistoryQuery{Location: "paris"},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			records, err := h.Query(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.Location+" "+r.Time.Format("15:04"))
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestOpenHistoryMigrations(t *testing.T) {
+	t.Parallel()
+	h, path := openTestHistory(t)
+	if _, err := h.Record("london", "owm", weather.Observation{Time: time.Unix(1621339200, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	h, err := weather.OpenHistory(path)
+	if err != nil {
+		t.Fatalf("want reopening a migrated database to succeed, got %v", err)
+	}
+	records, err := h.Query(weather.HistoryQuery{})
+	h.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("want 1 record kept across reopening, got %d", len(records))
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := weather.OpenHistory(path); err == nil {
+		t.Fatal("want error opening a database with a newer schema, got nil")
+	}
+}
@@ -0,0 +1,159 @@
+package weather
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsProductID identifies the program that generated a calendar.
+const icsProductID = "-//aculclasure//weather//EN"
+
+// idDomain is the domain the identifiers of calendar events and feed
+// entries are qualified with to make them globally unique.
+const idDomain = "weather.aculclasure.github.com"
+
+// icsLineLimit is the longest a content line may be, in octets, before it
+// must be folded onto the next.
+const icsLineLimit = 75
+
+// Calendar represents an iCalendar (RFC 5545) calendar of the forecasts and
+// alerts for a location, with an all-day event for each forecast day and a
+// timed event for each alert. Events have UIDs derived from the location,
+// day or alert, so importing an updated calendar updates its events instead
+// of duplicating them.
+type Calendar struct {
+	Location  string
+	Forecasts []DayForecast
+	Alerts    []Alert
+	// Units are the measurement units the forecasts were requested in, and
+	// Preferences the units they are shown in, by default those of Units.
+	Units       Units
+	Preferences Preferences
+	// Stamp is when the calendar was generated, written as the DTSTAMP of
+	// its events. The current time is used if it is zero.
+	Stamp time.Time
+}
+
+// Encode writes the Calendar to w in the iCalendar format. An error is
+// returned if writing to w fails.
+func (c Calendar) Encode(w io.Writer) error {
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	e := icsEncoder{w: w}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", icsProductID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	e.line("X-WR-CALNAME", icsText("Weather for "+c.Location))
+	for _, f := range c.Forecasts {
+		c.encodeForecast(&e, f, stamp)
+	}
+	for _, a := range c.Alerts {
+		c.encodeAlert(&e, a, stamp)
+	}
+	e.line("END", "VCALENDAR")
+	return e.err
+}
+
+// encodeForecast writes an all-day event for the forecast day f.
+func (c Calendar) encodeForecast(e *icsEncoder, f DayForecast, stamp time.Time) {
+	day := f.Date.Format("20060102")
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", icsUID("forecast-"+day+"-"+slug(c.Location)))
+	e.line("DTSTAMP", icsTime(stamp))
+	e.line("LAST-MODIFIED", icsTime(stamp))
+	e.line("DTSTART;VALUE=DATE", day)
+	e.line("DTEND;VALUE=DATE", f.Date.AddDate(0, 0, 1).Format("20060102"))
+	e.line("SUMMARY", icsText(forecastSummary(f, c.Units, c.Preferences)))
+	e.line("DESCRIPTION", icsText(forecastDetails(f, c.Units, c.Preferences)))
+	e.line("TRANSP", "TRANSPARENT")
+	e.line("END", "VEVENT")
+}
+
+// encodeAlert writes a timed event for the alert a, lasting from its start
+// to its end.
+func (c Calendar) encodeAlert(e *icsEncoder, a Alert, stamp time.Time) {
+	start := a.Start
+	if start.IsZero() {
+		start = stamp
+	}
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", icsUID("alert-"+alertKey(a)))
+	e.line("DTSTAMP", icsTime(stamp))
+	e.line("LAST-MODIFIED", icsTime(stamp))
+	e.line("DTSTART", icsTime(start))
+	if !a.End.IsZero() && a.End.After(start) {
+		e.line("DTEND", icsTime(a.End))
+	}
+	e.line("SUMMARY", icsText(alertTitle(a)))
+	e.line("DESCRIPTION", icsText(alertDetails(a)))
+	e.line("CATEGORIES", "WEATHER ALERT")
+	if a.Severity != "" {
+		e.line("X-WEATHER-SEVERITY", icsText(a.Severity))
+	}
+	e.line("END", "VEVENT")
+}
+
+// icsEncoder writes content lines, folding those that are too long and
+// remembering the first error.
+type icsEncoder struct {
+	w   io.Writer
+	err error
+}
+
+// line writes a content line made of a property name, which may include
+// parameters, and its already escaped value.
+func (e *icsEncoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = io.WriteString(e.w, fold(name+":"+value)+"\r\n")
+}
+
+// fold splits a content line longer than icsLineLimit octets into lines
+// joined by CRLF and a space, without splitting a UTF-8 character.
+func fold(line string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i])
+		b.WriteString("\r\n ")
+		line = line[i:]
+		// Continuation lines start with a space, which counts toward
+		// their length.
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// icsText escapes s for use as a TEXT property value.
+func icsText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// icsTime formats t as a UTC DATE-TIME value.
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icsUID returns a globally unique identifier for an event with the given
+// local identifier.
+func icsUID(id string) string {
+	return id + "@" + idDomain
+}
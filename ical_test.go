@@ -0,0 +1,124 @@
+package weather_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testCalendar() weather.Calendar {
+	return weather.Calendar{
+		Location:    "Tampa, FL",
+		Units:       weather.Metric,
+		Preferences: weather.Imperial.Preferences(),
+		Stamp:       time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+		Forecasts: []weather.DayForecast{
+			{
+				Date:       time.Date(2021, 5, 18, 17, 0, 0, 0, time.UTC),
+				Summary:    "moderate rain",
+				Low:        22,
+				High:       30,
+				Humidity:   72,
+				PrecipProb: 0.8,
+				Precip:     12.7,
+				Units:      weather.Metric,
+			},
+			{
+				Date:       time.Date(2021, 5, 19, 17, 0, 0, 0, time.UTC),
+				Summary:    "clear sky",
+				Low:        21,
+				High:       31.5,
+				Humidity:   60,
+				PrecipProb: 0.1,
+			},
+		},
+		Alerts: []weather.Alert{
+			{
+				ID:          "urn:oid:2.49.0.1.840.0.abc",
+				Event:       "Heat Advisory",
+				Headline:    "Heat Advisory issued May 18 at 11:00AM EDT until May 18 at 8:00PM EDT by NWS Tampa Bay",
+				Description: "* WHAT...Heat index values up to 108; stay hydrated.\n* WHERE...Hillsborough County.",
+				Instruction: "Drink plenty of fluids, stay in an air-conditioned room.",
+				Severity:    "Moderate",
+				Sender:      "NWS Tampa Bay",
+				Start:       time.Date(2021, 5, 18, 15, 0, 0, 0, time.UTC),
+				End:         time.Date(2021, 5, 19, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+}
+
+func TestCalendarEncode(t *testing.T) {
+	t.Parallel()
+	want, err := ioutil.ReadFile("testdata/calendar.ics")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := testCalendar().Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(string(want), string(got)))
+	}
+}
+
+func TestCalendarLinesAreFoldedWithoutSplittingCharacters(t *testing.T) {
+	t.Parallel()
+	cal := testCalendar()
+	description := strings.TrimSpace(strings.Repeat("Überschwemmungsgefahr für tiefer gelegene Gebiete. ", 6))
+	cal.Alerts[0].Description = description
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line is %d octets, want at most 75: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Fatalf("line splits a UTF-8 character: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:"+description) {
+		t.Fatalf("unfolded calendar does not contain the description:\n%s", unfolded)
+	}
+}
+
+func TestCalendarUIDsAreStableAcrossUpdates(t *testing.T) {
+	t.Parallel()
+	uids := func(cal weather.Calendar) []string {
+		var buf bytes.Buffer
+		if err := cal.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return regexp.MustCompile(`(?m)^UID:(.*)\r$`).FindAllString(buf.String(), -1)
+	}
+	first := testCalendar()
+	updated := testCalendar()
+	updated.Stamp = updated.Stamp.Add(6 * time.Hour)
+	updated.Forecasts[0].High = 28
+	updated.Forecasts[0].Summary = "light rain"
+	updated.Alerts[0].Headline = "Heat Advisory extended"
+
+	want, got := uids(first), uids(updated)
+	if len(want) != 3 {
+		t.Fatalf("want 3 events, got %d", len(want))
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+	other := testCalendar()
+	other.Location = "London"
+	if cmp.Equal(want[:2], uids(other)[:2]) {
+		t.Fatal("want forecast UIDs to differ between locations")
+	}
+}
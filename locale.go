@@ -0,0 +1,298 @@
+package weather
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// owmLanguages are the language codes OpenWeatherMap translates weather
+// descriptions into.
+var owmLanguages = map[string]bool{
+	"af": true, "al": true, "ar": true, "az": true, "bg": true, "ca": true,
+	"cz": true, "da": true, "de": true, "el": true, "en": true, "es": true,
+	"eu": true, "fa": true, "fi": true, "fr": true, "gl": true, "he": true,
+	"hi": true, "hr": true, "hu": true, "id": true, "it": true, "ja": true,
+	"kr": true, "la": true, "lt": true, "mk": true, "nl": true, "no": true,
+	"pl": true, "pt": true, "pt_br": true, "ro": true, "ru": true, "sk": true,
+	"sl": true, "sr": true, "sv": true, "th": true, "tr": true, "uk": true,
+	"vi": true, "zh_cn": true, "zh_tw": true, "zu": true,
+}
+
+// owmLanguageAliases maps ISO 639-1 language codes to the codes
+// OpenWeatherMap uses for them where the two differ.
+var owmLanguageAliases = map[string]string{
+	"cs": "cz",
+	"ko": "kr",
+	"lv": "la",
+	"nb": "no",
+	"nn": "no",
+	"sq": "al",
+	"zh": "zh_cn",
+}
+
+// localeFormat represents how a language writes numbers and dates.
+type localeFormat struct {
+	decimal    string
+	dateLayout string
+	timeLayout string
+}
+
+// englishFormat is used for languages without a localeFormat.
+var englishFormat = localeFormat{decimal: ".", dateLayout: "2006-01-02", timeLayout: "15:04:05"}
+
+var localeFormats = map[string]localeFormat{
+	"de": {decimal: ",", dateLayout: "02.01.2006", timeLayout: "15:04:05"},
+	"es": {decimal: ",", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+	"fr": {decimal: ",", dateLayout: "02/01/2006", timeLayout: "15:04:05"},
+}
+
+// catalogs holds the translations of the messages shown by the CLI, keyed
+// by language and then by the English message.
+var catalogs = map[string]map[string]string{
+	"de": {
+		"humidity %d%%":                   "Luftfeuchtigkeit %d%%",
+		"median of %d sources, spread %s": "Median aus %d Quellen, Streuung %s",
+		"wind %s":                         "Wind %s",
+		"feels like %s":                   "gefühlt %s",
+		"pressure %s":                     "Luftdruck %s",
+		"%s error: %v":                    "%s Fehler: %v",
+		"weather data unavailable":        "Wetterdaten nicht verfügbar",
+		"rate limited, try again later":   "Anfragelimit erreicht, später erneut versuchen",
+		"%s rate limited, keeping the previous reading": "%s Anfragelimit erreicht, vorherige Messung bleibt bestehen",
+		"No rain expected in the next hour":             "Kein Regen in der nächsten Stunde erwartet",
+		"Light rain":                                    "Leichter Regen",
+		"Moderate rain":                                 "Mäßiger Regen",
+		"Heavy rain":                                    "Starker Regen",
+		"Violent rain":                                  "Extremer Regen",
+		"%s now":                                        "%s jetzt",
+		"%s starting in %d min":                         "%s in %d Min.",
+		", ending in ~%d min":                           ", endet in ~%d Min.",
+		", continuing for at least the next hour":       ", hält mindestens die nächste Stunde an",
+		"Mon": "Mo",
+		"Tue": "Di",
+		"Wed": "Mi",
+		"Thu": "Do",
+		"Fri": "Fr",
+		"Sat": "Sa",
+		"Sun": "So",
+	},
+	"es": {
+		"humidity %d%%":                   "humedad %d%%",
+		"median of %d sources, spread %s": "mediana de %d fuentes, dispersión %s",
+		"wind %s":                         "viento %s",
+		"feels like %s":                   "sensación térmica %s",
+		"pressure %s":                     "presión %s",
+		"%s error: %v":                    "%s error: %v",
+		"weather data unavailable":        "datos meteorológicos no disponibles",
+		"rate limited, try again later":   "límite de solicitudes alcanzado, inténtelo más tarde",
+		"%s rate limited, keeping the previous reading": "%s límite de solicitudes alcanzado, se mantiene la lectura anterior",
+		"No rain expected in the next hour":             "No se espera lluvia en la próxima hora",
+		"Light rain":                                    "Lluvia débil",
+		"Moderate rain":                                 "Lluvia moderada",
+		"Heavy rain":                                    "Lluvia fuerte",
+		"Violent rain":                                  "Lluvia torrencial",
+		"%s now":                                        "%s ahora",
+		"%s starting in %d min":                         "%s a partir de %d min",
+		", ending in ~%d min":                           ", terminando en ~%d min",
+		", continuing for at least the next hour":       ", continuando al menos durante la próxima hora",
+		"Mon": "lun",
+		"Tue": "mar",
+		"Wed": "mié",
+		"Thu": "jue",
+		"Fri": "vie",
+		"Sat": "sáb",
+		"Sun": "dom",
+	},
+	"fr": {
+		"humidity %d%%":                   "humidité %d %%",
+		"median of %d sources, spread %s": "médiane de %d sources, écart %s",
+		"wind %s":                         "vent %s",
+		"feels like %s":                   "ressenti %s",
+		"pressure %s":                     "pression %s",
+		"%s error: %v":                    "%s erreur : %v",
+		"weather data unavailable":        "données météo indisponibles",
+		"rate limited, try again later":   "limite de requêtes atteinte, réessayez plus tard",
+		"%s rate limited, keeping the previous reading": "%s limite de requêtes atteinte, la lecture précédente est conservée",
+		"No rain expected in the next hour":             "Pas de pluie prévue dans l'heure",
+		"Light rain":                                    "Pluie faible",
+		"Moderate rain":                                 "Pluie modérée",
+		"Heavy rain":                                    "Pluie forte",
+		"Violent rain":                                  "Pluie violente",
+		"%s now":                                        "%s en cours",
+		"%s starting in %d min":                         "%s dans %d min",
+		", ending in ~%d min":                           ", se terminant dans ~%d min",
+		", continuing for at least the next hour":       ", pendant au moins l'heure à venir",
+		"Mon": "lun.",
+		"Tue": "mar.",
+		"Wed": "mer.",
+		"Thu": "jeu.",
+		"Fri": "ven.",
+		"Sat": "sam.",
+		"Sun": "dim.",
+	},
+}
+
+// Locale represents a language to request weather descriptions in and to
+// show CLI output in, along with how that language writes numbers and
+// dates. The zero Locale is English.
+type Locale struct {
+	// Lang is the OpenWeatherMap language code (e.g. "de" or "pt_br").
+	Lang string
+
+	messages map[string]string
+	format   localeFormat
+}
+
+// ParseLocale accepts a language, either as an OpenWeatherMap language code
+// (e.g. "de", "pt_br") or as a POSIX locale name like those in the LANG
+// environment variable (e.g. "de_DE.UTF-8"), and returns its Locale.
+// Messages in languages without a catalog are shown in English. An error is
+// returned if OpenWeatherMap does not support the language.
+func ParseLocale(s string) (Locale, error) {
+	tag := strings.TrimSpace(s)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "C" || tag == "POSIX" {
+		return Locale{Lang: "en"}, nil
+	}
+	tag = strings.ToLower(strings.ReplaceAll(tag, "-", "_"))
+	lang, region := tag, ""
+	if i := strings.Index(tag, "_"); i >= 0 {
+		lang, region = tag[:i], tag[i+1:]
+	}
+	code := lang
+	if alias, ok := owmLanguageAliases[lang]; ok {
+		code = alias
+	}
+	if (lang == "pt" && region == "br") || (lang == "zh" && region == "tw") {
+		code = lang + "_" + region
+	}
+	if !owmLanguages[code] {
+		return Locale{}, fmt.Errorf("unsupported language %q", s)
+	}
+	base := strings.SplitN(code, "_", 2)[0]
+	l := Locale{Lang: code, messages: catalogs[base], format: englishFormat}
+	if f, ok := localeFormats[base]; ok {
+		l.format = f
+	}
+	return l, nil
+}
+
+// LocaleFromEnv returns the Locale named by the first of the LC_ALL,
+// LC_MESSAGES and LANG environment variables that is set, or English if
+// none is set to a supported language.
+func LocaleFromEnv() Locale {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			l, err := ParseLocale(v)
+			if err != nil {
+				break
+			}
+			return l
+		}
+	}
+	return Locale{Lang: "en"}
+}
+
+// T returns the translation of the English message msg, or msg itself if
+// the Locale has none.
+func (l Locale) T(msg string) string {
+	if t, ok := l.messages[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf formats args according to the translation of the English format
+// string.
+func (l Locale) Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// FormatFloat returns v with prec decimal places, using the Locale's
+// decimal separator.
+func (l Locale) FormatFloat(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if l.format.decimal != "" && l.format.decimal != "." {
+		s = strings.Replace(s, ".", l.format.decimal, 1)
+	}
+	return s
+}
+
+// FormatDate returns the date of t as the Locale writes it (e.g.
+// "2021-05-18" in English or "18.05.2021" in German).
+func (l Locale) FormatDate(t time.Time) string {
+	layout := l.format.dateLayout
+	if layout == "" {
+		layout = englishFormat.dateLayout
+	}
+	return t.Format(layout)
+}
+
+// FormatWeekday returns the abbreviated name of the day of the week of t in
+// the Locale's language (e.g. "Tue" in English or "Di" in German).
+func (l Locale) FormatWeekday(t time.Time) string {
+	return l.T(t.Format("Mon"))
+}
+
+// FormatTime returns the time of day of t as the Locale writes it.
+func (l Locale) FormatTime(t time.Time) string {
+	layout := l.format.timeLayout
+	if layout == "" {
+		layout = englishFormat.timeLayout
+	}
+	return t.Format(layout)
+}
+
+// quantity returns a value to two decimal places followed by its unit.
+func (l Locale) quantity(v float64, unit string) string {
+	return l.FormatFloat(v, 2) + " " + unit
+}
+
+// FormatConditions accepts an Observation and the Preferences to show it in
+// and returns a string summarizing it in the Locale's language (e.g. "few
+// clouds, 52.72 F, humidity 47%").
+func (l Locale) FormatConditions(obs Observation, prefs Preferences) string {
+	temp := obs.Quantities().Temp.In(prefs.Temperature)
+	return fmt.Sprintf("%s, %s, %s",
+		strings.TrimSpace(obs.Summary),
+		l.quantity(temp.Value, string(temp.Unit)),
+		l.Sprintf("humidity %d%%", obs.Humidity))
+}
+
+// formatConsensus returns a string summarizing a ConsensusObservation shown
+// in prefs, in the Locale's language.
+func (l Locale) formatConsensus(co ConsensusObservation, prefs Preferences) string {
+	temp := co.Quantities().Temp
+	// The spread is a difference between temperatures, so only the scale of
+	// the conversion applies to it.
+	spread := Temperature{temp.Value + co.Spread, temp.Unit}.In(prefs.Temperature).Value - temp.In(prefs.Temperature).Value
+	return fmt.Sprintf("%s (%s)",
+		l.FormatConditions(co.Observation, prefs),
+		l.Sprintf("median of %d sources, spread %s", co.Sources, l.quantity(spread, string(prefs.Temperature))))
+}
+
+// FormatNowcast returns a summary of the nowcast in the Locale's language,
+// like "Light rain starting in 12 min, ending in ~35 min".
+func (l Locale) FormatNowcast(n Nowcast) string {
+	if !n.Precipitating {
+		return l.T("No rain expected in the next hour")
+	}
+
+	i := n.Intensity()
+	rain := l.T(strings.ToUpper(i[:1]) + i[1:] + " rain")
+	var s string
+	if n.StartsIn == 0 {
+		s = l.Sprintf("%s now", rain)
+	} else {
+		s = l.Sprintf("%s starting in %d min", rain, int(n.StartsIn.Minutes()))
+	}
+	if n.Ends {
+		return s + l.Sprintf(", ending in ~%d min", int(n.EndsIn.Minutes()))
+	}
+	return s + l.T(", continuing for at least the next hour")
+}
@@ -0,0 +1,165 @@
+package weather_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+)
+
+func TestParseLocale(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		s           string
+		want        string
+		errExpected bool
+	}{
+		"language code":                  {s: "de", want: "de"},
+		"POSIX locale name":              {s: "fr_FR.UTF-8", want: "fr"},
+		"BCP 47 tag":                     {s: "es-MX", want: "es"},
+		"region OpenWeather keeps":       {s: "pt_BR.UTF-8", want: "pt_br"},
+		"ISO code OpenWeather renames":   {s: "cs_CZ", want: "cz"},
+		"Chinese defaults to simplified": {s: "zh", want: "zh_cn"},
+		"C locale is English":            {s: "C.UTF-8", want: "en"},
+		"unsupported language":           {s: "tlh", errExpected: true},
+		"empty language":                 {s: "", errExpected: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := weather.ParseLocale(tc.s)
+			if tc.errExpected {
+				if err == nil {
+					t.Fatalf("ParseLocale(%q) did not return an expected error", tc.s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.want != got.Lang {
+				t.Fatalf("want language %q, got %q", tc.want, got.Lang)
+			}
+		})
+	}
+}
+
+func TestLocaleFromEnv(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if got := weather.LocaleFromEnv().Lang; got != "de" {
+		t.Fatalf("want language from LANG, got %q", got)
+	}
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if got := weather.LocaleFromEnv().Lang; got != "fr" {
+		t.Fatalf("want LC_ALL to override LANG, got %q", got)
+	}
+	t.Setenv("LC_ALL", "tlh")
+	if got := weather.LocaleFromEnv().Lang; got != "en" {
+		t.Fatalf("want English for an unsupported language, got %q", got)
+	}
+}
+
+func TestLocaleFormatConditions(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{Summary: "Ein paar Wolken", Temp: 11.51, Humidity: 47, Units: weather.Metric}
+	testCases := map[string]struct {
+		lang string
+		want string
+	}{
+		"English":                  {lang: "en", want: "Ein paar Wolken, 11.51 C, humidity 47%"},
+		"German":                   {lang: "de", want: "Ein paar Wolken, 11,51 C, Luftfeuchtigkeit 47%"},
+		"French":                   {lang: "fr", want: "Ein paar Wolken, 11,51 C, humidité 47 %"},
+		"language without catalog": {lang: "ja", want: "Ein paar Wolken, 11.51 C, humidity 47%"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			l, err := weather.ParseLocale(tc.lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := l.FormatConditions(obs, weather.Metric.Preferences())
+			if tc.want != got {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestZeroLocaleIsEnglish(t *testing.T) {
+	t.Parallel()
+	var l weather.Locale
+	if got := l.FormatFloat(1.5, 2); got != "1.50" {
+		t.Errorf("want 1.50, got %s", got)
+	}
+	if got := l.FormatNowcast(weather.Nowcast{}); got != "No rain expected in the next hour" {
+		t.Errorf("want English nowcast, got %q", got)
+	}
+}
+
+func TestLocaleFormatNowcast(t *testing.T) {
+	t.Parallel()
+	n := weather.Nowcast{
+		Precipitating: true,
+		StartsIn:      12 * time.Minute,
+		Ends:          true,
+		EndsIn:        35 * time.Minute,
+		Peak:          1,
+	}
+	l, err := weather.ParseLocale("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Leichter Regen in 12 Min., endet in ~35 Min."
+	if got := l.FormatNowcast(n); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestLocaleFormatDate(t *testing.T) {
+	t.Parallel()
+	date := time.Date(2021, 5, 18, 15, 4, 5, 0, time.UTC)
+	testCases := map[string]string{
+		"en": "2021-05-18",
+		"de": "18.05.2021",
+		"fr": "18/05/2021",
+	}
+
+	for lang, want := range testCases {
+		t.Run(lang, func(t *testing.T) {
+			l, err := weather.ParseLocale(lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.FormatDate(date); want != got {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestLocaleFormatWeekday(t *testing.T) {
+	t.Parallel()
+	date := time.Date(2021, 5, 19, 15, 4, 5, 0, time.UTC)
+	testCases := map[string]string{
+		"en": "Wed",
+		"de": "Mi",
+		"es": "mié",
+		"fr": "mer.",
+		"it": "Wed",
+	}
+
+	for lang, want := range testCases {
+		t.Run(lang, func(t *testing.T) {
+			l, err := weather.ParseLocale(lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.FormatWeekday(date); want != got {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetNorway represents a client for the MET Norway Locationforecast 2.0 API
+// at api.met.no. It implements the Provider and HourlyForecaster interfaces.
+//
+// MET Norway's terms of service require an identifying User-Agent and that
+// clients do not request data again before it expires, so responses are
+// cached until the time given in their Expires header and then revalidated
+// with If-Modified-Since. A MetNorway must therefore be created with
+// NewMetNorway, which sets up its cache.
+type MetNorway struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	// UserAgent identifies the application to MET Norway, which rejects
+	// requests without one. It should include contact information, e.g.
+	// "myweatherapp.com contact@myweatherapp.com".
+	UserAgent string
+	// Complete selects the complete forecast, which adds wind gusts and
+	// precipitation probabilities, instead of the compact one.
+	Complete bool
+	// Geocoder resolves location names to coordinates, since MET Norway has
+	// no geocoding service. Locations given as "lat,lon" are used as-is.
+	Geocoder Geocoder
+
+	cache *metCache
+}
+
+// NewMetNorway accepts a User-Agent string identifying the calling
+// application, creates a MetNorway client for the compact Locationforecast,
+// using Open-Meteo to geocode location names, and returns it. An error is
+// returned if the userAgent argument is empty.
+func NewMetNorway(userAgent string) (MetNorway, error) {
+	if userAgent == "" {
+		return MetNorway{}, errors.New("userAgent argument must not be empty")
+	}
+
+	return MetNorway{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		BaseURL:    "https://api.met.no",
+		UserAgent:  userAgent,
+		Geocoder:   NewOpenMeteo(),
+		cache:      &metCache{entries: map[string]metCacheEntry{}},
+	}, nil
+}
+
+// metCacheRetention is how long a response is kept after it expires, so
+// that it can be revalidated rather than requested again.
+const metCacheRetention = time.Hour
+
+// metCache stores Locationforecast responses by URL.
+type metCache struct {
+	mu      sync.Mutex
+	entries map[string]metCacheEntry
+}
+
+// metCacheEntry represents a cached response along with the time it
+// expires and the time it was last modified, as reported by MET Norway.
+type metCacheEntry struct {
+	data         []byte
+	expires      time.Time
+	lastModified string
+}
+
+// metForecastResp represents a response from the Locationforecast API.
+// Quantities are reported in SI units.
+type metForecastResp struct {
+	Properties struct {
+		Timeseries []metTimestep `json:"timeseries"`
+	} `json:"properties"`
+}
+
+// metTimestep represents the forecast at, and for the hours following, a
+// single point in time.
+type metTimestep struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Instant struct {
+			Details struct {
+				Pressure  float64 `json:"air_pressure_at_sea_level"`
+				Temp      float64 `json:"air_temperature"`
+				Clouds    float64 `json:"cloud_area_fraction"`
+				Humidity  float64 `json:"relative_humidity"`
+				WindDeg   float64 `json:"wind_from_direction"`
+				WindSpeed float64 `json:"wind_speed"`
+				WindGust  float64 `json:"wind_speed_of_gust"`
+			} `json:"details"`
+		} `json:"instant"`
+		Next1Hours  *metPeriod `json:"next_1_hours"`
+		Next6Hours  *metPeriod `json:"next_6_hours"`
+		Next12Hours *metPeriod `json:"next_12_hours"`
+	} `json:"data"`
+}
+
+// metPeriod represents the forecast summary for the hours following a
+// timestep.
+type metPeriod struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details struct {
+		TempMax    *float64 `json:"air_temperature_max"`
+		TempMin    *float64 `json:"air_temperature_min"`
+		Precip     float64  `json:"precipitation_amount"`
+		PrecipProb float64  `json:"probability_of_precipitation"`
+	} `json:"details"`
+}
+
+// summary returns the description of the first of the timestep's periods
+// with a symbol code, or an empty string if none has one.
+func (t metTimestep) summary() string {
+	for _, p := range []*metPeriod{t.Data.Next1Hours, t.Data.Next6Hours, t.Data.Next12Hours} {
+		if p != nil && p.Summary.SymbolCode != "" {
+			return metSymbolDescription(p.Summary.SymbolCode)
+		}
+	}
+	return ""
+}
+
+// CurrentObservation accepts a location (e.g. "oslo,no" or "59.9139,10.7522")
+// and a measurement unit ("standard", "metric", or "imperial"), requests the
+// Locationforecast for that location and returns its first timestep, which
+// is the nowcast for the current hour, as an Observation. An error is
+// returned if the units are invalid, if any API request fails, or if a
+// response cannot be decoded.
+func (m MetNorway) CurrentObservation(location string, units Units) (Observation, error) {
+	steps, err := m.timeseries(location, units)
+	if err != nil {
+		return Observation{}, err
+	}
+
+	t := steps[0]
+	d := t.Data.Instant.Details
+	obs := Observation{
+		Time:      t.Time.UTC(),
+		Summary:   t.summary(),
+		Temp:      fromCelsius(d.Temp, units),
+		FeelsLike: fromCelsius(d.Temp, units),
+		Humidity:  int(d.Humidity + 0.5),
+		Pressure:  d.Pressure,
+		WindSpeed: fromMetersPerSecond(d.WindSpeed, units),
+		WindGust:  fromMetersPerSecond(d.WindGust, units),
+		WindDeg:   int(d.WindDeg + 0.5),
+		Clouds:    int(d.Clouds + 0.5),
+		Units:     units,
+	}
+	if t.Data.Next1Hours != nil {
+		obs.Precip = t.Data.Next1Hours.Details.Precip
+	}
+	return obs, nil
+}
+
+// DailyForecast accepts a location (e.g. "oslo,no" or "59.9139,10.7522") and a
+// measurement unit ("standard", "metric", or "imperial"), requests the
+// Locationforecast for that location and aggregates its timesteps into a
+// DayForecast for each UTC day. Each day's summary is taken from the
+// timestep closest to noon. An error is returned if the units are invalid,
+// if any API request fails, or if a response cannot be decoded.
+func (m MetNorway) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	steps, err := m.timeseries(location, units)
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []DayForecast
+	var humidities []float64
+	var noonDist time.Duration
+	flushHumidity := func() {
+		if len(humidities) == 0 {
+			return
+		}
+		var sum float64
+		for _, h := range humidities {
+			sum += h
+		}
+		forecasts[len(forecasts)-1].Humidity = int(sum/float64(len(humidities)) + 0.5)
+		humidities = nil
+	}
+	for i, t := range steps {
+		d := t.Data.Instant.Details
+		date := time.Date(t.Time.Year(), t.Time.Month(), t.Time.Day(), 0, 0, 0, 0, time.UTC)
+		if len(forecasts) == 0 || !forecasts[len(forecasts)-1].Date.Equal(date) {
+			flushHumidity()
+			forecasts = append(forecasts, DayForecast{Date: date, Low: math.Inf(1), High: math.Inf(-1), Units: units})
+			noonDist = 24 * time.Hour
+		}
+		f := &forecasts[len(forecasts)-1]
+		temps := []float64{d.Temp}
+		humidities = append(humidities, d.Humidity)
+		if dist := absDuration(t.Time.Sub(date.Add(12 * time.Hour))); dist < noonDist {
+			if s := t.summary(); s != "" {
+				f.Summary, noonDist = s, dist
+			}
+		}
+
+		// Attribute precipitation to the day using the period that
+		// covers the gap to the next timestep, so it is not counted twice.
+		var period *metPeriod
+		if i+1 < len(steps) {
+			switch steps[i+1].Time.Sub(t.Time) {
+			case time.Hour:
+				period = t.Data.Next1Hours
+			case 6 * time.Hour:
+				period = t.Data.Next6Hours
+				if p := t.Data.Next6Hours; p != nil && p.Details.TempMax != nil && p.Details.TempMin != nil {
+					temps = append(temps, *p.Details.TempMax, *p.Details.TempMin)
+				}
+			}
+		}
+		if period != nil {
+			f.Precip += period.Details.Precip
+			if p := period.Details.PrecipProb / 100; p > f.PrecipProb {
+				f.PrecipProb = p
+			}
+		}
+		for _, temp := range temps {
+			f.Low = math.Min(f.Low, temp)
+			f.High = math.Max(f.High, temp)
+		}
+	}
+	flushHumidity()
+
+	for i := range forecasts {
+		forecasts[i].Low = fromCelsius(forecasts[i].Low, units)
+		forecasts[i].High = fromCelsius(forecasts[i].High, units)
+	}
+	return forecasts, nil
+}
+
+// HourlyForecast accepts a location (e.g. "oslo,no" or "59.9139,10.7522") and
+// a measurement unit ("standard", "metric", or "imperial"), requests the
+// Locationforecast for that location and returns its hourly timesteps as a
+// slice of HourlyForecast structs. An error is returned if the units are
+// invalid, if any API request fails, or if a response cannot be decoded.
+func (m MetNorway) HourlyForecast(location string, units Units) ([]HourlyForecast, error) {
+	steps, err := m.timeseries(location, units)
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []HourlyForecast
+	for _, t := range steps {
+		if t.Data.Next1Hours == nil {
+			break
+		}
+		d := t.Data.Instant.Details
+		forecasts = append(forecasts, HourlyForecast{
+			Time:       t.Time.UTC(),
+			Summary:    t.summary(),
+			Temp:       fromCelsius(d.Temp, units),
+			Humidity:   int(d.Humidity + 0.5),
+			WindSpeed:  fromMetersPerSecond(d.WindSpeed, units),
+			PrecipProb: t.Data.Next1Hours.Details.PrecipProb / 100,
+			Precip:     t.Data.Next1Hours.Details.Precip,
+			Units:      units,
+		})
+	}
+	return forecasts, nil
+}
+
+// Geocode accepts a location and returns its coordinates. Locations of the
+// form "lat,lon" are parsed directly; any other location is resolved using
+// the MetNorway client's Geocoder. An error is returned if the location is
+// empty or if it cannot be resolved.
+func (m MetNorway) Geocode(location string) (Location, error) {
+	return geocodeWith(m.Geocoder, location)
+}
+
+// timeseries geocodes the location and returns the Locationforecast
+// timesteps for it.
+func (m MetNorway) timeseries(location string, units Units) ([]metTimestep, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	loc, err := m.Geocode(location)
+	if err != nil {
+		return nil, err
+	}
+
+	product := "compact"
+	if m.Complete {
+		product = "complete"
+	}
+	// MET Norway asks for coordinates with at most four decimals so that
+	// responses can be cached.
+	URL := fmt.Sprintf("%s/weatherapi/locationforecast/2.0/%s?lat=%.4f&lon=%.4f",
+		m.BaseURL, product, loc.Lat, loc.Lon)
+	data, err := m.get(URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp metForecastResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("got error unmarshaling Locationforecast response: %v", err)
+	}
+	if len(resp.Properties.Timeseries) == 0 {
+		return nil, errors.New("response from Locationforecast API must contain at least one timestep")
+	}
+	return resp.Properties.Timeseries, nil
+}
+
+// get returns the body of the response from URL, serving it from the cache
+// until it expires and revalidating it with If-Modified-Since afterwards.
+// An error is returned if the MetNorway was not created by NewMetNorway,
+// since it then has no cache to honour MET Norway's terms with.
+func (m MetNorway) get(URL string) ([]byte, error) {
+	if m.cache == nil {
+		return nil, errors.New("MetNorway must be created with NewMetNorway")
+	}
+	entry, cached := m.cache.lookup(URL)
+	if cached && time.Now().Before(entry.expires) {
+		return entry.data, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %v", URL, err)
+	}
+	req.Header.Set("User-Agent", m.UserAgent)
+	if cached && entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data from %s: %v", URL, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		data = entry.data
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusNonAuthoritativeInfo:
+		entry.data = data
+		entry.lastModified = resp.Header.Get("Last-Modified")
+	default:
+		return nil, fmt.Errorf("got unexpected status %s from %s: %s", resp.Status, URL, data)
+	}
+	entry.expires, _ = http.ParseTime(resp.Header.Get("Expires"))
+	m.cache.mu.Lock()
+	m.cache.entries[URL] = entry
+	m.cache.mu.Unlock()
+	return data, nil
+}
+
+// lookup returns the cached entry for URL, if there is one. Entries that
+// expired more than metCacheRetention ago are evicted first, so that the
+// cache does not grow without bound.
+func (c *metCache) lookup(URL string) (metCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stale := time.Now().Add(-metCacheRetention)
+	for u, e := range c.entries {
+		if e.expires.Before(stale) {
+			delete(c.entries, u)
+		}
+	}
+	entry, ok := c.entries[URL]
+	return entry, ok
+}
+
+// metSymbolDescription converts a MET Norway weather symbol code, like
+// "lightrainshowers_day", into a description in the style of OpenWeather,
+// like "light shower rain". Unknown codes are returned without their
+// day/night suffix.
+func metSymbolDescription(code string) string {
+	if i := strings.Index(code, "_"); i >= 0 {
+		code = code[:i]
+	}
+	if desc, ok := metSymbolDescriptions[code]; ok {
+		return desc
+	}
+	return code
+}
+
+// metSymbolDescriptions maps MET Norway weather symbol codes, without their
+// day/night suffix, to descriptions in the style of OpenWeather.
+var metSymbolDescriptions = map[string]string{
+	"clearsky":                     "clear sky",
+	"fair":                         "few clouds",
+	"partlycloudy":                 "partly cloudy",
+	"cloudy":                       "overcast clouds",
+	"fog":                          "fog",
+	"lightrainshowers":             "light shower rain",
+	"rainshowers":                  "shower rain",
+	"heavyrainshowers":             "heavy intensity shower rain",
+	"lightrainshowersandthunder":   "thunderstorm with light rain",
+	"rainshowersandthunder":        "thunderstorm with rain",
+	"heavyrainshowersandthunder":   "thunderstorm with heavy rain",
+	"lightsleetshowers":            "light shower sleet",
+	"sleetshowers":                 "shower sleet",
+	"heavysleetshowers":            "heavy shower sleet",
+	"lightssleetshowersandthunder": "thunderstorm with light sleet",
+	"sleetshowersandthunder":       "thunderstorm with sleet",
+	"heavysleetshowersandthunder":  "thunderstorm with heavy sleet",
+	"lightsnowshowers":             "light shower snow",
+	"snowshowers":                  "shower snow",
+	"heavysnowshowers":             "heavy shower snow",
+	"lightssnowshowersandthunder":  "thunderstorm with light snow",
+	"snowshowersandthunder":        "thunderstorm with snow",
+	"heavysnowshowersandthunder":   "thunderstorm with heavy snow",
+	"lightrain":                    "light rain",
+	"rain":                         "moderate rain",
+	"heavyrain":                    "heavy intensity rain",
+	"lightrainandthunder":          "thunderstorm with light rain",
+	"rainandthunder":               "thunderstorm with rain",
+	"heavyrainandthunder":          "thunderstorm with heavy rain",
+	"lightsleet":                   "light sleet",
+	"sleet":                        "sleet",
+	"heavysleet":                   "heavy sleet",
+	"lightsleetandthunder":         "thunderstorm with light sleet",
+	"sleetandthunder":              "thunderstorm with sleet",
+	"heavysleetandthunder":         "thunderstorm with heavy sleet",
+	"lightsnow":                    "light snow",
+	"snow":                         "snow",
+	"heavysnow":                    "heavy snow",
+	"lightsnowandthunder":          "thunderstorm with light snow",
+	"snowandthunder":               "thunderstorm with snow",
+	"heavysnowandthunder":          "thunderstorm with heavy snow",
+}
+
+// absDuration returns the absolute value of d.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,215 @@
+package weather_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const metNorwayTestLocation = "59.9139,10.7522"
+
+// newMetNorwayTestServer returns an httptest.Server serving the recorded
+// Locationforecast fixture through handler, which may set caching headers
+// and return early, and a MetNorway client configured to use it.
+func newMetNorwayTestServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request) bool) (*httptest.Server, weather.MetNorway) {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/metNorwayCompactResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	wantReqURI := "/weatherapi/locationforecast/2.0/compact?lat=59.9139&lon=10.7522"
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantReqURI != r.RequestURI {
+			t.Errorf("want request URI: %s, got %s", wantReqURI, r.RequestURI)
+		}
+		if r.Header.Get("User-Agent") != "weather-test test@example.com" {
+			t.Errorf("want User-Agent header to be set, got %q", r.Header.Get("User-Agent"))
+		}
+		if handler != nil && handler(w, r) {
+			return
+		}
+		w.Write(data)
+	}))
+	m, err := weather.NewMetNorway("weather-test test@example.com")
+	if err != nil {
+		t.Fatalf("got error creating MetNorway client: %v", err)
+	}
+	m.HTTPClient = testServer.Client()
+	m.BaseURL = testServer.URL
+	m.Geocoder = nil
+	return testServer, m
+}
+
+func TestNewMetNorwayWithoutUserAgentReturnsError(t *testing.T) {
+	t.Parallel()
+	if _, err := weather.NewMetNorway(""); err == nil {
+		t.Fatal("NewMetNorway(\"\") did not return an expected error")
+	}
+}
+
+func TestMetNorwayCurrentObservation(t *testing.T) {
+	t.Parallel()
+	testServer, m := newMetNorwayTestServer(t, nil)
+	defer testServer.Close()
+
+	got, err := m.CurrentObservation(metNorwayTestLocation, "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.Observation{
+		Time:      time.Date(2021, 5, 18, 18, 0, 0, 0, time.UTC),
+		Summary:   "partly cloudy",
+		Temp:      14.8,
+		FeelsLike: 14.8,
+		Humidity:  70,
+		Pressure:  1013.2,
+		WindSpeed: 5.2,
+		WindDeg:   220,
+		Clouds:    40,
+		Units:     weather.Metric,
+	}
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMetNorwayDailyForecast(t *testing.T) {
+	t.Parallel()
+	testServer, m := newMetNorwayTestServer(t, nil)
+	defer testServer.Close()
+
+	got, err := m.DailyForecast(metNorwayTestLocation, "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 daily forecasts, got %d", len(got))
+	}
+	want := []weather.DayForecast{
+		{
+			Date:     time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC),
+			Summary:  "partly cloudy",
+			Low:      10.5,
+			High:     14.8,
+			Humidity: 71,
+			Units:    weather.Metric,
+		},
+		{
+			Date:     time.Date(2021, 5, 19, 0, 0, 0, 0, time.UTC),
+			Summary:  "light rain",
+			Low:      8.4,
+			High:     18.2,
+			Humidity: 75,
+			Precip:   1.8,
+			Units:    weather.Metric,
+		},
+	}
+	if !cmp.Equal(want, got[:2], cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got[:2]))
+	}
+}
+
+func TestMetNorwayHourlyForecast(t *testing.T) {
+	t.Parallel()
+	testServer, m := newMetNorwayTestServer(t, nil)
+	defer testServer.Close()
+
+	got, err := m.HourlyForecast(metNorwayTestLocation, "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 12 {
+		t.Fatalf("want 12 hourly forecasts, got %d", len(got))
+	}
+	if !closeEnough(58.64, got[0].Temp) {
+		t.Fatalf("want first hour's temperature to be 58.64 F, got %.2f", got[0].Temp)
+	}
+}
+
+func TestMetNorwayServesCachedResponsesUntilTheyExpire(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	testServer, m := newMetNorwayTestServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		return false
+	})
+	defer testServer.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.CurrentObservation(metNorwayTestLocation, "metric"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("want 1 request to be made, got %d", got)
+	}
+}
+
+func TestMetNorwayRevalidatesExpiredResponses(t *testing.T) {
+	t.Parallel()
+	lastModified := "Tue, 18 May 2021 17:52:24 GMT"
+	var revalidated int32
+	testServer, m := newMetNorwayTestServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Expires", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			atomic.AddInt32(&revalidated, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		return false
+	})
+	defer testServer.Close()
+
+	for i := 0; i < 2; i++ {
+		got, err := m.CurrentObservation(metNorwayTestLocation, "metric")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !closeEnough(14.8, got.Temp) {
+			t.Fatalf("want temperature 14.8, got %.2f", got.Temp)
+		}
+	}
+	if got := atomic.LoadInt32(&revalidated); got != 1 {
+		t.Fatalf("want 1 conditional request to be made, got %d", got)
+	}
+}
+
+func TestMetNorwayEvictsResponsesLongAfterTheyExpire(t *testing.T) {
+	t.Parallel()
+	var conditional int32
+	testServer, m := newMetNorwayTestServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("Expires", time.Now().Add(-2*time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", "Tue, 18 May 2021 17:52:24 GMT")
+		if r.Header.Get("If-Modified-Since") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		return false
+	})
+	defer testServer.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.CurrentObservation(metNorwayTestLocation, "metric"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&conditional); got != 0 {
+		t.Fatalf("want evicted response to be requested again, got %d conditional requests", got)
+	}
+}
+
+func TestMetNorwayNotCreatedByNewMetNorwayReturnsError(t *testing.T) {
+	t.Parallel()
+	m := weather.MetNorway{UserAgent: "weather-test test@example.com"}
+	if _, err := m.CurrentObservation(metNorwayTestLocation, "metric"); err == nil {
+		t.Fatal("want error for MetNorway without a cache, got nil")
+	}
+}
@@ -0,0 +1,457 @@
+package weather
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// DefaultNotifyTemplate is the template used to render an Event's message
+// body when a Notifier has no Template of its own.
+var DefaultNotifyTemplate = template.Must(template.New("event").Parse(
+	"{{.Title}}\n{{.Message}}\n"))
+
+// Event represents something worth notifying people about: a Rule firing or
+// clearing, or a government weather alert being issued.
+type Event struct {
+	Kind     string    `json:"kind"` // "rule" or "alert"
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Location string    `json:"location"`
+	State    string    `json:"state,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// EventFromRule accepts a RuleEvent and returns an Event describing it.
+func EventFromRule(re RuleEvent) Event {
+	return Event{
+		Kind:     "rule",
+		Title:    fmt.Sprintf("%s is %s", re.Rule, re.State),
+		Message:  re.Message,
+		Location: re.Location,
+		State:    re.State,
+		Time:     re.Time,
+	}
+}
+
+// EventFromAlert accepts a location and an Alert issued for it and returns
+// an Event describing it.
+func EventFromAlert(location string, a Alert) Event {
+	msg := a.Description
+	if a.Instruction != "" {
+		msg += "\n\n" + a.Instruction
+	}
+	title := a.Headline
+	if title == "" {
+		title = a.Event
+	}
+	return Event{
+		Kind:     "alert",
+		Title:    title,
+		Message:  msg,
+		Location: location,
+		Severity: a.Severity,
+		Time:     a.Start,
+	}
+}
+
+// renderEvent renders ev with t, or with DefaultNotifyTemplate if t is nil.
+func renderEvent(t *template.Template, ev Event) (string, error) {
+	if t == nil {
+		t = DefaultNotifyTemplate
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, ev); err != nil {
+		return "", fmt.Errorf("rendering notification: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Notifier represents a way of delivering Events to people.
+type Notifier interface {
+	Notify(ev Event) error
+}
+
+// Notifiers is a Notifier delivering each Event to all of its Notifiers.
+type Notifiers []Notifier
+
+// Notify delivers ev to every Notifier, even if some of them fail. The
+// first error is returned if any of them fail.
+func (ns Notifiers) Notify(ev Event) error {
+	var firstErr error
+	for _, n := range ns {
+		if err := n.Notify(ev); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Retry is a Notifier that retries delivery through its Notifier, waiting
+// Backoff before the first retry and twice as long before each one after.
+type Retry struct {
+	Notifier Notifier
+	Attempts int
+	Backoff  time.Duration
+}
+
+// NewRetry accepts a Notifier and returns a Retry making up to 3 attempts
+// to deliver each Event through it, starting with a 1 second backoff.
+func NewRetry(n Notifier) Retry {
+	return Retry{Notifier: n, Attempts: 3, Backoff: time.Second}
+}
+
+// Notify delivers ev through the Retry's Notifier, retrying on failure. The
+// last error is returned if every attempt fails.
+func (r Retry) Notify(ev Event) error {
+	backoff := r.Backoff
+	var err error
+	for i := 0; i < r.Attempts || i == 0; i++ {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = r.Notifier.Notify(ev); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("notifying after %d attempts: %w", r.Attempts, err)
+}
+
+// Webhook is a Notifier posting Events as JSON to a URL. The payload's
+// "text" field holds the rendered message, which is all that Slack and
+// Microsoft Teams incoming webhooks need, and its "event" field holds the
+// Event itself for other consumers.
+type Webhook struct {
+	HTTPClient *http.Client
+	URL        string
+	Template   *template.Template
+}
+
+// NewWebhook accepts a URL and returns a Webhook posting to it. An error is
+// returned if the URL is empty.
+func NewWebhook(URL string) (Webhook, error) {
+	if URL == "" {
+		return Webhook{}, errors.New("webhook URL must not be empty")
+	}
+	return Webhook{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		URL:        URL,
+	}, nil
+}
+
+// Notify posts ev to the Webhook's URL. An error is returned if the message
+// cannot be rendered, if the request fails or if the response status is
+// not 2xx.
+func (w Webhook) Notify(ev Event) error {
+	text, err := renderEvent(w.Template, ev)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(struct {
+		Text  string `json:"text"`
+		Event Event  `json:"event"`
+	}{text, ev})
+	if err != nil {
+		return err
+	}
+	resp, err := w.HTTPClient.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status from webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// Email is a Notifier sending Events by email through an SMTP server. The
+// Event's title is used as the subject and the rendered message as the
+// body. Auth may be nil if the server does not require authentication.
+type Email struct {
+	Addr     string
+	Auth     smtp.Auth
+	From     string
+	To       []string
+	Template *template.Template
+}
+
+// NewEmail accepts the address of an SMTP server (e.g. "smtp.example.com:587"),
+// a sender and one or more recipients and returns an Email sending from the
+// sender to the recipients through that server. An error is returned if the
+// address or sender are empty or if no recipients are given.
+func NewEmail(addr, from string, to ...string) (Email, error) {
+	if addr == "" {
+		return Email{}, errors.New("SMTP server address must not be empty")
+	}
+	if from == "" {
+		return Email{}, errors.New("email sender must not be empty")
+	}
+	if len(to) == 0 {
+		return Email{}, errors.New("at least one email recipient must be given")
+	}
+	return Email{Addr: addr, From: from, To: to}, nil
+}
+
+// Notify sends ev by email, with its title as the subject. An error is
+// returned if the message cannot be rendered or if the SMTP server does not
+// accept it.
+func (e Email) Notify(ev Event) error {
+	body, err := renderEvent(e.Template, ev)
+	if err != nil {
+		return err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	// Line breaks in the title would end the header early, and non-ASCII
+	// text must be encoded to appear in it.
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(ev.Title)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return smtp.SendMail(e.Addr, e.Auth, e.From, e.To, msg.Bytes())
+}
+
+// Command is a Notifier running a local command for each Event, with the
+// Event as JSON on its standard input and the rendered message in its
+// WEATHER_MESSAGE environment variable.
+type Command struct {
+	Path     string
+	Args     []string
+	Template *template.Template
+}
+
+// NewCommand accepts the path of a command and its arguments and returns a
+// Command running it. An error is returned if the path is empty.
+func NewCommand(path string, args ...string) (Command, error) {
+	if path == "" {
+		return Command{}, errors.New("command path must not be empty")
+	}
+	return Command{Path: path, Args: args}, nil
+}
+
+// Notify runs the Command for ev. An error, including the command's output,
+// is returned if the message cannot be rendered or if the command fails.
+func (c Command) Notify(ev Event) error {
+	text, err := renderEvent(c.Template, ev)
+	if err != nil {
+		return err
+	}
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(cmd.Environ(), "WEATHER_MESSAGE="+text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %s: %w: %s", c.Path, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// Monitor represents a RuleEngine evaluated on an interval, delivering an
+// Event through a Notifier each time a Rule fires or clears and, if Alerts
+// is set and the RuleEngine's Provider is an AlertProvider, each time a new
+// government alert is issued for one of the Rules' locations.
+type Monitor struct {
+	Engine   *RuleEngine
+	Notifier Notifier
+	Alerts   bool
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewMonitor accepts a RuleEngine and a Notifier and returns a Monitor
+// delivering the RuleEngine's events, and any government alerts, through
+// the Notifier.
+func NewMonitor(e *RuleEngine, n Notifier) *Monitor {
+	return &Monitor{
+		Engine:   e,
+		Notifier: n,
+		Alerts:   true,
+		seen:     map[string]bool{},
+	}
+}
+
+// Poll evaluates the Monitor's rules and checks for new alerts once,
+// delivering an Event for each. The first error evaluating the rules,
+// fetching the alerts or delivering an Event is returned, after every
+// Event that could be delivered has been.
+func (m *Monitor) Poll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	ruleEvents, err := m.Engine.Evaluate()
+	record(err)
+	for _, re := range ruleEvents {
+		record(m.Notifier.Notify(EventFromRule(re)))
+	}
+
+	ap, ok := m.Engine.Provider.(AlertProvider)
+	if !m.Alerts || !ok {
+		return firstErr
+	}
+	current := map[string]bool{}
+	done := map[string]bool{}
+	for _, r := range m.Engine.Rules {
+		if done[r.Location] {
+			continue
+		}
+		done[r.Location] = true
+		alerts, err := ap.Alerts(r.Location)
+		if err != nil {
+			record(fmt.Errorf("fetching alerts for %s: %w", r.Location, err))
+			continue
+		}
+		for _, a := range alerts {
+			key := r.Location + "\x00" + a.ID
+			current[key] = true
+			if m.seen[key] {
+				continue
+			}
+			if err := m.Notifier.Notify(EventFromAlert(r.Location, a)); err != nil {
+				record(err)
+				continue
+			}
+			m.seen[key] = true
+		}
+	}
+	for key := range m.seen {
+		if !current[key] && done[strings.SplitN(key, "\x00", 2)[0]] {
+			delete(m.seen, key)
+		}
+	}
+	return firstErr
+}
+
+// Run polls the Monitor immediately and then every interval until stop is
+// closed, logging any errors.
+func (m *Monitor) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Poll(); err != nil {
+			log.Printf("error polling rules: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// notifyConfig represents the command line flags configuring where
+// notifications are delivered.
+type notifyConfig struct {
+	webhooks stringsFlag
+	command  string
+	smtpAddr string
+	smtpUser string
+	smtpFrom string
+	smtpTo   stringsFlag
+	template string
+	attempts int
+	alerts   bool
+}
+
+// register defines the notification flags on fs.
+func (c *notifyConfig) register(fs *flag.FlagSet) {
+	fs.Var(&c.webhooks, "webhook", "a URL to post notifications to as JSON; may be repeated")
+	fs.StringVar(&c.command, "command", "", "a command to run for each notification, with the event as JSON on stdin")
+	fs.StringVar(&c.smtpAddr, "smtp-addr", "", "the SMTP server to send email notifications through (e.g. 'smtp.example.com:587')")
+	fs.StringVar(&c.smtpUser, "smtp-user", "", "the SMTP username, whose password is read from the WEATHER_SMTP_PASSWORD environment variable")
+	fs.StringVar(&c.smtpFrom, "smtp-from", "", "the sender of email notifications")
+	fs.Var(&c.smtpTo, "smtp-to", "a recipient of email notifications; may be repeated")
+	fs.StringVar(&c.template, "template", "", "a text/template file to render notification messages with")
+	fs.IntVar(&c.attempts, "attempts", 3, "how many times to try delivering each notification")
+	fs.BoolVar(&c.alerts, "alerts", true, "also notify about government alerts for the rules' locations, if the provider supports them")
+}
+
+// notifier returns a Notifier delivering to every configured destination,
+// retrying failed deliveries. An error is returned if no destination is
+// configured, if the template cannot be parsed or if a destination is
+// invalid.
+func (c *notifyConfig) notifier() (Notifier, error) {
+	var t *template.Template
+	if c.template != "" {
+		var err error
+		if t, err = template.ParseFiles(c.template); err != nil {
+			return nil, err
+		}
+	}
+	if c.attempts < 1 {
+		return nil, errors.New("attempts flag must be at least 1")
+	}
+
+	var ns Notifiers
+	for _, u := range c.webhooks {
+		w, err := NewWebhook(u)
+		if err != nil {
+			return nil, err
+		}
+		w.Template = t
+		ns = append(ns, w)
+	}
+	if c.command != "" {
+		fields := strings.Fields(c.command)
+		cmd, err := NewCommand(fields[0], fields[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Template = t
+		ns = append(ns, cmd)
+	}
+	if c.smtpAddr != "" {
+		e, err := NewEmail(c.smtpAddr, c.smtpFrom, c.smtpTo...)
+		if err != nil {
+			return nil, err
+		}
+		if c.smtpUser != "" {
+			host, _, err := net.SplitHostPort(c.smtpAddr)
+			if err != nil {
+				return nil, err
+			}
+			e.Auth = smtp.PlainAuth("", c.smtpUser, os.Getenv("WEATHER_SMTP_PASSWORD"), host)
+		}
+		e.Template = t
+		ns = append(ns, e)
+	}
+	if len(ns) == 0 {
+		return nil, errors.New("at least one of the webhook, command or smtp-addr flags must be set")
+	}
+
+	retried := make(Notifiers, len(ns))
+	for i, n := range ns {
+		r := NewRetry(n)
+		r.Attempts = c.attempts
+		retried[i] = r
+	}
+	return retried, nil
+}
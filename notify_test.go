@@ -0,0 +1,356 @@
+package weather_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+var testEvent = weather.Event{
+	Kind:     "rule",
+	Title:    "warehouse gusts is firing",
+	Message:  "current.wind_gust is 44.74 mph (> 40 mph)",
+	Location: "tampa,fl,us",
+	State:    "firing",
+	Time:     time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+}
+
+// recordingNotifier is a weather.Notifier that records the Events it is
+// given, failing the first failures of them.
+type recordingNotifier struct {
+	events   []weather.Event
+	failures int
+}
+
+func (r *recordingNotifier) Notify(ev weather.Event) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("delivery failed")
+	}
+	r.events = append(r.events, ev)
+	return nil
+}
+
+func TestWebhookPostsSlackCompatiblePayload(t *testing.T) {
+	t.Parallel()
+	type payload struct {
+		Text  string        `json:"text"`
+		Event weather.Event `json:"event"`
+	}
+	got := make(chan payload, 1)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		got <- p
+	}))
+	defer ts.Close()
+
+	w, err := weather.NewWebhook(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.HTTPClient = ts.Client()
+	if err := w.Notify(testEvent); err != nil {
+		t.Fatal(err)
+	}
+	want := payload{
+		Text:  "warehouse gusts is firing\ncurrent.wind_gust is 44.74 mph (> 40 mph)\n",
+		Event: testEvent,
+	}
+	if p := <-got; !cmp.Equal(want, p) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, p))
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	w, err := weather.NewWebhook(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.HTTPClient = ts.Client()
+	if err := w.Notify(testEvent); err == nil {
+		t.Fatal("want error for a 500 response, got nil")
+	}
+}
+
+// smtpStub starts an in-process SMTP server accepting a single message and
+// returns its address and a channel receiving the message's data.
+func smtpStub(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	data := make(chan string, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP stub")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 end data with <CR><LF>.<CR><LF>")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				data <- b.String()
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return lis.Addr().String(), data
+}
+
+func TestEmailSendsThroughSMTP(t *testing.T) {
+	t.Parallel()
+	addr, data := smtpStub(t)
+	e, err := weather.NewEmail(addr, "weather@example.com", "ops@example.com", "oncall@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Template = template.Must(template.New("t").Parse("{{.Location}}: {{.Message}}"))
+	if err := e.Notify(testEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-data
+	for _, want := range []string{
+		"From: weather@example.com\r\n",
+		"To: ops@example.com, oncall@example.com\r\n",
+		"Subject: warehouse gusts is firing\r\n",
+		"\r\n\r\ntampa,fl,us: current.wind_gust is 44.74 mph (> 40 mph)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want message to contain %q, got %q", want, got)
+		}
+	}
+}
+
+func TestEmailEncodesSubject(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		title string
+		want  string
+	}{
+		"line breaks cannot add headers": {
+			title: "gusts\r\nBcc: evil@example.com",
+			want:  "Subject: gusts  Bcc: evil@example.com\r\n",
+		},
+		"bare carriage return": {
+			title: "gusts\rBcc: evil@example.com",
+			want:  "Subject: gusts Bcc: evil@example.com\r\n",
+		},
+		"non-ASCII title is encoded": {
+			title: "Tempête à Paris",
+			want:  "Subject: =?utf-8?q?Temp=C3=AAte_=C3=A0_Paris?=\r\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			addr, data := smtpStub(t)
+			e, err := weather.NewEmail(addr, "weather@example.com", "ops@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev := testEvent
+			ev.Title = tc.title
+			if err := e.Notify(ev); err != nil {
+				t.Fatal(err)
+			}
+			got := <-data
+			if !strings.Contains(got, tc.want) {
+				t.Fatalf("want message to contain %q, got %q", tc.want, got)
+			}
+			headers := strings.SplitN(got, "\r\n\r\n", 2)[0]
+			if strings.Contains(headers, "\r\nBcc:") {
+				t.Fatalf("title added a header: %q", headers)
+			}
+		})
+	}
+}
+
+func TestNewEmailInvalidArgs(t *testing.T) {
+	t.Parallel()
+	if _, err := weather.NewEmail("", "a@example.com", "b@example.com"); err == nil {
+		t.Fatal("want error for empty address")
+	}
+	if _, err := weather.NewEmail("localhost:25", "", "b@example.com"); err == nil {
+		t.Fatal("want error for empty sender")
+	}
+	if _, err := weather.NewEmail("localhost:25", "a@example.com"); err == nil {
+		t.Fatal("want error for no recipients")
+	}
+}
+
+func TestCommandReceivesEventOnStdin(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	out := filepath.Join(dir, "event.json")
+	c, err := weather.NewCommand("sh", "-c", `cat > "$0" && printf %s "$WEATHER_MESSAGE" > "$0.msg"`, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Notify(testEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got weather.Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(testEvent, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(testEvent, got))
+	}
+	msg, err := os.ReadFile(out + ".msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "warehouse gusts is firing\ncurrent.wind_gust is 44.74 mph (> 40 mph)\n"; want != string(msg) {
+		t.Fatalf("want message %q, got %q", want, msg)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	t.Parallel()
+	c, err := weather.NewCommand("sh", "-c", "echo oops; exit 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Notify(testEvent)
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Fatalf("want error including the command's output, got %v", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		failures    int
+		errExpected bool
+	}{
+		"succeeds first time":        {failures: 0},
+		"succeeds on the last retry": {failures: 2},
+		"fails every attempt":        {failures: 3, errExpected: true},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			n := &recordingNotifier{failures: tc.failures}
+			r := weather.NewRetry(n)
+			r.Backoff = time.Millisecond
+			err := r.Notify(testEvent)
+			if errReceived := err != nil; tc.errExpected != errReceived {
+				t.Fatalf("want error %v, got %v", tc.errExpected, err)
+			}
+			if !tc.errExpected && len(n.events) != 1 {
+				t.Fatalf("want 1 event delivered, got %d", len(n.events))
+			}
+		})
+	}
+}
+
+func TestNotifiersDeliversToAll(t *testing.T) {
+	t.Parallel()
+	failing, ok := &recordingNotifier{failures: 1}, &recordingNotifier{}
+	if err := (weather.Notifiers{failing, ok}).Notify(testEvent); err == nil {
+		t.Fatal("want error from the failing notifier, got nil")
+	}
+	if len(ok.events) != 1 {
+		t.Fatalf("want event delivered to the other notifier, got %d events", len(ok.events))
+	}
+}
+
+func TestMonitorPoll(t *testing.T) {
+	t.Parallel()
+	p := alertingProvider{
+		fakeProvider: fakeProvider{obs: weather.Observation{WindGust: 20}},
+		alerts: []weather.Alert{{
+			ID:          "1",
+			Event:       "Wind Advisory",
+			Headline:    "Wind Advisory issued for Tampa",
+			Description: "Gusts up to 50 mph.",
+			Instruction: "Secure loose objects.",
+			Severity:    "Moderate",
+		}},
+	}
+	e, err := weather.NewRuleEngine(p, weather.Rule{Name: "gusts", Location: "tampa", When: "current.wind_gust > 40 mph"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &recordingNotifier{}
+	m := weather.NewMonitor(e, n)
+
+	for i := 0; i < 2; i++ {
+		if err := m.Poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, ev := range n.events {
+		got = append(got, ev.Kind+": "+ev.Title)
+	}
+	want := []string{
+		"rule: gusts is firing",
+		"alert: Wind Advisory issued for Tampa",
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+	if want := "Gusts up to 50 mph.\n\nSecure loose objects."; n.events[1].Message != want {
+		t.Fatalf("want alert message %q, got %q", want, n.events[1].Message)
+	}
+}
@@ -0,0 +1,136 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OneCallMinuteForecast represents the forecasted precipitation for a single
+// minute returned from the OpenWeather One Call API. Precipitation is always
+// reported in mm/h, regardless of the units requested.
+type OneCallMinuteForecast struct {
+	Date          uint64  `json:"dt"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+// DecodeOneCallMinutelyData accepts a slice of bytes representing a JSON
+// response from a call to the OpenWeather One Call API, attempts to decode
+// the data into a slice of OneCallMinuteForecast structs, and returns the
+// slice. An error is returned if data is empty, if there is a problem
+// JSON-decoding the bytes, or if the response contains no minutely data.
+func DecodeOneCallMinutelyData(data []byte) ([]OneCallMinuteForecast, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data must be a non-empty response from the OneCall API")
+	}
+
+	var resp OneCallAPIResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("got error unmarshaling onecall API response: %v", err)
+	}
+	if len(resp.Minutely) == 0 {
+		return nil, errors.New("response from OneCall API must contain minutely data")
+	}
+
+	return resp.Minutely, nil
+}
+
+// Nowcast summarizes the precipitation expected over the next hour.
+type Nowcast struct {
+	// Precipitating is true if any precipitation is forecast in the window.
+	Precipitating bool
+	// StartsIn is the time from now until precipitation begins. It is
+	// zero if it is already precipitating.
+	StartsIn time.Duration
+	// Ends is true if precipitation stops before the end of the window.
+	Ends bool
+	// EndsIn is the time from now until precipitation stops. It is only
+	// meaningful when Ends is true.
+	EndsIn time.Duration
+	// Peak is the highest forecast precipitation intensity in mm/h.
+	Peak float64
+	// PeakIn is the time from now until the peak intensity is reached.
+	PeakIn time.Duration
+}
+
+// NowcastFromMinutely accepts a slice of minutely precipitation forecasts
+// ordered by time, and returns a Nowcast describing when the first spell
+// of precipitation starts and stops, relative to the first forecast, and
+// its peak intensity.
+func NowcastFromMinutely(minutes []OneCallMinuteForecast) Nowcast {
+	var n Nowcast
+	if len(minutes) == 0 {
+		return n
+	}
+
+	now := minutes[0].Date
+	offset := func(m OneCallMinuteForecast) time.Duration {
+		return time.Duration(m.Date-now) * time.Second
+	}
+	for _, m := range minutes {
+		wet := m.Precipitation > 0
+		switch {
+		case wet && !n.Precipitating:
+			n.Precipitating = true
+			n.StartsIn = offset(m)
+		case !wet && n.Precipitating:
+			n.Ends = true
+			n.EndsIn = offset(m)
+		}
+		if n.Ends {
+			break
+		}
+		if wet && m.Precipitation > n.Peak {
+			n.Peak = m.Precipitation
+			n.PeakIn = offset(m)
+		}
+	}
+
+	return n
+}
+
+// Nowcast accepts a location (e.g. "london", "tampa,fl,us", etc.), looks up
+// its coordinates, requests the minutely precipitation forecast for them from
+// the One Call API and returns a Nowcast of the precipitation over the next
+// hour. An error is returned if either API request fails or if an API
+// response cannot be decoded.
+func (c Client) Nowcast(location string) (Nowcast, error) {
+	loc, err := geocodeWith(c, location)
+	if err != nil {
+		return Nowcast{}, err
+	}
+	data, err := c.OneCallData(loc.Lat, loc.Lon, canonicalUnits, "current", "hourly", "daily", "alerts")
+	if err != nil {
+		return Nowcast{}, err
+	}
+	minutes, err := DecodeOneCallMinutelyData(data)
+	if err != nil {
+		return Nowcast{}, err
+	}
+	return NowcastFromMinutely(minutes), nil
+}
+
+// Intensity returns a qualitative description ("light", "moderate", "heavy"
+// or "violent") of the nowcast's peak precipitation intensity, or an empty
+// string if no precipitation is forecast.
+func (n Nowcast) Intensity() string {
+	switch {
+	case !n.Precipitating:
+		return ""
+	case n.Peak < 2.5:
+		return "light"
+	case n.Peak < 7.6:
+		return "moderate"
+	case n.Peak < 50:
+		return "heavy"
+	default:
+		return "violent"
+	}
+}
+
+// String returns a human-readable summary of the nowcast, like
+// "Light rain starting in 12 min, ending in ~35 min".
+func (n Nowcast) String() string {
+	return Locale{}.FormatNowcast(n)
+}
@@ -0,0 +1,124 @@
+package weather_test
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeOneCallMinutelyData(t *testing.T) {
+	t.Parallel()
+	validData, err := ioutil.ReadFile("testdata/oneCallAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testCases := map[string]struct {
+		input       []byte
+		wantLength  int
+		errExpected bool
+	}{
+		"empty input returns an error": {
+			input:       []byte(""),
+			errExpected: true,
+		},
+		"non-json input returns an error": {
+			input:       []byte(nonJSONData),
+			errExpected: true,
+		},
+		"response without minutely data returns an error": {
+			input:       []byte(`{"daily": []}`),
+			errExpected: true,
+		},
+		"valid data gets decoded into []OneCallMinuteForecast": {
+			input:      validData,
+			wantLength: 61,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := weather.DecodeOneCallMinutelyData(tc.input)
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && tc.wantLength != len(got) {
+				t.Fatalf("want []OneCallMinuteForecast to have length %d, got %d",
+					tc.wantLength, len(got))
+			}
+		})
+	}
+}
+
+func TestNowcastFromMinutely(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		precip     []float64
+		want       weather.Nowcast
+		wantString string
+	}{
+		"no data": {
+			want:       weather.Nowcast{},
+			wantString: "No rain expected in the next hour",
+		},
+		"dry hour": {
+			precip:     []float64{0, 0, 0, 0},
+			want:       weather.Nowcast{},
+			wantString: "No rain expected in the next hour",
+		},
+		"rain starts and stops": {
+			precip: []float64{0, 0, 0.5, 1.2, 0.8, 0, 0, 3.0},
+			want: weather.Nowcast{
+				Precipitating: true,
+				StartsIn:      2 * time.Minute,
+				Ends:          true,
+				EndsIn:        5 * time.Minute,
+				Peak:          1.2,
+				PeakIn:        3 * time.Minute,
+			},
+			wantString: "Light rain starting in 2 min, ending in ~5 min",
+		},
+		"raining now without stopping": {
+			precip: []float64{3.1, 4.0, 8.2, 6.0},
+			want: weather.Nowcast{
+				Precipitating: true,
+				Peak:          8.2,
+				PeakIn:        2 * time.Minute,
+			},
+			wantString: "Heavy rain now, continuing for at least the next hour",
+		},
+		"raining now and stopping": {
+			precip: []float64{3.0, 2.0, 0},
+			want: weather.Nowcast{
+				Precipitating: true,
+				Ends:          true,
+				EndsIn:        2 * time.Minute,
+				Peak:          3.0,
+			},
+			wantString: "Moderate rain now, ending in ~2 min",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var minutes []weather.OneCallMinuteForecast
+			for i, p := range tc.precip {
+				minutes = append(minutes, weather.OneCallMinuteForecast{
+					Date:          1621360980 + uint64(i*60),
+					Precipitation: p,
+				})
+			}
+			got := weather.NowcastFromMinutely(minutes)
+			if !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+			if tc.wantString != got.String() {
+				t.Fatalf("want string %q, got %q", tc.wantString, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NWS represents a client for the US National Weather Service API at
+// api.weather.gov. It implements the Provider, HourlyForecaster and
+// AlertProvider interfaces for locations within the United States.
+type NWS struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	// UserAgent identifies the application to the NWS, which rejects
+	// requests without one. It should include contact information, e.g.
+	// "(myweatherapp.com, contact@myweatherapp.com)".
+	UserAgent string
+	// Geocoder resolves location names to coordinates, since the NWS API
+	// has no geocoding service. Locations given as "lat,lon" are used as-is.
+	Geocoder Geocoder
+}
+
+// NewNWS accepts a User-Agent string identifying the calling application,
+// creates an NWS client for communicating with api.weather.gov, using
+// Open-Meteo to geocode location names, and returns it. An error is returned
+// if the userAgent argument is empty.
+func NewNWS(userAgent string) (NWS, error) {
+	if userAgent == "" {
+		return NWS{}, errors.New("userAgent argument must not be empty")
+	}
+
+	return NWS{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		BaseURL:    "https://api.weather.gov",
+		UserAgent:  userAgent,
+		Geocoder:   NewOpenMeteo(),
+	}, nil
+}
+
+// nwsMaxStations is the number of nearest observation stations tried when
+// looking for a complete observation.
+const nwsMaxStations = 3
+
+// nwsValue represents a quantity in an NWS response. Value is nil when the
+// quantity is missing.
+type nwsValue struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+// float returns the value, or zero if it is missing.
+func (v nwsValue) float() float64 {
+	if v.Value == nil {
+		return 0
+	}
+	return *v.Value
+}
+
+// nwsPointsResp represents a response from the NWS /points endpoint.
+type nwsPointsResp struct {
+	Properties struct {
+		Forecast            string `json:"forecast"`
+		ForecastHourly      string `json:"forecastHourly"`
+		ObservationStations string `json:"observationStations"`
+		RelativeLocation    struct {
+			Properties struct {
+				City  string `json:"city"`
+				State string `json:"state"`
+			} `json:"properties"`
+		} `json:"relativeLocation"`
+	} `json:"properties"`
+}
+
+// nwsForecastResp represents a response from the NWS gridpoint forecast and
+// hourly forecast endpoints.
+type nwsForecastResp struct {
+	Properties struct {
+		Periods []nwsPeriod `json:"periods"`
+	} `json:"properties"`
+}
+
+// nwsPeriod represents a single forecast period, which is either an hour or
+// a half day, in an NWS forecast.
+type nwsPeriod struct {
+	StartTime        time.Time `json:"startTime"`
+	IsDaytime        bool      `json:"isDaytime"`
+	Temperature      float64   `json:"temperature"`
+	TemperatureUnit  string    `json:"temperatureUnit"`
+	PrecipProb       nwsValue  `json:"probabilityOfPrecipitation"`
+	RelativeHumidity nwsValue  `json:"relativeHumidity"`
+	WindSpeed        string    `json:"windSpeed"`
+	ShortForecast    string    `json:"shortForecast"`
+}
+
+// nwsStationsResp represents a response from the NWS gridpoint stations
+// endpoint.
+type nwsStationsResp struct {
+	ObservationStations []string `json:"observationStations"`
+}
+
+// nwsObservationResp represents a response from the NWS latest station
+// observation endpoint. Quantities are reported in SI units.
+type nwsObservationResp struct {
+	Properties struct {
+		Timestamp             time.Time `json:"timestamp"`
+		TextDescription       string    `json:"textDescription"`
+		Temperature           nwsValue  `json:"temperature"`
+		HeatIndex             nwsValue  `json:"heatIndex"`
+		WindChill             nwsValue  `json:"windChill"`
+		RelativeHumidity      nwsValue  `json:"relativeHumidity"`
+		WindDirection         nwsValue  `json:"windDirection"`
+		WindSpeed             nwsValue  `json:"windSpeed"`
+		WindGust              nwsValue  `json:"windGust"`
+		BarometricPressure    nwsValue  `json:"barometricPressure"`
+		Visibility            nwsValue  `json:"visibility"`
+		PrecipitationLastHour nwsValue  `json:"precipitationLastHour"`
+	} `json:"properties"`
+}
+
+// nwsAlertsResp represents a response from the NWS active alerts endpoint.
+type nwsAlertsResp struct {
+	Features []struct {
+		Properties struct {
+			ID          string    `json:"id"`
+			Event       string    `json:"event"`
+			Headline    string    `json:"headline"`
+			Description string    `json:"description"`
+			Instruction string    `json:"instruction"`
+			Severity    string    `json:"severity"`
+			SenderName  string    `json:"senderName"`
+			Onset       time.Time `json:"onset"`
+			Ends        time.Time `json:"ends"`
+			Expires     time.Time `json:"expires"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// CurrentObservation accepts a location (e.g. "tampa,fl,us" or
+// "27.9506,-82.4572") and a measurement unit ("standard", "metric", or
+// "imperial"), requests the latest observation from the observation station
+// nearest to that location and returns it as an Observation. Stations often
+// leave quantities out of their observations; when the nearest station's
+// lacks the temperature or pressure, the next nearest stations are tried in
+// turn. An error is returned if the units are invalid, if any API request
+// fails, if a response cannot be decoded, or if none of the nearest stations
+// reports both the temperature and pressure.
+func (n NWS) CurrentObservation(location string, units Units) (Observation, error) {
+	if !units.Valid() {
+		return Observation{}, ErrInvalidUnits
+	}
+	pts, err := n.points(location)
+	if err != nil {
+		return Observation{}, err
+	}
+	var stations nwsStationsResp
+	if err := n.getJSON(pts.Properties.ObservationStations, &stations); err != nil {
+		return Observation{}, err
+	}
+	if len(stations.ObservationStations) == 0 {
+		return Observation{}, fmt.Errorf("no observation stations found near %q", location)
+	}
+	var resp nwsObservationResp
+	for i, station := range stations.ObservationStations {
+		if i == nwsMaxStations {
+			break
+		}
+		resp = nwsObservationResp{}
+		if err = n.getJSON(station+"/observations/latest", &resp); err != nil {
+			continue
+		}
+		if resp.Properties.Temperature.Value == nil || resp.Properties.BarometricPressure.Value == nil {
+			err = fmt.Errorf("station %s reports no temperature or pressure", station)
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return Observation{}, err
+	}
+
+	p := resp.Properties
+	feelsLike := p.Temperature
+	switch {
+	case p.HeatIndex.Value != nil:
+		feelsLike = p.HeatIndex
+	case p.WindChill.Value != nil:
+		feelsLike = p.WindChill
+	}
+	return Observation{
+		Time:       p.Timestamp.UTC(),
+		Summary:    strings.ToLower(p.TextDescription),
+		Temp:       fromCelsius(p.Temperature.float(), units),
+		FeelsLike:  fromCelsius(feelsLike.float(), units),
+		Humidity:   int(p.RelativeHumidity.float() + 0.5),
+		Pressure:   p.BarometricPressure.float() / 100,
+		WindSpeed:  fromMetersPerSecond(p.WindSpeed.float()/3.6, units),
+		WindGust:   fromMetersPerSecond(p.WindGust.float()/3.6, units),
+		WindDeg:    int(p.WindDirection.float()),
+		Visibility: p.Visibility.float(),
+		Precip:     p.PrecipitationLastHour.float(),
+		Units:      units,
+	}, nil
+}
+
+// DailyForecast accepts a location (e.g. "tampa,fl,us" or "27.9506,-82.4572")
+// and a measurement unit ("standard", "metric", or "imperial"), requests the
+// gridpoint forecast for that location and returns it as a slice of
+// DayForecast structs. The NWS forecasts days and nights separately; each
+// day's high and summary come from its daytime period and its low from the
+// following night. When only one of the two is available, as for "Tonight",
+// it is used for both the high and the low. An error is returned if the
+// units are invalid, if any API request fails, or if a response cannot be
+// decoded.
+func (n NWS) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	pts, err := n.points(location)
+	if err != nil {
+		return nil, err
+	}
+	var resp nwsForecastResp
+	if err := n.getJSON(pts.Properties.Forecast, &resp); err != nil {
+		return nil, err
+	}
+
+	var forecasts []DayForecast
+	var haveDay, haveNight bool
+	for _, p := range resp.Properties.Periods {
+		date := p.StartTime
+		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		temp := p.temp(units)
+		if len(forecasts) == 0 || !forecasts[len(forecasts)-1].Date.Equal(date) {
+			forecasts = append(forecasts, DayForecast{Date: date, Low: temp, High: temp, Units: units})
+			haveDay, haveNight = false, false
+		}
+		f := &forecasts[len(forecasts)-1]
+		if p.PrecipProb.float()/100 > f.PrecipProb {
+			f.PrecipProb = p.PrecipProb.float() / 100
+		}
+		if p.IsDaytime {
+			f.High, f.Summary = temp, strings.ToLower(p.ShortForecast)
+			f.Humidity = int(p.RelativeHumidity.float() + 0.5)
+			if !haveNight {
+				f.Low = temp
+			}
+			haveDay = true
+			continue
+		}
+		f.Low = temp
+		if !haveDay {
+			f.High, f.Summary = temp, strings.ToLower(p.ShortForecast)
+			f.Humidity = int(p.RelativeHumidity.float() + 0.5)
+		}
+		haveNight = true
+	}
+	return forecasts, nil
+}
+
+// HourlyForecast accepts a location (e.g. "tampa,fl,us" or
+// "27.9506,-82.4572") and a measurement unit ("standard", "metric", or
+// "imperial"), requests the hourly gridpoint forecast for that location and
+// returns it as a slice of HourlyForecast structs. An error is returned if
+// the units are invalid, if any API request fails, or if a response cannot
+// be decoded.
+func (n NWS) HourlyForecast(location string, units Units) ([]HourlyForecast, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	pts, err := n.points(location)
+	if err != nil {
+		return nil, err
+	}
+	var resp nwsForecastResp
+	if err := n.getJSON(pts.Properties.ForecastHourly, &resp); err != nil {
+		return nil, err
+	}
+
+	forecasts := make([]HourlyForecast, 0, len(resp.Properties.Periods))
+	for _, p := range resp.Properties.Periods {
+		forecasts = append(forecasts, HourlyForecast{
+			Time:       p.StartTime.UTC(),
+			Summary:    strings.ToLower(p.ShortForecast),
+			Temp:       p.temp(units),
+			Humidity:   int(p.RelativeHumidity.float() + 0.5),
+			WindSpeed:  fromMetersPerSecond(parseNWSWindSpeed(p.WindSpeed)*0.44704, units),
+			PrecipProb: p.PrecipProb.float() / 100,
+			Units:      units,
+		})
+	}
+	return forecasts, nil
+}
+
+// Alerts accepts a location (e.g. "tampa,fl,us" or "27.9506,-82.4572"),
+// requests the alerts currently active at that point and returns them as a
+// slice of Alert structs. An error is returned if the request fails or if
+// the response cannot be decoded.
+func (n NWS) Alerts(location string) ([]Alert, error) {
+	loc, err := n.Geocode(location)
+	if err != nil {
+		return nil, err
+	}
+	var resp nwsAlertsResp
+	URL := fmt.Sprintf("%s/alerts/active?point=%.4f,%.4f", n.BaseURL, loc.Lat, loc.Lon)
+	if err := n.getJSON(URL, &resp); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(resp.Features))
+	for _, f := range resp.Features {
+		p := f.Properties
+		end := p.Ends
+		if end.IsZero() {
+			end = p.Expires
+		}
+		alerts = append(alerts, Alert{
+			ID:          p.ID,
+			Event:       p.Event,
+			Headline:    p.Headline,
+			Description: p.Description,
+			Instruction: p.Instruction,
+			Severity:    p.Severity,
+			Sender:      p.SenderName,
+			Start:       p.Onset.UTC(),
+			End:         end.UTC(),
+		})
+	}
+	return alerts, nil
+}
+
+// Geocode accepts a location and returns its coordinates. Locations of the
+// form "lat,lon" are parsed directly; any other location is resolved using
+// the NWS client's Geocoder. An error is returned if the location is empty
+// or if it cannot be resolved.
+func (n NWS) Geocode(location string) (Location, error) {
+	return geocodeWith(n.Geocoder, location)
+}
+
+// points geocodes the location and resolves it to the NWS forecast grid.
+func (n NWS) points(location string) (nwsPointsResp, error) {
+	loc, err := n.Geocode(location)
+	if err != nil {
+		return nwsPointsResp{}, err
+	}
+	var resp nwsPointsResp
+	URL := fmt.Sprintf("%s/points/%.4f,%.4f", n.BaseURL, loc.Lat, loc.Lon)
+	if err := n.getJSON(URL, &resp); err != nil {
+		return nwsPointsResp{}, err
+	}
+	return resp, nil
+}
+
+// getJSON requests the GeoJSON document at URL with the client's User-Agent
+// and decodes it into v.
+func (n NWS) getJSON(URL string, v interface{}) error {
+	header := http.Header{}
+	header.Set("User-Agent", n.UserAgent)
+	header.Set("Accept", "application/geo+json")
+	data, err := fetch(n.HTTPClient, URL, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("got error unmarshaling NWS response from %s: %v", URL, err)
+	}
+	return nil
+}
+
+// temp returns the period's temperature in the given units.
+func (p nwsPeriod) temp(units Units) float64 {
+	if p.TemperatureUnit == "C" {
+		return fromCelsius(p.Temperature, units)
+	}
+	return fromCelsius((p.Temperature-32)*5/9, units)
+}
+
+// parseNWSWindSpeed accepts an NWS wind speed like "10 mph" or "5 to 10 mph"
+// and returns the highest speed it mentions.
+func parseNWSWindSpeed(s string) float64 {
+	var max float64
+	for _, f := range strings.Fields(s) {
+		if v, err := strconv.ParseFloat(f, 64); err == nil && v > max {
+			max = v
+		}
+	}
+	return max
+}
@@ -0,0 +1,241 @@
+package weather_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// newNWSTestServer returns an httptest.Server serving the recorded NWS
+// fixtures, with their api.weather.gov links rewritten to point at the
+// server, and an NWS client configured to use it.
+func newNWSTestServer(t *testing.T) (*httptest.Server, weather.NWS) {
+	t.Helper()
+	return newNWSTestServerWith(t, nil)
+}
+
+// newNWSTestServerWith is like newNWSTestServer, but serves the given
+// fixtures in addition to, or instead of, the recorded ones.
+func newNWSTestServerWith(t *testing.T, overrides map[string]string) (*httptest.Server, weather.NWS) {
+	t.Helper()
+	fixtures := map[string]string{
+		"/points/27.9506,-82.4572":              "testdata/nwsPointsResp.json",
+		"/gridpoints/TBW/71,98/forecast":        "testdata/nwsForecastResp.json",
+		"/gridpoints/TBW/71,98/forecast/hourly": "testdata/nwsForecastHourlyResp.json",
+		"/gridpoints/TBW/71,98/stations":        "testdata/nwsStationsResp.json",
+		"/stations/KTPA/observations/latest":    "testdata/nwsLatestObservationResp.json",
+		"/alerts/active?point=27.9506,-82.4572": "testdata/nwsAlertsResp.json",
+	}
+	for uri, path := range overrides {
+		fixtures[uri] = path
+	}
+	var testServer *httptest.Server
+	testServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "weather-test (test@example.com)" {
+			t.Errorf("want User-Agent header to be set, got %q", r.Header.Get("User-Agent"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		path, ok := fixtures[r.RequestURI]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("unable to read test data file: %v", err)
+			return
+		}
+		w.Write(bytes.ReplaceAll(data, []byte("https://api.weather.gov"), []byte(testServer.URL)))
+	}))
+	nws, err := weather.NewNWS("weather-test (test@example.com)")
+	if err != nil {
+		t.Fatalf("got error creating NWS client: %v", err)
+	}
+	nws.HTTPClient = testServer.Client()
+	nws.BaseURL = testServer.URL
+	nws.Geocoder = nil
+	return testServer, nws
+}
+
+func TestNewNWSWithoutUserAgentReturnsError(t *testing.T) {
+	t.Parallel()
+	if _, err := weather.NewNWS(""); err == nil {
+		t.Fatal("NewNWS(\"\") did not return an expected error")
+	}
+}
+
+func TestNWSCurrentObservation(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServer(t)
+	defer testServer.Close()
+
+	got, err := nws.CurrentObservation("27.9506,-82.4572", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.Observation{
+		Time:       time.Date(2021, 5, 18, 19, 53, 0, 0, time.UTC),
+		Summary:    "partly cloudy",
+		Temp:       31.1,
+		FeelsLike:  34.6,
+		Humidity:   55,
+		Pressure:   1017,
+		WindSpeed:  4.6,
+		WindDeg:    270,
+		Visibility: 16090,
+		Units:      weather.Metric,
+	}
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestNWSCurrentObservationFallsBackToNextStation(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServerWith(t, map[string]string{
+		"/stations/KTPA/observations/latest": "testdata/nwsLatestObservationNullResp.json",
+		"/stations/KPIE/observations/latest": "testdata/nwsLatestObservationResp.json",
+	})
+	defer testServer.Close()
+
+	got, err := nws.CurrentObservation("27.9506,-82.4572", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Temp != 31.1 || got.Pressure != 1017 {
+		t.Errorf("want observation from the next station with temp 31.1 and pressure 1017, got temp %v and pressure %v", got.Temp, got.Pressure)
+	}
+}
+
+func TestNWSCurrentObservationWithoutValuesReturnsError(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServerWith(t, map[string]string{
+		"/stations/KTPA/observations/latest": "testdata/nwsLatestObservationNullResp.json",
+		"/stations/KPIE/observations/latest": "testdata/nwsLatestObservationNullResp.json",
+	})
+	defer testServer.Close()
+
+	_, err := nws.CurrentObservation("27.9506,-82.4572", "metric")
+	if err == nil {
+		t.Fatal("want error when no station reports temperature and pressure, got nil")
+	}
+}
+
+func TestNWSDailyForecast(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServer(t)
+	defer testServer.Close()
+
+	got, err := nws.DailyForecast("27.9506,-82.4572", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weather.DayForecast{
+		{
+			Date:       time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC),
+			Summary:    "partly cloudy",
+			Low:        72,
+			High:       72,
+			Humidity:   88,
+			PrecipProb: 0.2,
+			Units:      weather.Imperial,
+		},
+		{
+			Date:       time.Date(2021, 5, 19, 0, 0, 0, 0, time.UTC),
+			Summary:    "chance showers and thunderstorms",
+			Low:        73,
+			High:       89,
+			Humidity:   62,
+			PrecipProb: 0.4,
+			Units:      weather.Imperial,
+		},
+		{
+			Date:       time.Date(2021, 5, 20, 0, 0, 0, 0, time.UTC),
+			Summary:    "sunny",
+			Low:        74,
+			High:       91,
+			Humidity:   58,
+			PrecipProb: 0.1,
+			Units:      weather.Imperial,
+		},
+	}
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestNWSHourlyForecast(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServer(t)
+	defer testServer.Close()
+
+	got, err := nws.HourlyForecast("27.9506,-82.4572", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("want 4 hourly forecasts, got %d", len(got))
+	}
+	want := weather.HourlyForecast{
+		Time:       time.Date(2021, 5, 19, 0, 0, 0, 0, time.UTC),
+		Summary:    "mostly clear",
+		Temp:       25.5556,
+		Humidity:   80,
+		WindSpeed:  2.68224,
+		PrecipProb: 0.1,
+		Units:      weather.Metric,
+	}
+	if !cmp.Equal(want, got[0], cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got[0]))
+	}
+}
+
+func TestNWSAlerts(t *testing.T) {
+	t.Parallel()
+	testServer, nws := newNWSTestServer(t)
+	defer testServer.Close()
+
+	got, err := nws.Alerts("27.9506,-82.4572")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("want 1 alert, got %d", len(got))
+	}
+	if got[0].Event != "Heat Advisory" || got[0].Severity != "Moderate" {
+		t.Fatalf("want a Moderate Heat Advisory, got a %s %s", got[0].Severity, got[0].Event)
+	}
+	wantEnd := time.Date(2021, 5, 19, 1, 0, 0, 0, time.UTC)
+	if !wantEnd.Equal(got[0].End) {
+		t.Fatalf("want alert to end at %s, got %s", wantEnd, got[0].End)
+	}
+}
+
+func TestNWSGeocodeWithoutGeocoderRequiresCoordinates(t *testing.T) {
+	t.Parallel()
+	nws, err := weather.NewNWS("weather-test (test@example.com)")
+	if err != nil {
+		t.Fatalf("got error creating NWS client: %v", err)
+	}
+	nws.Geocoder = nil
+
+	if _, err := nws.Geocode("tampa,fl,us"); err == nil {
+		t.Fatal("Geocode(\"tampa,fl,us\") did not return an expected error")
+	}
+	got, err := nws.Geocode("27.9506,-82.4572")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.Location{Lat: 27.9506, Lon: -82.4572}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,333 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenMeteo represents a client for the keyless Open-Meteo forecast and
+// geocoding APIs. It implements the Provider interface.
+type OpenMeteo struct {
+	HTTPClient   *http.Client
+	BaseURL      string
+	GeocodingURL string
+}
+
+// NewOpenMeteo creates an OpenMeteo client for communicating with the public
+// Open-Meteo APIs and returns it.
+func NewOpenMeteo() OpenMeteo {
+	return OpenMeteo{
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		BaseURL:      "https://api.open-meteo.com",
+		GeocodingURL: "https://geocoding-api.open-meteo.com",
+	}
+}
+
+// openMeteoForecastResp represents a response from the Open-Meteo forecast
+// API requested with timeformat=unixtime.
+type openMeteoForecastResp struct {
+	// UTCOffset is the offset of the location's time zone, whose local
+	// midnights the daily times are.
+	UTCOffset int64 `json:"utc_offset_seconds"`
+	Current   struct {
+		Time        int64   `json:"time"`
+		Temp        float64 `json:"temperature_2m"`
+		Humidity    int     `json:"relative_humidity_2m"`
+		FeelsLike   float64 `json:"apparent_temperature"`
+		Precip      float64 `json:"precipitation"`
+		WeatherCode int     `json:"weather_code"`
+		Clouds      int     `json:"cloud_cover"`
+		Pressure    float64 `json:"pressure_msl"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		WindDeg     int     `json:"wind_direction_10m"`
+		WindGust    float64 `json:"wind_gusts_10m"`
+	} `json:"current"`
+	Daily struct {
+		Time        []int64   `json:"time"`
+		WeatherCode []int     `json:"weather_code"`
+		High        []float64 `json:"temperature_2m_max"`
+		Low         []float64 `json:"temperature_2m_min"`
+		Precip      []float64 `json:"precipitation_sum"`
+		PrecipProb  []float64 `json:"precipitation_probability_max"`
+		Humidity    []int     `json:"relative_humidity_2m_mean"`
+	} `json:"daily"`
+	Hourly struct {
+		Time        []int64   `json:"time"`
+		WeatherCode []int     `json:"weather_code"`
+		Temp        []float64 `json:"temperature_2m"`
+		Humidity    []int     `json:"relative_humidity_2m"`
+		WindSpeed   []float64 `json:"wind_speed_10m"`
+		PrecipProb  []float64 `json:"precipitation_probability"`
+		Precip      []float64 `json:"precipitation"`
+	} `json:"hourly"`
+}
+
+// openMeteoGeocodeResp represents a response from the Open-Meteo geocoding
+// API.
+type openMeteoGeocodeResp struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		CountryCode string  `json:"country_code"`
+		Lat         float64 `json:"latitude"`
+		Lon         float64 `json:"longitude"`
+	} `json:"results"`
+}
+
+// CurrentObservation accepts a location (e.g. "london", "tampa,us", etc.) and
+// a measurement unit ("standard", "metric", or "imperial"), requests the
+// current weather for that location from the Open-Meteo forecast API and
+// returns it as an Observation. An error is returned if the units are
+// invalid, if any API request fails, or if a response cannot be decoded.
+func (o OpenMeteo) CurrentObservation(location string, units Units) (Observation, error) {
+	resp, err := o.forecast(location, units,
+		"current=temperature_2m,relative_humidity_2m,apparent_temperature,precipitation,"+
+			"weather_code,cloud_cover,pressure_msl,wind_speed_10m,wind_direction_10m,wind_gusts_10m")
+	if err != nil {
+		return Observation{}, err
+	}
+
+	c := resp.Current
+	temp := openMeteoTemp(units)
+	return Observation{
+		Time:      time.Unix(c.Time, 0).UTC(),
+		Summary:   wmoDescriptions[c.WeatherCode],
+		Temp:      temp(c.Temp),
+		FeelsLike: temp(c.FeelsLike),
+		Humidity:  c.Humidity,
+		Pressure:  c.Pressure,
+		WindSpeed: c.WindSpeed,
+		WindGust:  c.WindGust,
+		WindDeg:   c.WindDeg,
+		Clouds:    c.Clouds,
+		Precip:    c.Precip,
+		Units:     units,
+	}, nil
+}
+
+// DailyForecast accepts a location (e.g. "london", "tampa,us", etc.) and a
+// measurement unit ("standard", "metric", or "imperial"), requests the daily
+// forecasts for that location from the Open-Meteo forecast API and returns
+// them as a slice of DayForecast structs. Each day covers the location's
+// local calendar day, and its Date is midnight UTC of that day. An error is
+// returned if the units are invalid, if any API request fails, or if a
+// response cannot be decoded.
+func (o OpenMeteo) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	resp, err := o.forecast(location, units,
+		"daily=weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,"+
+			"precipitation_probability_max,relative_humidity_2m_mean")
+	if err != nil {
+		return nil, err
+	}
+
+	d := resp.Daily
+	n := len(d.Time)
+	if len(d.WeatherCode) != n || len(d.High) != n || len(d.Low) != n ||
+		len(d.Precip) != n || len(d.PrecipProb) != n || len(d.Humidity) != n {
+		return nil, errors.New("daily data from Open-Meteo forecast API must have equal length series")
+	}
+	temp := openMeteoTemp(units)
+	forecasts := make([]DayForecast, 0, n)
+	for i := range d.Time {
+		forecasts = append(forecasts, DayForecast{
+			Date:       time.Unix(d.Time[i]+resp.UTCOffset, 0).UTC(),
+			Summary:    wmoDescriptions[d.WeatherCode[i]],
+			Low:        temp(d.Low[i]),
+			High:       temp(d.High[i]),
+			Humidity:   d.Humidity[i],
+			PrecipProb: d.PrecipProb[i] / 100,
+			Precip:     d.Precip[i],
+			Units:      units,
+		})
+	}
+	return forecasts, nil
+}
+
+// HourlyForecast accepts a location (e.g. "london", "tampa,us", etc.) and a
+// measurement unit ("standard", "metric", or "imperial"), requests the
+// forecasts for the next 48 hours for that location from the Open-Meteo
+// forecast API and returns them as a slice of HourlyForecast structs. An
+// error is returned if the units are invalid, if any API request fails, or
+// if a response cannot be decoded.
+func (o OpenMeteo) HourlyForecast(location string, units Units) ([]HourlyForecast, error) {
+	resp, err := o.forecast(location, units,
+		"hourly=weather_code,temperature_2m,relative_humidity_2m,wind_speed_10m,"+
+			"precipitation_probability,precipitation&forecast_hours=48")
+	if err != nil {
+		return nil, err
+	}
+
+	h := resp.Hourly
+	n := len(h.Time)
+	if len(h.WeatherCode) != n || len(h.Temp) != n || len(h.Humidity) != n ||
+		len(h.WindSpeed) != n || len(h.PrecipProb) != n || len(h.Precip) != n {
+		return nil, errors.New("hourly data from Open-Meteo forecast API must have equal length series")
+	}
+	temp := openMeteoTemp(units)
+	forecasts := make([]HourlyForecast, 0, n)
+	for i := range h.Time {
+		forecasts = append(forecasts, HourlyForecast{
+			Time:       time.Unix(h.Time[i], 0).UTC(),
+			Summary:    wmoDescriptions[h.WeatherCode[i]],
+			Temp:       temp(h.Temp[i]),
+			Humidity:   h.Humidity[i],
+			WindSpeed:  h.WindSpeed[i],
+			PrecipProb: h.PrecipProb[i] / 100,
+			Precip:     h.Precip[i],
+			Units:      units,
+		})
+	}
+	return forecasts, nil
+}
+
+// Geocode accepts a location (e.g. "london", "tampa,fl,us", etc.), requests
+// its geographical data from the Open-Meteo geocoding API and returns the
+// best match as a Location. A location of three comma-separated parts, such
+// as "tampa,fl,us", must match the ISO country code in its last part. A
+// location of two parts prefers matches whose country code is the second
+// part, but falls back to the best match, since the second part may be a
+// state or region as in "tampa,fl". An error is returned if the location is
+// empty, if the request fails, or if no matching location is found.
+func (o OpenMeteo) Geocode(location string) (Location, error) {
+	if location == "" {
+		return Location{}, errEmptyLocation
+	}
+
+	parts := strings.Split(location, ",")
+	name := strings.TrimSpace(parts[0])
+	count := 1
+	country := ""
+	strict := len(parts) > 2
+	if len(parts) > 1 {
+		country = strings.ToUpper(strings.TrimSpace(parts[len(parts)-1]))
+		count = 10
+	}
+	URL := fmt.Sprintf("%s/v1/search?name=%s&count=%d&format=json",
+		o.GeocodingURL, url.QueryEscape(name), count)
+	data, err := fetch(o.HTTPClient, URL, nil)
+	if err != nil {
+		return Location{}, err
+	}
+
+	var resp openMeteoGeocodeResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Location{}, fmt.Errorf("got error unmarshaling geocode json data: %v", err)
+	}
+	for _, r := range resp.Results {
+		if country != "" && r.CountryCode != country {
+			continue
+		}
+		return Location{Name: r.Name, Country: r.CountryCode, Lat: r.Lat, Lon: r.Lon}, nil
+	}
+	if !strict && len(resp.Results) > 0 {
+		r := resp.Results[0]
+		return Location{Name: r.Name, Country: r.CountryCode, Lat: r.Lat, Lon: r.Lon}, nil
+	}
+	return Location{}, fmt.Errorf("no location found matching %q", location)
+}
+
+// forecast geocodes the location and requests the given variables from the
+// Open-Meteo forecast API in units matching the OpenWeather unit systems.
+// Daily variables are aggregated over the location's local days.
+func (o OpenMeteo) forecast(location string, units Units, variables string) (openMeteoForecastResp, error) {
+	if !units.Valid() {
+		return openMeteoForecastResp{}, ErrInvalidUnits
+	}
+	loc, err := geocodeWith(o, location)
+	if err != nil {
+		return openMeteoForecastResp{}, err
+	}
+
+	tempUnit, windUnit := "celsius", "ms"
+	if units.normal() == Imperial {
+		tempUnit, windUnit = "fahrenheit", "mph"
+	}
+	URL := fmt.Sprintf("%s/v1/forecast?latitude=%.4f&longitude=%.4f&%s"+
+		"&temperature_unit=%s&wind_speed_unit=%s&timeformat=unixtime&timezone=auto",
+		o.BaseURL, loc.Lat, loc.Lon, variables, tempUnit, windUnit)
+	data, err := fetch(o.HTTPClient, URL, nil)
+	if err != nil {
+		return openMeteoForecastResp{}, err
+	}
+
+	var resp openMeteoForecastResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return openMeteoForecastResp{}, fmt.Errorf("got error unmarshaling Open-Meteo forecast response: %v", err)
+	}
+	return resp, nil
+}
+
+// openMeteoTemp returns a function converting temperatures reported by
+// Open-Meteo for the given units into that unit system. Open-Meteo has no
+// Kelvin option, so "standard" temperatures are requested in Celsius.
+func openMeteoTemp(units Units) func(float64) float64 {
+	if units.normal() == Standard {
+		return func(c float64) float64 { return c + 273.15 }
+	}
+	return func(t float64) float64 { return t }
+}
+
+// wmoDescriptions maps WMO weather interpretation codes, as used by
+// Open-Meteo, to descriptions in the style of OpenWeather.
+var wmoDescriptions = map[int]string{
+	0:  "clear sky",
+	1:  "mainly clear",
+	2:  "partly cloudy",
+	3:  "overcast clouds",
+	45: "fog",
+	48: "depositing rime fog",
+	51: "light drizzle",
+	53: "drizzle",
+	55: "heavy intensity drizzle",
+	56: "light freezing drizzle",
+	57: "freezing drizzle",
+	61: "light rain",
+	63: "moderate rain",
+	65: "heavy intensity rain",
+	66: "light freezing rain",
+	67: "freezing rain",
+	71: "light snow",
+	73: "snow",
+	75: "heavy snow",
+	77: "snow grains",
+	80: "light shower rain",
+	81: "shower rain",
+	82: "heavy intensity shower rain",
+	85: "light shower snow",
+	86: "heavy shower snow",
+	95: "thunderstorm",
+	96: "thunderstorm with light hail",
+	99: "thunderstorm with heavy hail",
+}
+
+// fetch makes a GET request to URL using hc, setting any given headers, and
+// returns the response body. An error is returned if the request fails, if
+// the response status is not 200 OK, or if the body cannot be read.
+func fetch(hc *http.Client, URL string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %v", URL, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data from %s: %v", URL, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got unexpected status %s from %s: %s", resp.Status, URL, data)
+	}
+
+	return data, nil
+}
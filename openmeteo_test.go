@@ -0,0 +1,246 @@
+package weather_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// newOpenMeteoTestServer returns an httptest.Server serving the recorded
+// Open-Meteo fixtures and an OpenMeteo client configured to use it. Every
+// forecast request URI is sent on reqURIs.
+func newOpenMeteoTestServer(t *testing.T, reqURIs chan<- string) (*httptest.Server, weather.OpenMeteo) {
+	t.Helper()
+	forecastData, err := ioutil.ReadFile("testdata/openMeteoForecastAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	geoData, err := ioutil.ReadFile("testdata/openMeteoGeocodeAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/search":
+			if r.URL.Query().Get("name") != "London" {
+				w.Write([]byte(`{"generationtime_ms": 0.3}`))
+				return
+			}
+			w.Write(geoData)
+		case "/v1/forecast":
+			if reqURIs != nil {
+				reqURIs <- r.RequestURI
+			}
+			w.Write(forecastData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	om := weather.NewOpenMeteo()
+	om.HTTPClient = testServer.Client()
+	om.BaseURL = testServer.URL
+	om.GeocodingURL = testServer.URL
+	return testServer, om
+}
+
+func TestOpenMeteoCurrentObservation(t *testing.T) {
+	t.Parallel()
+	reqURIs := make(chan string, 1)
+	testServer, om := newOpenMeteoTestServer(t, reqURIs)
+	defer testServer.Close()
+
+	got, err := om.CurrentObservation("London", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReqURI := "/v1/forecast?latitude=51.5085&longitude=-0.1257&current=temperature_2m," +
+		"relative_humidity_2m,apparent_temperature,precipitation,weather_code,cloud_cover," +
+		"pressure_msl,wind_speed_10m,wind_direction_10m,wind_gusts_10m" +
+		"&temperature_unit=fahrenheit&wind_speed_unit=mph&timeformat=unixtime&timezone=auto"
+	if gotReqURI := <-reqURIs; wantReqURI != gotReqURI {
+		t.Fatalf("want request URI: %s, got %s", wantReqURI, gotReqURI)
+	}
+	want := weather.Observation{
+		Time:      time.Unix(1620055800, 0).UTC(),
+		Summary:   "partly cloudy",
+		Temp:      52.7,
+		FeelsLike: 49.9,
+		Humidity:  47,
+		Pressure:  1009.2,
+		WindSpeed: 20.7,
+		WindGust:  39.1,
+		WindDeg:   220,
+		Clouds:    20,
+		Precip:    0.1,
+		Units:     weather.Imperial,
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestOpenMeteoCurrentObservationInStandardUnitsConvertsToKelvin(t *testing.T) {
+	t.Parallel()
+	testServer, om := newOpenMeteoTestServer(t, nil)
+	defer testServer.Close()
+
+	got, err := om.CurrentObservation("London", "standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closeEnough(325.85, got.Temp) {
+		t.Fatalf("want temperature 325.85, got %.2f", got.Temp)
+	}
+}
+
+func TestOpenMeteoDailyForecast(t *testing.T) {
+	t.Parallel()
+	testServer, om := newOpenMeteoTestServer(t, nil)
+	defer testServer.Close()
+
+	got, err := om.DailyForecast("London,GB", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 7 {
+		t.Fatalf("want 7 daily forecasts, got %d", len(got))
+	}
+	want := weather.DayForecast{
+		Date:       time.Unix(1620086400, 0).UTC(),
+		Summary:    "light rain",
+		Low:        44.6,
+		High:       55.2,
+		Humidity:   81,
+		PrecipProb: 0.75,
+		Precip:     4.2,
+		Units:      weather.Imperial,
+	}
+	if !cmp.Equal(want, got[1], cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got[1]))
+	}
+}
+
+func TestOpenMeteoHourlyForecast(t *testing.T) {
+	t.Parallel()
+	reqURIs := make(chan string, 1)
+	testServer, om := newOpenMeteoTestServer(t, reqURIs)
+	defer testServer.Close()
+
+	var hf weather.HourlyForecaster = om
+	got, err := hf.HourlyForecast("London", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReqURI := "/v1/forecast?latitude=51.5085&longitude=-0.1257&hourly=weather_code,temperature_2m," +
+		"relative_humidity_2m,wind_speed_10m,precipitation_probability,precipitation&forecast_hours=48" +
+		"&temperature_unit=fahrenheit&wind_speed_unit=mph&timeformat=unixtime&timezone=auto"
+	if gotReqURI := <-reqURIs; wantReqURI != gotReqURI {
+		t.Fatalf("want request URI: %s, got %s", wantReqURI, gotReqURI)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 hourly forecasts, got %d", len(got))
+	}
+	want := weather.HourlyForecast{
+		Time:       time.Unix(1620057600, 0).UTC(),
+		Summary:    "light rain",
+		Temp:       51.8,
+		Humidity:   64,
+		WindSpeed:  18.3,
+		PrecipProb: 0.55,
+		Precip:     0.4,
+		Units:      weather.Imperial,
+	}
+	if !cmp.Equal(want, got[1]) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got[1]))
+	}
+}
+
+func TestOpenMeteoGeocode(t *testing.T) {
+	t.Parallel()
+	testServer, om := newOpenMeteoTestServer(t, nil)
+	defer testServer.Close()
+
+	testCases := map[string]struct {
+		location    string
+		want        weather.Location
+		errExpected bool
+	}{
+		"empty location returns an error": {
+			location:    "",
+			errExpected: true,
+		},
+		"unknown location returns an error": {
+			location:    "Atlantis",
+			errExpected: true,
+		},
+		"country code that does not match returns an error": {
+			location:    "London,ON,CA",
+			errExpected: true,
+		},
+		"region instead of a country code falls back to the best match": {
+			location: "London,EN",
+			want: weather.Location{
+				Name:    "London",
+				Country: "GB",
+				Lat:     51.50853,
+				Lon:     -0.12574,
+			},
+		},
+		"known location returns a weather.Location": {
+			location: "London,GB",
+			want: weather.Location{
+				Name:    "London",
+				Country: "GB",
+				Lat:     51.50853,
+				Lon:     -0.12574,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := om.Geocode(tc.location)
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestOpenMeteoForecastsCoordinatesWithoutGeocoding(t *testing.T) {
+	t.Parallel()
+	reqURIs := make(chan string, 1)
+	testServer, om := newOpenMeteoTestServer(t, reqURIs)
+	defer testServer.Close()
+
+	if _, err := om.CurrentObservation("40.71,-74.01", "metric"); err != nil {
+		t.Fatal(err)
+	}
+	want := "/v1/forecast?latitude=40.7100&longitude=-74.0100&"
+	if got := <-reqURIs; !strings.HasPrefix(got, want) {
+		t.Fatalf("want request URI starting with %q, got %q", want, got)
+	}
+}
+
+func TestOpenMeteoWithInvalidUnitsReturnsError(t *testing.T) {
+	t.Parallel()
+	om := weather.NewOpenMeteo()
+	if _, err := om.CurrentObservation("London", "martian"); err == nil {
+		t.Fatal("CurrentObservation did not return an expected error")
+	}
+	if _, err := om.DailyForecast("London", "martian"); err == nil {
+		t.Fatal("DailyForecast did not return an expected error")
+	}
+}
@@ -6,20 +6,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
-var (
-	errEmptyLocation = errors.New("location argument must not be empty")
-	errInvalidUnits  = errors.New("units must be one of: standard, metric, imperial")
-)
-
-// Client represents an OpenWeatherMap API client.
+var errEmptyLocation = errors.New("location argument must not be empty")
+
+// canonicalUnits are the units the Client requests observations and
+// forecasts in, whatever units they are wanted in, so that cached responses
+// can be converted locally rather than requested again for each unit system.
+// OpenWeatherMap reports visibility in meters and precipitation in mm in
+// every unit system, so only temperatures and speeds need converting.
+// Converted values can differ from those requested in other units by 0.01,
+// as OpenWeatherMap rounds the values it reports to two decimal places.
+const canonicalUnits = Metric
+
+// Client represents an OpenWeatherMap API client. If Lang is set, weather
+// descriptions are requested in that language (e.g. "de"), otherwise in
+// English. If Cache is set, API responses are served from it while they are
+// fresh. If Limiter is set, requests that would exceed it fail with
+// ErrRateLimited instead of being sent.
 type Client struct {
 	HTTPClient *http.Client
 	BaseURL    string
 	APIKey     string
+	Lang       string
+	Cache      *Cache
+	Limiter    *RateLimiter
 }
 
 // NewClient accepts an OpenWeatherMap API key as a string, creates a Client
@@ -39,33 +53,28 @@ func NewClient(apiKey string) (Client, error) {
 	}, nil
 }
 
-// Current accepts a location (e.g. "london", "tampa,us", etc.), a measurement
-// unit ("standard", "metric", or "imperial"), makes a call to the
-// OpenWeatherMap Current Weather API to retrieve the current weather
-// data for that location and returns the API response as a slice of bytes.
-// An error is returned if the location or units arguments are invalid, if
-// the HTTP request to the OpenWeatherMap API fails, or if there is a problem
+// Current accepts a location (e.g. "london", "tampa,us", "27.95,-82.46",
+// etc.), a measurement unit ("standard", "metric", or "imperial"), makes a
+// call to the OpenWeatherMap Current Weather API to retrieve the current
+// weather data for that location and returns the API response as a slice of
+// bytes. A location given as "lat,lon" is requested by its coordinates. An
+// error is returned if the location or units arguments are invalid, if the
+// HTTP request to the OpenWeatherMap API fails, or if there is a problem
 // reading the response body.
-func (c Client) Current(location, units string) ([]byte, error) {
+func (c Client) Current(location string, units Units) ([]byte, error) {
 	if location == "" {
 		return nil, errEmptyLocation
 	}
-	if !validUnit(units) {
-		return nil, errInvalidUnits
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
 	}
 
-	URL := fmt.Sprintf("%s/data/2.5/weather?q=%s&units=%s&appid=%s", c.BaseURL, location, units, c.APIKey)
-	resp, err := c.HTTPClient.Get(URL)
-	if err != nil {
-		return nil, fmt.Errorf("error getting data from %s: %v", URL, err)
+	query := "q=" + queryEscape(location)
+	if loc, ok := parseLatLon(location); ok {
+		query = fmt.Sprintf("lat=%.4f&lon=%.4f", loc.Lat, loc.Lon)
 	}
-	defer resp.Body.Close()
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("got error reading response body: %v", err)
-	}
-
-	return data, nil
+	URL := fmt.Sprintf("%s/data/2.5/weather?%s&units=%s&appid=%s%s", c.BaseURL, query, units.normal(), c.APIKey, c.langParam())
+	return c.get(URL)
 }
 
 // GeocodeData accepts a location (e.g. "london", "tampa,fl,us", etc.), makes a
@@ -78,18 +87,8 @@ func (c Client) GeocodeData(location string) ([]byte, error) {
 		return nil, errEmptyLocation
 	}
 
-	URL := fmt.Sprintf("%s/geo/1.0/direct?q=%s&limit=1&appid=%s", c.BaseURL, location, c.APIKey)
-	resp, err := c.HTTPClient.Get(URL)
-	if err != nil {
-		return nil, fmt.Errorf("error getting data from %s: %v", URL, err)
-	}
-	defer resp.Body.Close()
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
-	}
-
-	return data, nil
+	URL := fmt.Sprintf("%s/geo/1.0/direct?q=%s&limit=1&appid=%s", c.BaseURL, queryEscape(location), c.APIKey)
+	return c.get(URL)
 }
 
 // OneCallData accepts a location's latitude and longitude, a measurement
@@ -100,9 +99,9 @@ func (c Client) GeocodeData(location string) ([]byte, error) {
 // An error is returned if the units argument is invalid, if the HTTP request
 // to the OpenWeatherMap One Call API fails, or if there is a problem reading
 // the response body.
-func (c Client) OneCallData(lat, lon float64, units string, exclude ...string) ([]byte, error) {
-	if !validUnit(units) {
-		return nil, errInvalidUnits
+func (c Client) OneCallData(lat, lon float64, units Units, exclude ...string) ([]byte, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
 	}
 
 	var timeFramesToExclude []string
@@ -117,26 +116,32 @@ func (c Client) OneCallData(lat, lon float64, units string, exclude ...string) (
 		excludes = fmt.Sprintf("&exclude=%s", strings.Join(timeFramesToExclude, ","))
 	}
 
-	URL := fmt.Sprintf("%s/data/2.5/onecall?lat=%.2f&lon=%.2f&units=%s&appid=%s%s",
-		c.BaseURL, lat, lon, units, c.APIKey, excludes)
-	resp, err := c.HTTPClient.Get(URL)
-	if err != nil {
-		return nil, fmt.Errorf("error getting data from %s: %v", URL, err)
-	}
-	defer resp.Body.Close()
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
-	}
+	URL := fmt.Sprintf("%s/data/2.5/onecall?lat=%.2f&lon=%.2f&units=%s&appid=%s%s%s",
+		c.BaseURL, lat, lon, units.normal(), c.APIKey, excludes, c.langParam())
+	return c.get(URL)
+}
 
-	return data, nil
+// AirPollutionData accepts a location's latitude and longitude, makes a call
+// to the OpenWeather Air Pollution API to retrieve the current air quality
+// at that location and returns the API response as a slice of bytes. An
+// error is returned if the HTTP request to the Air Pollution API fails or if
+// there is a problem reading the response body.
+func (c Client) AirPollutionData(lat, lon float64) ([]byte, error) {
+	URL := fmt.Sprintf("%s/data/2.5/air_pollution?lat=%.2f&lon=%.2f&appid=%s", c.BaseURL, lat, lon, c.APIKey)
+	return c.get(URL)
 }
 
 // CurrentAPIResp represents a response from a call to the current weather
 // API at OpenWeather.
 type CurrentAPIResp struct {
-	Summaries []Summary `json:"weather"`
-	Metrics   Metrics   `json:"main"`
+	Date       uint64    `json:"dt"`
+	Summaries  []Summary `json:"weather"`
+	Metrics    Metrics   `json:"main"`
+	Wind       Wind      `json:"wind"`
+	Clouds     Clouds    `json:"clouds"`
+	Visibility float64   `json:"visibility"`
+	Rain       Precip    `json:"rain"`
+	Snow       Precip    `json:"snow"`
 }
 
 // Summary represents a weather description, like "drizzly", "overcast", etc.
@@ -146,8 +151,27 @@ type Summary struct {
 
 // Metrics represents a type to store weather metrics.
 type Metrics struct {
-	Temp     float64 `json:"temp"`
-	Humidity int     `json:"humidity"`
+	Temp      float64 `json:"temp"`
+	FeelsLike float64 `json:"feels_like"`
+	Pressure  float64 `json:"pressure"`
+	Humidity  int     `json:"humidity"`
+}
+
+// Wind represents wind speed, gust speed and direction in degrees.
+type Wind struct {
+	Speed float64 `json:"speed"`
+	Gust  float64 `json:"gust"`
+	Deg   int     `json:"deg"`
+}
+
+// Clouds represents cloudiness as a percentage.
+type Clouds struct {
+	All int `json:"all"`
+}
+
+// Precip represents a precipitation volume in mm over the last hour.
+type Precip struct {
+	LastHour float64 `json:"1h"`
 }
 
 // DecodeCurrent accepts a slice of bytes containing the response from a call
@@ -194,16 +218,58 @@ func DecodeGeoData(data []byte) (Location, error) {
 
 // OneCallAPIResp represents a response from the OpenWeather One Call API.
 type OneCallAPIResp struct {
-	Daily []OneCallDayForecast `json:"daily"`
+	Timezone       string                  `json:"timezone"`
+	TimezoneOffset int                     `json:"timezone_offset"`
+	Minutely       []OneCallMinuteForecast `json:"minutely"`
+	Hourly         []OneCallHourForecast   `json:"hourly"`
+	Daily          []OneCallDayForecast    `json:"daily"`
+	Alerts         []OneCallAlert          `json:"alerts"`
+}
+
+// OneCallAlert represents a national weather alert returned from the
+// OpenWeather One Call API.
+type OneCallAlert struct {
+	SenderName  string `json:"sender_name"`
+	Event       string `json:"event"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	Description string `json:"description"`
 }
 
 // OneCallDayForecast represents metrics for a daily forecast returned
 // from the OpenWeather One Call API.
 type OneCallDayForecast struct {
-	Date     uint64              `json:"dt"`
-	Temp     OneCallDayTemp      `json:"temp"`
-	Humidity int                 `json:"humidity"`
-	Weather  []OneCallDaySummary `json:"weather"`
+	Date      uint64              `json:"dt"`
+	Sunrise   int64               `json:"sunrise"`
+	Sunset    int64               `json:"sunset"`
+	Moonrise  int64               `json:"moonrise"`
+	Moonset   int64               `json:"moonset"`
+	MoonPhase float64             `json:"moon_phase"`
+	Temp      OneCallDayTemp      `json:"temp"`
+	Humidity  int                 `json:"humidity"`
+	Weather   []OneCallDaySummary `json:"weather"`
+	Pop       float64             `json:"pop"`
+	Rain      float64             `json:"rain"`
+	Snow      float64             `json:"snow"`
+}
+
+// OneCallHourForecast represents metrics for an hourly forecast returned
+// from the OpenWeather One Call API.
+type OneCallHourForecast struct {
+	Date      uint64              `json:"dt"`
+	Temp      float64             `json:"temp"`
+	Humidity  int                 `json:"humidity"`
+	WindSpeed float64             `json:"wind_speed"`
+	Weather   []OneCallDaySummary `json:"weather"`
+	Pop       float64             `json:"pop"`
+	Rain      OneCallHourPrecip   `json:"rain"`
+	Snow      OneCallHourPrecip   `json:"snow"`
+}
+
+// OneCallHourPrecip represents the precipitation forecast for an hour, in
+// mm.
+type OneCallHourPrecip struct {
+	OneHour float64 `json:"1h"`
 }
 
 // OneCallDayTemp represents a forecasted low and high temperature.
@@ -236,9 +302,314 @@ func DecodeOneCallDailyData(data []byte) ([]OneCallDayForecast, error) {
 	return resp.Daily, nil
 }
 
-// validUnit accepts a string and returns true if it represents a valid
-// weather measurement unit ("standard", "metric", "imperial")
-func validUnit(u string) bool {
-	u = strings.ToLower(u)
-	return u == "standard" || u == "metric" || u == "imperial"
+// DecodeOneCallHourlyData accepts a slice of bytes representing a JSON
+// response from a call to the OneCall API, attempts to decode the data into a
+// slice of OneCallHourForecast structs, and returns the slice. An error is
+// returned if the data is empty or cannot be decoded.
+func DecodeOneCallHourlyData(data []byte) ([]OneCallHourForecast, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data must be a non-empty response from the OneCall API")
+	}
+
+	var resp OneCallAPIResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("got error unmarshaling onecall API response: %v", err)
+	}
+
+	return resp.Hourly, nil
+}
+
+// AirPollutionAPIResp represents a response from the OpenWeather Air
+// Pollution API.
+type AirPollutionAPIResp struct {
+	List []AirQuality `json:"list"`
+}
+
+// AirQuality represents the air quality at a location. The AQI ranges from 1
+// (good) to 5 (very poor) and component concentrations are in μg/m3.
+type AirQuality struct {
+	Date uint64 `json:"dt"`
+	Main struct {
+		AQI int `json:"aqi"`
+	} `json:"main"`
+	Components map[string]float64 `json:"components"`
+}
+
+// DecodeAirPollution accepts a slice of bytes containing the response from a
+// call to the OpenWeather Air Pollution API, attempts to decode it and
+// returns the current AirQuality. An error is returned if the decoding fails
+// or if the response does not contain any air quality data.
+func DecodeAirPollution(data []byte) (AirQuality, error) {
+	var resp AirPollutionAPIResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return AirQuality{}, fmt.Errorf("got error unmarshaling air pollution json data: %v", err)
+	}
+	if len(resp.List) == 0 {
+		return AirQuality{}, errors.New("response from Air Pollution API must contain air quality data")
+	}
+
+	return resp.List[0], nil
+}
+
+// DecodeOneCallAlerts accepts a slice of bytes representing a JSON response
+// from a call to the OpenWeather One Call API, attempts to decode the data
+// into a slice of OneCallAlert structs, and returns the slice, which is empty
+// if no alerts are in effect. An error is returned if data is empty or if
+// there is a problem JSON-decoding the bytes.
+func DecodeOneCallAlerts(data []byte) ([]OneCallAlert, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data must be a non-empty response from the OneCall API")
+	}
+
+	var resp OneCallAPIResp
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("got error unmarshaling onecall API response: %v", err)
+	}
+
+	return resp.Alerts, nil
+}
+
+// CurrentObservation accepts a location (e.g. "london", "tampa,us", etc.) and
+// a measurement unit ("standard", "metric", or "imperial"), requests the
+// current weather for that location from the OpenWeatherMap Current Weather
+// API and returns it as an Observation. The weather is always requested in
+// the canonical units and converted to the given units, so a cached response
+// serves every unit system. An error is returned if the units are invalid,
+// if the request fails or if the response cannot be decoded.
+func (c Client) CurrentObservation(location string, units Units) (Observation, error) {
+	if !units.Valid() {
+		return Observation{}, ErrInvalidUnits
+	}
+	data, err := c.Current(location, canonicalUnits)
+	if err != nil {
+		return Observation{}, err
+	}
+	resp, err := DecodeCurrent(data)
+	if err != nil {
+		return Observation{}, err
+	}
+
+	obs := Observation{
+		Time:       time.Unix(int64(resp.Date), 0).UTC(),
+		Temp:       resp.Metrics.Temp,
+		FeelsLike:  resp.Metrics.FeelsLike,
+		Humidity:   resp.Metrics.Humidity,
+		Pressure:   resp.Metrics.Pressure,
+		WindSpeed:  resp.Wind.Speed,
+		WindGust:   resp.Wind.Gust,
+		WindDeg:    resp.Wind.Deg,
+		Clouds:     resp.Clouds.All,
+		Visibility: resp.Visibility,
+		Precip:     resp.Rain.LastHour + resp.Snow.LastHour,
+		Units:      canonicalUnits,
+	}
+	if len(resp.Summaries) > 0 {
+		obs.Summary = resp.Summaries[0].Desc
+	}
+	return obs.In(units), nil
+}
+
+// DailyForecast accepts a location (e.g. "london", "tampa,fl,us", etc.) and
+// a measurement unit ("standard", "metric", or "imperial"), looks up the
+// coordinates of the location, requests the daily forecasts for those
+// coordinates from the One Call API in the canonical units and returns them
+// converted to the given units as a slice of DayForecast structs. An error
+// is returned if the units are invalid, if any API request fails or if an
+// API response cannot be decoded.
+func (c Client) DailyForecast(location string, units Units) ([]DayForecast, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	loc, err := geocodeWith(c, location)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.OneCallData(loc.Lat, loc.Lon, canonicalUnits, "current", "minutely", "hourly", "alerts")
+	if err != nil {
+		return nil, err
+	}
+	days, err := DecodeOneCallDailyData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts := make([]DayForecast, 0, len(days))
+	for _, d := range days {
+		f := DayForecast{
+			Date:       time.Unix(int64(d.Date), 0).UTC(),
+			Low:        d.Temp.Low,
+			High:       d.Temp.High,
+			Humidity:   d.Humidity,
+			PrecipProb: d.Pop,
+			Precip:     d.Rain + d.Snow,
+			Units:      canonicalUnits,
+		}
+		if len(d.Weather) > 0 {
+			f.Summary = d.Weather[0].Desc
+		}
+		forecasts = append(forecasts, f.In(units))
+	}
+	return forecasts, nil
+}
+
+// HourlyForecast accepts a location (e.g. "london", "tampa,fl,us", etc.)
+// and a measurement unit ("standard", "metric", or "imperial"), looks up the
+// coordinates of the location, requests the hourly forecasts for those
+// coordinates from the One Call API in the canonical units and returns them
+// converted to the given units as a slice of HourlyForecast structs. An error
+// is returned if the units are invalid, if any API request fails or if an
+// API response cannot be decoded.
+func (c Client) HourlyForecast(location string, units Units) ([]HourlyForecast, error) {
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	loc, err := geocodeWith(c, location)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.OneCallData(loc.Lat, loc.Lon, canonicalUnits, "current", "minutely", "daily", "alerts")
+	if err != nil {
+		return nil, err
+	}
+	hours, err := DecodeOneCallHourlyData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts := make([]HourlyForecast, 0, len(hours))
+	for _, h := range hours {
+		f := HourlyForecast{
+			Time:       time.Unix(int64(h.Date), 0).UTC(),
+			Temp:       h.Temp,
+			Humidity:   h.Humidity,
+			WindSpeed:  h.WindSpeed,
+			PrecipProb: h.Pop,
+			Precip:     h.Rain.OneHour + h.Snow.OneHour,
+			Units:      canonicalUnits,
+		}
+		if len(h.Weather) > 0 {
+			f.Summary = h.Weather[0].Desc
+		}
+		forecasts = append(forecasts, f.In(units))
+	}
+	return forecasts, nil
+}
+
+// Alerts accepts a location (e.g. "london", "tampa,fl,us", etc.), looks up
+// the coordinates of the location, requests the national weather alerts for
+// those coordinates from the One Call API and returns them as a slice of
+// Alert structs. The One Call API does not identify alerts, so each Alert's
+// ID is derived from its sender, event and start time. An error is returned
+// if any API request fails or if an API response cannot be decoded.
+func (c Client) Alerts(location string) ([]Alert, error) {
+	loc, err := geocodeWith(c, location)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.OneCallData(loc.Lat, loc.Lon, canonicalUnits, "current", "minutely", "hourly", "daily")
+	if err != nil {
+		return nil, err
+	}
+	oneCallAlerts, err := DecodeOneCallAlerts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(oneCallAlerts))
+	for _, a := range oneCallAlerts {
+		alerts = append(alerts, Alert{
+			ID:          fmt.Sprintf("%s/%s/%d", a.SenderName, a.Event, a.Start),
+			Event:       a.Event,
+			Headline:    a.Event,
+			Description: a.Description,
+			Sender:      a.SenderName,
+			Start:       time.Unix(a.Start, 0).UTC(),
+			End:         time.Unix(a.End, 0).UTC(),
+		})
+	}
+	return alerts, nil
+}
+
+// Geocode accepts a location (e.g. "london", "tampa,fl,us", etc.), requests
+// its geographical data from the OpenWeather Geocoding API and returns it as
+// a Location, named in the Client's language if the API knows a local name
+// for it. An error is returned if the request fails or if the response
+// cannot be decoded.
+func (c Client) Geocode(location string) (Location, error) {
+	data, err := c.GeocodeData(location)
+	if err != nil {
+		return Location{}, err
+	}
+	loc, err := DecodeGeoData(data)
+	if err != nil || c.Lang == "" {
+		return loc, err
+	}
+	var names []struct {
+		LocalNames map[string]string `json:"local_names"`
+	}
+	if err := json.Unmarshal(data, &names); err == nil && len(names) > 0 {
+		if name, ok := names[0].LocalNames[strings.SplitN(c.Lang, "_", 2)[0]]; ok {
+			loc.Name = name
+		}
+	}
+	return loc, nil
+}
+
+// langParam returns the query parameter selecting the Client's language, or
+// an empty string if no language is set.
+func (c Client) langParam() string {
+	if c.Lang == "" {
+		return ""
+	}
+	return "&lang=" + url.QueryEscape(c.Lang)
+}
+
+// queryEscape escapes a location so it can be placed in a URL query as a
+// single parameter. Commas, which separate the parts of a location (e.g.
+// "tampa,fl,us") and need no escaping in a query, are left as they are.
+func queryEscape(location string) string {
+	return strings.ReplaceAll(url.QueryEscape(location), "%2C", ",")
+}
+
+// get returns the body of the response to a GET request for URL, serving it
+// from the Client's Cache if it holds a fresh copy. An error is returned if
+// the Client's Limiter does not allow the request, if the request fails, or
+// if there is a problem reading the response body. Errors name the endpoint
+// requested but never its query, which holds the Client's API key.
+func (c Client) get(URL string) ([]byte, error) {
+	if c.Cache != nil {
+		if data, ok := c.Cache.Get(URL); ok {
+			return data, nil
+		}
+	}
+	if c.Limiter != nil && !c.Limiter.Allow() {
+		return nil, ErrRateLimited
+	}
+
+	endpoint := URL
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	resp, err := c.HTTPClient.Get(URL)
+	if err != nil {
+		// A *url.Error repeats the whole URL, so report only its cause.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("error getting data from %s: %v", endpoint, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got unexpected status %s from %s: %s", resp.Status, c.BaseURL, data)
+	}
+	if c.Cache != nil {
+		c.Cache.Set(URL, data)
+	}
+
+	return data, nil
 }
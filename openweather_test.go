@@ -7,10 +7,13 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aculclasure/weather"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 const nonJSONData = "123456"
@@ -103,7 +106,7 @@ func TestGetCurrentWeatherWithInvalidArgumentsReturnsError(t *testing.T) {
 	t.Parallel()
 	testCases := map[string]struct {
 		location string
-		units    string
+		units    weather.Units
 	}{
 		"Empty location": {
 			location: "",
@@ -121,7 +124,7 @@ func TestGetCurrentWeatherWithInvalidArgumentsReturnsError(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			_, err := client.Current(tc.location, tc.location)
+			_, err := client.Current(tc.location, tc.units)
 			if err == nil {
 				t.Fatalf("client.Current(%s, %s) did not return an expected error",
 					tc.location, tc.units)
@@ -213,6 +216,99 @@ func TestGetGeocodeData(t *testing.T) {
 	}
 }
 
+func TestClientEscapesLocationsInRequests(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		location string
+		get      func(c weather.Client, location string) ([]byte, error)
+	}{
+		"current weather": {
+			location: "new york",
+			get: func(c weather.Client, location string) ([]byte, error) {
+				return c.Current(location, weather.Metric)
+			},
+		},
+		"geocoding": {
+			location: "x&appid=OTHER&lang=zz",
+			get:      weather.Client.GeocodeData,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if got := q.Get("q"); got != tc.location {
+					t.Errorf("want q parameter %q, got %q", tc.location, got)
+				}
+				if got := q["appid"]; !cmp.Equal([]string{"apikey"}, got) {
+					t.Errorf("want only the client's appid, got %q", got)
+				}
+				if q.Has("lang") {
+					t.Errorf("want no lang parameter, got %q", q.Get("lang"))
+				}
+				fmt.Fprint(w, "[]")
+			}))
+			defer testServer.Close()
+			client, err := weather.NewClient("apikey")
+			if err != nil {
+				t.Fatalf("got error creating new weather client: %v", err)
+			}
+			client.HTTPClient = testServer.Client()
+			client.BaseURL = testServer.URL
+			if _, err := tc.get(client, tc.location); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestClientErrorsDoNotRevealAPIKey(t *testing.T) {
+	t.Parallel()
+	testServer := httptest.NewTLSServer(http.NotFoundHandler())
+	unreachable := testServer.URL
+	testServer.Close()
+	client, err := weather.NewClient("SECRETKEY")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.BaseURL = unreachable
+	testCases := map[string]func() error{
+		"current": func() error {
+			_, err := client.Current("London", weather.Metric)
+			return err
+		},
+		"geocode": func() error {
+			_, err := client.GeocodeData("London")
+			return err
+		},
+		"one call": func() error {
+			_, err := client.OneCallData(51.5, -0.12, weather.Metric)
+			return err
+		},
+		"air pollution": func() error {
+			_, err := client.AirPollutionData(51.5, -0.12)
+			return err
+		},
+	}
+
+	for name, request := range testCases {
+		request := request
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := request()
+			if err == nil {
+				t.Fatal("want error from an unreachable server, got nil")
+			}
+			if strings.Contains(err.Error(), "SECRETKEY") {
+				t.Fatalf("error reveals the API key: %v", err)
+			}
+		})
+	}
+}
+
 func TestGetGeocodeDataWithoutLocationReturnsError(t *testing.T) {
 	client, err := weather.NewClient("apikey")
 	if err != nil {
@@ -338,7 +434,7 @@ func TestGetOneCallDataWithInvalidUnitsReturnsError(t *testing.T) {
 	if err != nil {
 		t.Fatalf("got error creating new weather client: %v", err)
 	}
-	lat, lon, invalidUnits := 1.00, 2.00, "martian"
+	lat, lon, invalidUnits := 1.00, 2.00, weather.Units("martian")
 	_, err = client.OneCallData(lat, lon, invalidUnits)
 	if err == nil {
 		t.Fatalf("OneCallData(%.2f, %.2f, %s) did not return an expected error",
@@ -351,3 +447,298 @@ func TestGetOneCallDataWithInvalidUnitsReturnsError(t *testing.T) {
 func closeEnough(a, b float64) bool {
 	return math.Abs(a-b) < 0.001
 }
+
+func TestClientImplementsProvider(t *testing.T) {
+	t.Parallel()
+	currentData, err := ioutil.ReadFile("testdata/currentWeatherAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	geoData, err := ioutil.ReadFile("testdata/geocodeAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	oneCallData, err := ioutil.ReadFile("testdata/oneCallAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			w.Write(currentData)
+		case "/geo/1.0/direct":
+			w.Write(geoData)
+		case "/data/2.5/onecall":
+			w.Write(oneCallData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	var p weather.Provider = client
+
+	obs, err := p.CurrentObservation("London", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantObs := weather.Observation{
+		Time:       time.Unix(1620056197, 0).UTC(),
+		Summary:    "few clouds",
+		Temp:       52.72,
+		FeelsLike:  49.89,
+		Humidity:   47,
+		Pressure:   1009,
+		WindSpeed:  20.71,
+		WindGust:   39.12,
+		WindDeg:    220,
+		Clouds:     20,
+		Visibility: 10000,
+		Units:      weather.Imperial,
+	}
+	if !cmp.Equal(wantObs, obs, cmpopts.EquateApprox(0, 0.02)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(wantObs, obs))
+	}
+
+	forecasts, err := p.DailyForecast("London", "standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forecasts) != 8 {
+		t.Fatalf("want 8 daily forecasts, got %d", len(forecasts))
+	}
+	wantDay := weather.DayForecast{
+		Date:       time.Unix(1621360800, 0).UTC(),
+		Summary:    "very heavy rain",
+		Low:        290.44,
+		High:       298.72,
+		Humidity:   72,
+		PrecipProb: 1,
+		Precip:     63.24,
+		Units:      weather.Standard,
+	}
+	if !cmp.Equal(wantDay, forecasts[0], cmpopts.EquateApprox(0, 0.02)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(wantDay, forecasts[0]))
+	}
+
+	loc, err := p.Geocode("London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "London" || loc.Country != "GB" {
+		t.Fatalf("want London, GB, got %s, %s", loc.Name, loc.Country)
+	}
+}
+
+func TestClientUsesCoordinatesWithoutGeocoding(t *testing.T) {
+	t.Parallel()
+	currentData, err := ioutil.ReadFile("testdata/currentWeatherAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	oneCallData, err := ioutil.ReadFile("testdata/oneCallAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("lat") != "33.44" && q.Get("lat") != "33.4400" {
+			t.Errorf("want request for latitude 33.44, got %q", r.URL.RequestURI())
+		}
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			w.Write(currentData)
+		case "/data/2.5/onecall":
+			w.Write(oneCallData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+
+	if _, err := client.CurrentObservation("33.44,-94.04", weather.Metric); err != nil {
+		t.Errorf("CurrentObservation: %v", err)
+	}
+	if _, err := client.DailyForecast("33.44,-94.04", weather.Metric); err != nil {
+		t.Errorf("DailyForecast: %v", err)
+	}
+	if _, err := client.HourlyForecast("33.44,-94.04", weather.Metric); err != nil {
+		t.Errorf("HourlyForecast: %v", err)
+	}
+	if _, err := client.Alerts("33.44,-94.04"); err != nil {
+		t.Errorf("Alerts: %v", err)
+	}
+}
+
+func TestClientHourlyForecast(t *testing.T) {
+	t.Parallel()
+	geoData, err := ioutil.ReadFile("testdata/geocodeAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	oneCallData, err := ioutil.ReadFile("testdata/oneCallAPIRespMetric.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/geo/1.0/direct":
+			w.Write(geoData)
+		case "/data/2.5/onecall":
+			w.Write(oneCallData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	var hf weather.HourlyForecaster = client
+
+	hours, err := hf.HourlyForecast("London", weather.Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hours) != 48 {
+		t.Fatalf("want 48 hourly forecasts, got %d", len(hours))
+	}
+	want := []weather.HourlyForecast{
+		{
+			Time:       time.Unix(1621360800, 0).UTC(),
+			Summary:    "scattered clouds",
+			Temp:       298.72,
+			Humidity:   72,
+			WindSpeed:  2.06,
+			PrecipProb: 0.38,
+			Units:      weather.Standard,
+		},
+		{
+			Time:       time.Unix(1621371600, 0).UTC(),
+			Temp:       hours[3].Temp,
+			Humidity:   hours[3].Humidity,
+			WindSpeed:  hours[3].WindSpeed,
+			Summary:    hours[3].Summary,
+			PrecipProb: 1,
+			Precip:     5.57,
+			Units:      weather.Standard,
+		},
+	}
+	got := []weather.HourlyForecast{hours[0], hours[3]}
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.02)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+
+	if _, err := client.HourlyForecast("London", "kelvins"); err == nil {
+		t.Fatal("want error for invalid units, got nil")
+	}
+}
+
+func TestDecodeOneCallAlerts(t *testing.T) {
+	t.Parallel()
+	noAlertsData, err := ioutil.ReadFile("testdata/oneCallAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	alertsData := `{"alerts": [{"sender_name": "NWS Tulsa", "event": "Heat Advisory",
+		"start": 1597341600, "end": 1597366800, "description": "...HEAT ADVISORY..."}]}`
+	testCases := map[string]struct {
+		input       []byte
+		want        []weather.OneCallAlert
+		errExpected bool
+	}{
+		"empty input returns an error": {
+			input:       []byte(""),
+			errExpected: true,
+		},
+		"non-json input returns an error": {
+			input:       []byte(nonJSONData),
+			errExpected: true,
+		},
+		"response without alerts returns no alerts": {
+			input: noAlertsData,
+		},
+		"alerts are decoded": {
+			input: []byte(alertsData),
+			want: []weather.OneCallAlert{{
+				SenderName:  "NWS Tulsa",
+				Event:       "Heat Advisory",
+				Start:       1597341600,
+				End:         1597366800,
+				Description: "...HEAT ADVISORY...",
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := weather.DecodeOneCallAlerts(tc.input)
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestClientWithLangRequestsAndNamesInThatLanguage(t *testing.T) {
+	t.Parallel()
+	geoData, err := ioutil.ReadFile("testdata/geocodeAPIResp.json")
+	if err != nil {
+		t.Fatalf("unable to read test data file: %v", err)
+	}
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			if got := r.URL.Query().Get("lang"); got != "fr" {
+				t.Errorf("want lang parameter fr, got %q", got)
+			}
+			fmt.Fprint(w, `{"weather": [{"description": "peu nuageux"}], "main": {"temp": 11.51}}`)
+		case "/geo/1.0/direct":
+			w.Write(geoData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	client, err := weather.NewClient("apikey")
+	if err != nil {
+		t.Fatalf("got error creating new weather client: %v", err)
+	}
+	client.HTTPClient = testServer.Client()
+	client.BaseURL = testServer.URL
+	client.Lang = "fr"
+
+	obs, err := client.CurrentObservation("London", weather.Metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs.Summary != "peu nuageux" {
+		t.Fatalf("want French summary, got %q", obs.Summary)
+	}
+	loc, err := client.Geocode("London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Name != "Londres" {
+		t.Fatalf("want French name Londres, got %q", loc.Name)
+	}
+}
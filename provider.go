@@ -0,0 +1,196 @@
+package weather
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider represents a source of weather data. Implementations translate
+// their backend's responses into the provider-neutral Observation,
+// DayForecast and Location types so that callers do not depend on any
+// particular weather API.
+type Provider interface {
+	// CurrentObservation returns the current weather conditions for a
+	// location (e.g. "london", "tampa,fl,us", etc.) reported in the given
+	// measurement units ("standard", "metric" or "imperial").
+	CurrentObservation(location string, units Units) (Observation, error)
+	// DailyForecast returns the daily forecasts for a location, starting
+	// with today, reported in the given measurement units.
+	DailyForecast(location string, units Units) ([]DayForecast, error)
+	Geocoder
+}
+
+// Geocoder represents a source of geographical data for locations.
+type Geocoder interface {
+	// Geocode returns the geographical data for a location.
+	Geocode(location string) (Location, error)
+}
+
+// HourlyForecaster is implemented by Providers that can forecast the weather
+// hour by hour.
+type HourlyForecaster interface {
+	// HourlyForecast returns the hourly forecasts for a location, starting
+	// with the current hour, reported in the given measurement units.
+	HourlyForecast(location string, units Units) ([]HourlyForecast, error)
+}
+
+// AlertProvider is implemented by Providers that can report government
+// weather alerts.
+type AlertProvider interface {
+	// Alerts returns the weather alerts currently in effect for a location.
+	Alerts(location string) ([]Alert, error)
+}
+
+// Observation represents the weather conditions observed at a location at
+// a particular time. Temperatures and speeds are expressed in the
+// measurement units the observation was requested in.
+type Observation struct {
+	Time       time.Time `json:"time"`
+	Summary    string    `json:"summary"`
+	Temp       float64   `json:"temp"`
+	FeelsLike  float64   `json:"feels_like"`
+	Humidity   int       `json:"humidity"`
+	Pressure   float64   `json:"pressure"` // hPa
+	WindSpeed  float64   `json:"wind_speed"`
+	WindGust   float64   `json:"wind_gust"`
+	WindDeg    int       `json:"wind_deg"`
+	Clouds     int       `json:"clouds"`     // percent
+	Visibility float64   `json:"visibility"` // meters
+	Precip     float64   `json:"precip"`     // mm over the last hour
+	Units      Units     `json:"units,omitempty"`
+}
+
+// DayForecast represents the forecasted weather for a single day.
+// Temperatures are expressed in the measurement units the forecast was
+// requested in.
+type DayForecast struct {
+	Date       time.Time `json:"date"`
+	Summary    string    `json:"summary"`
+	Low        float64   `json:"low"`
+	High       float64   `json:"high"`
+	Humidity   int       `json:"humidity"`
+	PrecipProb float64   `json:"precip_prob"` // 0 to 1
+	Precip     float64   `json:"precip"`      // mm
+	Units      Units     `json:"units,omitempty"`
+}
+
+// HourlyForecast represents the forecasted weather for a single hour.
+// Temperatures and speeds are expressed in the measurement units the
+// forecast was requested in.
+type HourlyForecast struct {
+	Time       time.Time `json:"time"`
+	Summary    string    `json:"summary"`
+	Temp       float64   `json:"temp"`
+	Humidity   int       `json:"humidity"`
+	WindSpeed  float64   `json:"wind_speed"`
+	PrecipProb float64   `json:"precip_prob"` // 0 to 1
+	Precip     float64   `json:"precip"`      // mm over the hour
+	Units      Units     `json:"units,omitempty"`
+}
+
+// Alert represents a weather alert issued for a location, like a heat
+// advisory or a tornado warning.
+type Alert struct {
+	ID          string    `json:"id"`
+	Event       string    `json:"event"`
+	Headline    string    `json:"headline"`
+	Description string    `json:"description"`
+	Instruction string    `json:"instruction,omitempty"`
+	Severity    string    `json:"severity,omitempty"`
+	Sender      string    `json:"sender"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+// parseLatLon accepts a location and, if it is of the form "lat,lon" (e.g.
+// "27.9506,-82.4572"), returns a Location with those coordinates and true.
+// Otherwise it returns an empty Location and false.
+func parseLatLon(location string) (Location, bool) {
+	parts := strings.Split(location, ",")
+	if len(parts) != 2 {
+		return Location{}, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Location{}, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return Location{}, false
+	}
+	return Location{Lat: lat, Lon: lon}, true
+}
+
+// geocodeWith accepts a Geocoder, which may be nil, and a location and
+// returns the location's coordinates. Locations of the form "lat,lon" are
+// parsed directly; any other location is resolved using g. An error is
+// returned if the location is empty or if it cannot be resolved.
+func geocodeWith(g Geocoder, location string) (Location, error) {
+	if location == "" {
+		return Location{}, errEmptyLocation
+	}
+	if loc, ok := parseLatLon(location); ok {
+		return loc, nil
+	}
+	if g == nil {
+		return Location{}, fmt.Errorf("location %q must be given as lat,lon when no geocoder is configured", location)
+	}
+	return g.Geocode(location)
+}
+
+// fromCelsius converts a temperature in degrees Celsius into the
+// temperature unit of the given measurement units.
+func fromCelsius(c float64, units Units) float64 {
+	return Temperature{c, Celsius}.In(units.Temperature()).Value
+}
+
+// fromMetersPerSecond converts a speed in meters per second into the speed
+// unit of the given measurement units.
+func fromMetersPerSecond(v float64, units Units) float64 {
+	return Speed{v, MetersPerSecond}.In(units.Speed()).Value
+}
+
+// CurrentConditions accepts a Provider, a location (e.g. "london",
+// "tampa,us", etc.) and a measurement unit ("standard", "metric" or
+// "imperial"), requests the current observation for that location from the
+// provider and returns a string summarizing it. An error is returned if the
+// units are invalid or if the provider returns an error.
+func CurrentConditions(p Provider, location string, units Units) (string, error) {
+	if !units.Valid() {
+		return "", ErrInvalidUnits
+	}
+	obs, err := p.CurrentObservation(location, units)
+	if err != nil {
+		return "", err
+	}
+	if obs.Units == "" {
+		obs.Units = units
+	}
+	return FormatConditions(obs, units.Preferences()), nil
+}
+
+// FormatConditions accepts an Observation and the Preferences to show it in
+// and returns a string summarizing it in English (e.g. "few clouds, 52.72 F,
+// humidity 47%").
+func FormatConditions(obs Observation, prefs Preferences) string {
+	return Locale{}.FormatConditions(obs, prefs)
+}
+
+// ConsensusConditions accepts a Consensus, a location (e.g. "london",
+// "tampa,us", etc.) and a measurement unit ("standard", "metric" or
+// "imperial"), requests the current observation for that location from all
+// of the Consensus's providers and returns a string summarizing their median
+// along with the spread between their temperatures. An error is returned if
+// the units are invalid or if every provider returns an error.
+func ConsensusConditions(c Consensus, location string, units Units) (string, error) {
+	if !units.Valid() {
+		return "", ErrInvalidUnits
+	}
+	co, err := c.CurrentConsensus(location, units)
+	if err != nil {
+		return "", err
+	}
+	return Locale{}.formatConsensus(co, units.Preferences()), nil
+}
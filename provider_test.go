@@ -0,0 +1,86 @@
+package weather_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aculclasure/weather"
+)
+
+// fakeProvider is a weather.Provider that returns canned data.
+type fakeProvider struct {
+	obs       weather.Observation
+	forecasts []weather.DayForecast
+	loc       weather.Location
+	err       error
+}
+
+func (f fakeProvider) CurrentObservation(location string, units weather.Units) (weather.Observation, error) {
+	return f.obs, f.err
+}
+
+func (f fakeProvider) DailyForecast(location string, units weather.Units) ([]weather.DayForecast, error) {
+	return f.forecasts, f.err
+}
+
+func (f fakeProvider) Geocode(location string) (weather.Location, error) {
+	return f.loc, f.err
+}
+
+func TestCurrentConditions(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		provider    weather.Provider
+		units       weather.Units
+		want        string
+		errExpected bool
+	}{
+		"provider error is returned": {
+			provider:    fakeProvider{err: errors.New("boom")},
+			units:       "metric",
+			errExpected: true,
+		},
+		"invalid units returns an error": {
+			provider:    fakeProvider{},
+			units:       "martian",
+			errExpected: true,
+		},
+		"observation is summarized": {
+			provider: fakeProvider{obs: weather.Observation{
+				Summary:  "few clouds",
+				Temp:     52.72,
+				Humidity: 47,
+			}},
+			units: "imperial",
+			want:  "few clouds, 52.72 F, humidity 47%",
+		},
+		"units in any case": {
+			provider: fakeProvider{obs: weather.Observation{
+				Summary:  "few clouds",
+				Temp:     11.51,
+				Humidity: 47,
+			}},
+			units: "Metric",
+			want:  "few clouds, 11.51 C, humidity 47%",
+		},
+		"observation without a summary": {
+			provider: fakeProvider{obs: weather.Observation{Temp: 283.1, Humidity: 80}},
+			units:    "standard",
+			want:     ", 283.10 K, humidity 80%",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := weather.CurrentConditions(tc.provider, "London", tc.units)
+			errReceived := err != nil
+
+			if tc.errExpected != errReceived {
+				t.Fatalf("got unexpected error status: %v", errReceived)
+			}
+			if !tc.errExpected && tc.want != got {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package weather
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aculclasure/weather/stats"
+)
+
+// Report accepts a History, a HistoryQuery selecting the observations to
+// summarize and stats.Options, and returns a stats.Summary of the selected
+// observations for each period. An error is returned if the History cannot
+// be queried or if no observations match the query.
+func Report(h *History, q HistoryQuery, opts stats.Options) ([]stats.Summary, error) {
+	records, err := h.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]stats.Sample, len(records))
+	for i, r := range records {
+		samples[i] = stats.Sample{Time: r.Time, Temp: r.Temp, Precip: r.Precip}
+	}
+	return stats.Summarize(samples, opts)
+}
+
+// reportColumns are the column headings of a report, in order.
+var reportColumns = []string{
+	"start", "samples", "min", "max", "mean", "hdd", "cdd", "precip",
+	"hours_above", "hours_below", "mean_rank", "precip_rank",
+}
+
+// reportRow returns the values of a report row for a Summary, formatted for
+// the columns in reportColumns.
+func reportRow(s stats.Summary) []string {
+	hours := func(h *float64) string {
+		if h == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*h, 'f', 1, 64)
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	return []string{
+		s.Start.Format("2006-01-02"), strconv.Itoa(s.Samples),
+		f(s.Min), f(s.Max), f(s.Mean),
+		f(s.HeatingDegreeDays), f(s.CoolingDegreeDays), f(s.Precip),
+		hours(s.HoursAbove), hours(s.HoursBelow),
+		strconv.FormatFloat(s.MeanRank, 'f', 0, 64), strconv.FormatFloat(s.PrecipRank, 'f', 0, 64),
+	}
+}
+
+// writeReport writes summaries to w in the given format ("text", "csv" or
+// "json"). An error is returned if the format is unknown or writing fails.
+func writeReport(w io.Writer, summaries []stats.Summary, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(reportColumns)
+		for _, s := range summaries {
+			cw.Write(reportRow(s))
+		}
+		cw.Flush()
+		return cw.Error()
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "START\tSAMPLES\tMIN (C)\tMAX (C)\tMEAN (C)\tHDD\tCDD\tPRECIP (mm)\tHOURS ABOVE\tHOURS BELOW\tMEAN %ILE\tPRECIP %ILE\t")
+		for _, s := range summaries {
+			for _, v := range reportRow(s) {
+				fmt.Fprintf(tw, "%s\t", v)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown format %q, must be one of: text, csv, json", format)
+}
+
+// floatFlag represents a flag holding an optional float64.
+type floatFlag struct {
+	v *float64
+}
+
+func (f *floatFlag) String() string {
+	if f.v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f.v, 'g', -1, 64)
+}
+
+func (f *floatFlag) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	f.v = &v
+	return nil
+}
+
+// ReportCLI accepts a slice of command line flags and arguments and prints
+// daily, weekly or monthly statistics for the observations recorded for a
+// location in the history database, as a text table, CSV or JSON. An error
+// is returned if the flags are invalid, if the database cannot be opened or
+// queried, or if no observations match.
+func ReportCLI(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather report [-db=weather.db] -location=<location> [-period={day|week|month}] [-since=<time|duration>] [-until=<time|duration>] [-base=18] [-above=<C>] [-below=<C>] [-format={text|csv|json}]\n\n"))
+		fs.PrintDefaults()
+	}
+	opts := stats.DefaultOptions()
+	var above, below floatFlag
+	path := fs.String("db", "weather.db", "the SQLite database observations are recorded in")
+	location := fs.String("location", "", "the location to report on")
+	source := fs.String("source", "", "only use observations recorded from this provider")
+	period := fs.String("period", "day", "the period to summarize over, one of: day, week, month")
+	since := fs.String("since", "", "only use observations from this RFC 3339 time, or this long ago (e.g. '720h')")
+	until := fs.String("until", "", "only use observations up to this RFC 3339 time, or this long ago")
+	tz := fs.String("tz", "UTC", "the time zone periods start and end in (e.g. 'America/New_York', 'Local')")
+	fs.Float64Var(&opts.Base, "base", opts.Base, "the base temperature in C for heating and cooling degree days")
+	fs.Var(&above, "above", "count the hours above this temperature in C")
+	fs.Var(&below, "below", "count the hours below this temperature in C")
+	format := fs.String("format", "text", "the output format, one of: text, csv, json")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *location == "" {
+		return errors.New("location flag must be set")
+	}
+	if *format != "text" && *format != "csv" && *format != "json" {
+		return errors.New("format flag must be one of: text, csv, json")
+	}
+	var err error
+	if opts.Period, err = stats.ParsePeriod(*period); err != nil {
+		return err
+	}
+	if opts.Location, err = time.LoadLocation(*tz); err != nil {
+		return err
+	}
+	opts.Above, opts.Below = above.v, below.v
+
+	q := HistoryQuery{Location: *location, Source: *source}
+	now := time.Now()
+	if q.Since, err = parseTimeFlag(*since, now); err != nil {
+		return fmt.Errorf("since flag: %w", err)
+	}
+	if q.Until, err = parseTimeFlag(*until, now); err != nil {
+		return fmt.Errorf("until flag: %w", err)
+	}
+
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+	h, err := OpenHistory(*path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	summaries, err := Report(h, q, opts)
+	if err != nil {
+		return err
+	}
+	return writeReport(os.Stdout, summaries, *format)
+}
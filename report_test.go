@@ -0,0 +1,39 @@
+package weather_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/aculclasure/weather/stats"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+	h, _ := openTestHistory(t)
+	start := time.Date(2021, 5, 17, 0, 0, 0, 0, time.UTC)
+	for i, temp := range []float64{10, 14, 20, 24} {
+		obs := weather.Observation{Time: start.Add(time.Duration(i) * 12 * time.Hour), Temp: temp}
+		if _, err := h.Record("london", "owm", obs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := h.Record("tampa", "owm", weather.Observation{Time: start, Temp: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := weather.Report(h, weather.HistoryQuery{Location: "london"}, stats.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 daily summaries, got %d", len(got))
+	}
+	if got[0].Mean != 12 || got[1].Mean != 22 {
+		t.Fatalf("want daily means 12 and 22, got %v and %v", got[0].Mean, got[1].Mean)
+	}
+
+	if _, err := weather.Report(h, weather.HistoryQuery{Location: "paris"}, stats.DefaultOptions()); err == nil {
+		t.Fatal("want error reporting on a location with no observations")
+	}
+}
@@ -0,0 +1,538 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Rule represents a condition on the weather at a location that should be
+// reported when it becomes true. When is a small expression comparing
+// fields of the current observation or of today's or tomorrow's forecast to
+// thresholds, optionally with units, joined by "and", for example:
+//
+//	current.wind_gust > 40 mph
+//	tomorrow.low < 0 C and tomorrow.precip_prob >= 50 %
+//
+// Once a Rule fires it does not fire again until it has cleared, and it only
+// clears once each compared value has moved Hysteresis past its threshold,
+// in the threshold's units, so that readings hovering around a threshold do
+// not fire the Rule on every poll.
+type Rule struct {
+	Name       string  `json:"name"`
+	Location   string  `json:"location"`
+	When       string  `json:"when"`
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+}
+
+// LoadRules reads a JSON array of Rules from r, for example:
+//
+//	[{"name": "warehouse gusts", "location": "tampa,fl,us",
+//	  "when": "current.wind_gust > 40 mph", "hysteresis": 5}]
+//
+// An error is returned if the JSON cannot be decoded.
+func LoadRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("decoding rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ruleDimension returns the kind of quantity a threshold in unit measures
+// (e.g. "temperature"), or "" if unit is unknown. Thresholds may be given
+// in the units of any of the typed quantities, spelled in any case, or in
+// "%".
+func ruleDimension(unit string) string {
+	if unit == "%" {
+		return "percent"
+	}
+	for _, u := range []TemperatureUnit{Kelvin, Celsius, Fahrenheit} {
+		if unit == string(u) {
+			return "temperature"
+		}
+	}
+	if _, ok := metersPerSecond[SpeedUnit(unit)]; ok {
+		return "speed"
+	}
+	if _, ok := hectopascals[PressureUnit(unit)]; ok {
+		return "pressure"
+	}
+	if _, ok := millimeters[PrecipitationUnit(unit)]; ok {
+		return "precipitation"
+	}
+	if _, ok := meters[DistanceUnit(unit)]; ok {
+		return "distance"
+	}
+	return ""
+}
+
+// ruleUnit returns the symbol of the unit spelled unit in any case (e.g.
+// "mph" for "MPH"), or unit itself if there is no such unit.
+func ruleUnit(unit string) string {
+	symbols := []string{string(Kelvin), string(Celsius), string(Fahrenheit)}
+	for u := range metersPerSecond {
+		symbols = append(symbols, string(u))
+	}
+	for u := range hectopascals {
+		symbols = append(symbols, string(u))
+	}
+	for u := range millimeters {
+		symbols = append(symbols, string(u))
+	}
+	for u := range meters {
+		symbols = append(symbols, string(u))
+	}
+	for _, sym := range symbols {
+		if strings.EqualFold(unit, sym) {
+			return sym
+		}
+	}
+	return unit
+}
+
+// ruleConvert converts v from one unit to another of the same dimension
+// using the typed quantities.
+func ruleConvert(v float64, from, to string) float64 {
+	switch ruleDimension(from) {
+	case "temperature":
+		return Temperature{v, TemperatureUnit(from)}.In(TemperatureUnit(to)).Value
+	case "speed":
+		return Speed{v, SpeedUnit(from)}.In(SpeedUnit(to)).Value
+	case "pressure":
+		return Pressure{v, PressureUnit(from)}.In(PressureUnit(to)).Value
+	case "precipitation":
+		return Precipitation{v, PrecipitationUnit(from)}.In(PrecipitationUnit(to)).Value
+	case "distance":
+		return Distance{v, DistanceUnit(from)}.In(DistanceUnit(to)).Value
+	}
+	return v
+}
+
+// ruleData represents the weather data a Rule is evaluated against, in
+// metric units. Forecasts is nil if the Rule's fields do not need it.
+type ruleData struct {
+	obs       Observation
+	forecasts []DayForecast
+}
+
+// ruleField represents a field that may be compared in a Rule, along with
+// its default units and how to read it from the weather data.
+type ruleField struct {
+	unit  string
+	value func(d ruleData) (float64, bool)
+}
+
+// currentField returns a ruleField reading a value from the current
+// observation.
+func currentField(unit string, value func(o Observation) float64) ruleField {
+	return ruleField{unit, func(d ruleData) (float64, bool) {
+		return value(d.obs), true
+	}}
+}
+
+// dayField returns a ruleField reading a value from the forecast for the
+// given day, where 0 is today.
+func dayField(day int, unit string, value func(f DayForecast) float64) ruleField {
+	return ruleField{unit, func(d ruleData) (float64, bool) {
+		if day >= len(d.forecasts) {
+			return 0, false
+		}
+		return value(d.forecasts[day]), true
+	}}
+}
+
+var ruleFields = map[string]ruleField{
+	"current.temp":       currentField("C", func(o Observation) float64 { return o.Temp }),
+	"current.feels_like": currentField("C", func(o Observation) float64 { return o.FeelsLike }),
+	"current.humidity":   currentField("%", func(o Observation) float64 { return float64(o.Humidity) }),
+	"current.pressure":   currentField("hPa", func(o Observation) float64 { return o.Pressure }),
+	"current.wind_speed": currentField("m/s", func(o Observation) float64 { return o.WindSpeed }),
+	"current.wind_gust":  currentField("m/s", func(o Observation) float64 { return o.WindGust }),
+	"current.clouds":     currentField("%", func(o Observation) float64 { return float64(o.Clouds) }),
+	"current.visibility": currentField("m", func(o Observation) float64 { return o.Visibility }),
+	"current.precip":     currentField("mm", func(o Observation) float64 { return o.Precip }),
+}
+
+func init() {
+	for day, prefix := range []string{"today.", "tomorrow."} {
+		ruleFields[prefix+"low"] = dayField(day, "C", func(f DayForecast) float64 { return f.Low })
+		ruleFields[prefix+"high"] = dayField(day, "C", func(f DayForecast) float64 { return f.High })
+		ruleFields[prefix+"humidity"] = dayField(day, "%", func(f DayForecast) float64 { return float64(f.Humidity) })
+		ruleFields[prefix+"precip_prob"] = dayField(day, "%", func(f DayForecast) float64 { return f.PrecipProb * 100 })
+		ruleFields[prefix+"precip"] = dayField(day, "mm", func(f DayForecast) float64 { return f.Precip })
+	}
+}
+
+// comparison represents one comparison of a Rule's expression.
+type comparison struct {
+	field     string
+	op        string
+	threshold float64 // in unit
+	unit      string
+}
+
+var (
+	andRE        = regexp.MustCompile(`(?i)\s+and\s+`)
+	comparisonRE = regexp.MustCompile(`^([a-z_]+\.[a-z_]+)\s*(>=|<=|>|<)\s*(-?[0-9]*\.?[0-9]+)\s*(\S*)$`)
+)
+
+// parseRuleExpr parses a Rule's When expression into its comparisons. An
+// error is returned if the expression is empty, refers to an unknown field
+// or unit, or compares a field to a threshold in units of another
+// dimension.
+func parseRuleExpr(expr string) ([]comparison, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, errors.New("expression must not be empty")
+	}
+	var comps []comparison
+	for _, part := range andRE.Split(strings.TrimSpace(expr), -1) {
+		m := comparisonRE.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, fmt.Errorf("invalid comparison %q, must be of the form <field> <op> <number> [unit]", part)
+		}
+		f, ok := ruleFields[m[1]]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", m[1])
+		}
+		threshold, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %w", m[3], err)
+		}
+		unit := ruleUnit(m[4])
+		if unit == "" {
+			unit = f.unit
+		}
+		dim := ruleDimension(unit)
+		if dim == "" {
+			return nil, fmt.Errorf("unknown unit %q", unit)
+		}
+		if fieldDim := ruleDimension(f.unit); dim != fieldDim {
+			return nil, fmt.Errorf("unit %q cannot be used with field %s, which is a %s", unit, m[1], fieldDim)
+		}
+		comps = append(comps, comparison{field: m[1], op: m[2], threshold: threshold, unit: unit})
+	}
+	return comps, nil
+}
+
+// value returns the compared field's value from d in the comparison's
+// units, and false if d does not contain it.
+func (c comparison) value(d ruleData) (float64, bool) {
+	f := ruleFields[c.field]
+	v, ok := f.value(d)
+	if !ok {
+		return 0, false
+	}
+	return ruleConvert(v, f.unit, c.unit), true
+}
+
+// holds reports whether v satisfies the comparison, with the threshold
+// moved by slack in the direction that makes it easier to satisfy.
+func (c comparison) holds(v, slack float64) bool {
+	switch c.op {
+	case ">":
+		return v > c.threshold-slack
+	case ">=":
+		return v >= c.threshold-slack
+	case "<":
+		return v < c.threshold+slack
+	}
+	return v <= c.threshold+slack
+}
+
+// usesForecast reports whether any of the comparisons needs the daily
+// forecast.
+func usesForecast(comps []comparison) bool {
+	for _, c := range comps {
+		if !strings.HasPrefix(c.field, "current.") {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleResult represents the outcome of evaluating a Rule once.
+type RuleResult struct {
+	Rule    Rule
+	Matched bool
+	// Values describes each compared value, e.g. "current.wind_gust is
+	// 45.20 mph (> 40 mph)".
+	Values []string
+	Err    error
+}
+
+// RuleEvent represents a Rule firing or clearing.
+type RuleEvent struct {
+	Rule     string    `json:"rule"`
+	Location string    `json:"location"`
+	State    string    `json:"state"` // "firing" or "resolved"
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// RuleEngine represents a set of Rules evaluated against the weather data
+// from a Provider. It remembers which Rules are firing between evaluations,
+// so that each Rule only produces an event when it fires or clears. It is
+// safe for concurrent use.
+type RuleEngine struct {
+	Provider Provider
+	Rules    []Rule
+
+	mu     sync.Mutex
+	comps  map[string][]comparison
+	firing map[string]bool
+}
+
+// NewRuleEngine accepts a Provider and one or more Rules and returns a
+// RuleEngine evaluating them against that Provider's data. An error is
+// returned if no rules are given, if a Rule's name or location is empty, if
+// two Rules have the same name, or if a Rule's expression is invalid.
+func NewRuleEngine(p Provider, rules ...Rule) (*RuleEngine, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("at least one rule must be given")
+	}
+	e := &RuleEngine{
+		Provider: p,
+		Rules:    rules,
+		comps:    map[string][]comparison{},
+		firing:   map[string]bool{},
+	}
+	for _, r := range rules {
+		if r.Name == "" {
+			return nil, errors.New("rule name must not be empty")
+		}
+		if _, ok := e.comps[r.Name]; ok {
+			return nil, fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		if r.Location == "" {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, errEmptyLocation)
+		}
+		if r.Hysteresis < 0 {
+			return nil, fmt.Errorf("rule %q: hysteresis must not be negative", r.Name)
+		}
+		comps, err := parseRuleExpr(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		e.comps[r.Name] = comps
+	}
+	return e, nil
+}
+
+// Check evaluates every Rule against the current weather data without
+// remembering which are firing, as a dry run. Rules whose data could not be
+// fetched have their error recorded in their RuleResult.
+func (e *RuleEngine) Check() []RuleResult {
+	e.mu.Lock()
+	firing := make(map[string]bool, len(e.firing))
+	for k, v := range e.firing {
+		firing[k] = v
+	}
+	e.mu.Unlock()
+	return e.evaluate(firing)
+}
+
+// Evaluate evaluates every Rule against the current weather data and
+// returns an event for each Rule that has started firing, or that was
+// firing and has cleared, since the last evaluation. Rules whose data could
+// not be fetched keep their previous state, and the first such error is
+// returned along with the events of the other Rules.
+func (e *RuleEngine) Evaluate() ([]RuleEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var events []RuleEvent
+	var firstErr error
+	for _, res := range e.evaluate(e.firing) {
+		name := res.Rule.Name
+		if res.Err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("rule %q: %w", name, res.Err)
+			}
+			continue
+		}
+		if res.Matched == e.firing[name] {
+			continue
+		}
+		e.firing[name] = res.Matched
+		ev := RuleEvent{
+			Rule:     name,
+			Location: res.Rule.Location,
+			State:    "resolved",
+			Time:     time.Now(),
+		}
+		if res.Matched {
+			ev.State = "firing"
+		}
+		ev.Message = fmt.Sprintf("%s at %s is %s: %s", name, res.Rule.Location, ev.State, strings.Join(res.Values, ", "))
+		events = append(events, ev)
+	}
+	return events, firstErr
+}
+
+// evaluate evaluates every Rule, applying hysteresis to those marked as
+// firing, fetching the data for each location only once.
+func (e *RuleEngine) evaluate(firing map[string]bool) []RuleResult {
+	type fetched struct {
+		data ruleData
+		err  error
+	}
+	cache := map[string]*fetched{}
+	needsForecast := map[string]bool{}
+	for _, r := range e.Rules {
+		if usesForecast(e.comps[r.Name]) {
+			needsForecast[r.Location] = true
+		}
+	}
+
+	results := make([]RuleResult, 0, len(e.Rules))
+	for _, r := range e.Rules {
+		f, ok := cache[r.Location]
+		if !ok {
+			f = &fetched{}
+			f.data.obs, f.err = e.Provider.CurrentObservation(r.Location, Metric)
+			if f.err == nil && needsForecast[r.Location] {
+				f.data.forecasts, f.err = e.Provider.DailyForecast(r.Location, Metric)
+			}
+			cache[r.Location] = f
+		}
+		res := RuleResult{Rule: r, Err: f.err}
+		if f.err == nil {
+			res.Matched, res.Values, res.Err = evaluateRule(e.comps[r.Name], f.data, r.Hysteresis, firing[r.Name])
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// evaluateRule reports whether all of a Rule's comparisons hold for d,
+// allowing hysteresis slack if the Rule is already firing, and describes
+// the compared values. An error is returned if d is missing a value.
+func evaluateRule(comps []comparison, d ruleData, hysteresis float64, firing bool) (bool, []string, error) {
+	slack := 0.0
+	if firing {
+		slack = hysteresis
+	}
+	matched := true
+	var values []string
+	for _, c := range comps {
+		v, ok := c.value(d)
+		if !ok {
+			return false, nil, fmt.Errorf("no data for %s", c.field)
+		}
+		if !c.holds(v, slack) {
+			matched = false
+		}
+		values = append(values, fmt.Sprintf("%s is %.2f %s (%s %g %s)", c.field, v, c.unit, c.op, c.threshold, c.unit))
+	}
+	return matched, values, nil
+}
+
+// RulesCLI accepts a slice of command line flags and arguments and runs the
+// rules subcommand named by the first argument. The "check" subcommand
+// evaluates each rule in the rules file once and prints whether it would
+// fire, as a dry run. The "run" subcommand evaluates the rules on an
+// interval until the process is interrupted, delivering notifications
+// through the configured webhooks, email recipients and command when rules
+// fire or clear and when government alerts are issued. An error is returned
+// if the subcommand is unknown, if the flags are invalid, if the rules file
+// cannot be read or is invalid, or if the provider cannot be created.
+func RulesCLI(args []string) error {
+	const usage = "USAGE: weather rules {check|run} [-rules=rules.json] [-provider=<name>]"
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if len(args) == 0 || (args[0] != "check" && args[0] != "run") {
+		return errors.New(usage)
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("rules "+sub, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte(usage + "\n\n"))
+		fs.PrintDefaults()
+	}
+	path := fs.String("rules", "rules.json", "the JSON file to load rules from")
+	provider := fs.String("provider", "owm", "the weather provider to use, one of: owm, openmeteo, nws, metno, or a comma-separated list of them prefixed with fallback: or consensus:")
+	var nc notifyConfig
+	var interval time.Duration
+	if sub == "run" {
+		fs.DurationVar(&interval, "interval", 5*time.Minute, "how often to evaluate the rules")
+		nc.register(fs)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rules, err := LoadRules(f)
+	if err != nil {
+		return err
+	}
+	p, err := providerFromName(*provider, "")
+	if err != nil {
+		return err
+	}
+	e, err := NewRuleEngine(p, rules...)
+	if err != nil {
+		return err
+	}
+	if sub == "check" {
+		writeRuleResults(os.Stdout, e.Check())
+		return nil
+	}
+
+	if interval <= 0 {
+		return errors.New("interval flag must be positive")
+	}
+	n, err := nc.notifier()
+	if err != nil {
+		return err
+	}
+	m := NewMonitor(e, n)
+	m.Alerts = nc.alerts
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		m.Run(interval, stop)
+		close(done)
+	}()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	<-sig
+	close(stop)
+	<-done
+	return nil
+}
+
+// writeRuleResults writes one line per RuleResult describing whether the
+// rule would fire.
+func writeRuleResults(w io.Writer, results []RuleResult) {
+	for _, res := range results {
+		status, detail := "ok", strings.Join(res.Values, ", ")
+		switch {
+		case res.Err != nil:
+			status, detail = "ERROR", res.Err.Error()
+		case res.Matched:
+			status = "FIRING"
+		}
+		fmt.Fprintf(w, "%-7s %s (%s): %s\n", status, res.Rule.Name, res.Rule.Location, detail)
+	}
+}
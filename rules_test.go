@@ -0,0 +1,171 @@
+package weather_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadRules(t *testing.T) {
+	t.Parallel()
+	got, err := weather.LoadRules(strings.NewReader(`[
+		{"name": "warehouse gusts", "location": "tampa,fl,us", "when": "current.wind_gust > 40 mph", "hysteresis": 5},
+		{"name": "frost", "location": "london", "when": "tomorrow.low < 0 C"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weather.Rule{
+		{Name: "warehouse gusts", Location: "tampa,fl,us", When: "current.wind_gust > 40 mph", Hysteresis: 5},
+		{Name: "frost", Location: "london", When: "tomorrow.low < 0 C"},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+
+	if _, err := weather.LoadRules(strings.NewReader(`{"name": "not a list"}`)); err == nil {
+		t.Fatal("want error decoding a rules object instead of a list")
+	}
+}
+
+func TestNewRuleEngineInvalidRules(t *testing.T) {
+	t.Parallel()
+	testCases := map[string][]weather.Rule{
+		"no rules":          nil,
+		"empty name":        {{Location: "london", When: "current.temp > 30"}},
+		"empty location":    {{Name: "hot", When: "current.temp > 30"}},
+		"empty expression":  {{Name: "hot", Location: "london"}},
+		"unknown field":     {{Name: "hot", Location: "london", When: "current.heat > 30"}},
+		"unknown unit":      {{Name: "hot", Location: "london", When: "current.temp > 30 R"}},
+		"mismatched unit":   {{Name: "hot", Location: "london", When: "current.temp > 30 mph"}},
+		"malformed":         {{Name: "hot", Location: "london", When: "current.temp is hot"}},
+		"unsupported op":    {{Name: "hot", Location: "london", When: "current.temp == 30"}},
+		"negative slack":    {{Name: "hot", Location: "london", When: "current.temp > 30", Hysteresis: -1}},
+		"duplicate name":    {{Name: "hot", Location: "london", When: "current.temp > 30"}, {Name: "hot", Location: "paris", When: "current.temp > 30"}},
+		"bad second clause": {{Name: "hot", Location: "london", When: "current.temp > 30 and nope"}},
+	}
+
+	for name, rules := range testCases {
+		rules := rules
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := weather.NewRuleEngine(fakeProvider{}, rules...); err == nil {
+				t.Fatalf("want error for rules %+v", rules)
+			}
+		})
+	}
+}
+
+func TestRuleEngineCheck(t *testing.T) {
+	t.Parallel()
+	p := fakeProvider{
+		obs: weather.Observation{Temp: 11.5, WindGust: 20, Humidity: 80},
+		forecasts: []weather.DayForecast{
+			{Low: 4, High: 12, PrecipProb: 0.2},
+			{Low: -1.5, High: 6, PrecipProb: 0.6},
+		},
+	}
+	e, err := weather.NewRuleEngine(p,
+		weather.Rule{Name: "gusts", Location: "london", When: "current.wind_gust > 40 MPH"},
+		weather.Rule{Name: "frost", Location: "london", When: "tomorrow.low < 0 c and tomorrow.precip_prob >= 50 %"},
+		weather.Rule{Name: "mild", Location: "london", When: "current.temp > 50 F and current.humidity > 70"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := e.Check()
+	want := []weather.RuleResult{
+		{
+			Rule:    e.Rules[0],
+			Matched: true,
+			Values:  []string{"current.wind_gust is 44.74 mph (> 40 mph)"},
+		},
+		{
+			Rule:    e.Rules[1],
+			Matched: true,
+			Values: []string{
+				"tomorrow.low is -1.50 C (< 0 C)",
+				"tomorrow.precip_prob is 60.00 % (>= 50 %)",
+			},
+		},
+		{
+			Rule:    e.Rules[2],
+			Matched: true,
+			Values: []string{
+				"current.temp is 52.70 F (> 50 F)",
+				"current.humidity is 80.00 % (> 70 %)",
+			},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestRuleEngineEvaluateAppliesHysteresis(t *testing.T) {
+	t.Parallel()
+	gusts := []float64{15, 18.5, 17.5, 16, 14, 19}
+	p := &sequenceProvider{}
+	for _, g := range gusts {
+		p.obs = append(p.obs, weather.Observation{WindGust: g})
+	}
+	e, err := weather.NewRuleEngine(p, weather.Rule{
+		Name:       "gusts",
+		Location:   "warehouse",
+		When:       "current.wind_gust > 17 m/s",
+		Hysteresis: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for range gusts {
+		events, err := e.Evaluate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range events {
+			got = append(got, ev.State)
+		}
+		got = append(got, "|")
+	}
+	// 15: quiet, 18.5: fires, 17.5 and 16: still above 17-2, 14: resolves,
+	// 19: fires again.
+	want := []string{"|", "firing", "|", "|", "|", "resolved", "|", "firing", "|"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestRuleEngineEvaluateKeepsStateOnError(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	p := &sequenceProvider{
+		obs:  []weather.Observation{{Temp: 35}, {}, {Temp: 36}},
+		errs: []error{nil, boom},
+	}
+	e, err := weather.NewRuleEngine(p, weather.Rule{Name: "hot", Location: "tampa", When: "current.temp > 30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := e.Evaluate()
+	if err != nil || len(events) != 1 || events[0].State != "firing" {
+		t.Fatalf("want one firing event, got %+v (error: %v)", events, err)
+	}
+	if !strings.Contains(events[0].Message, "current.temp is 35.00 C") {
+		t.Fatalf("want message to describe the value, got %q", events[0].Message)
+	}
+	if _, err := e.Evaluate(); !errors.Is(err, boom) {
+		t.Fatalf("want error %v, got %v", boom, err)
+	}
+	events, err = e.Evaluate()
+	if err != nil || len(events) != 0 {
+		t.Fatalf("want no events after the rule kept firing, got %+v (error: %v)", events, err)
+	}
+}
@@ -0,0 +1,316 @@
+package weather
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Server represents an HTTP server exposing weather data from a Provider as
+// a JSON API, so that many applications can share one Provider, along with
+// its API key, cache and rate limit. It serves the following routes:
+//
+//	GET /v1/current?location=<location>&units=<units>
+//	GET /v1/forecast?location=<location>&units=<units>
+//	GET /v1/geocode?location=<location>
+//	GET /v1/alerts?location=<location>
+//	GET /feed/<location>.atom?units=<units>
+//	GET /feed/<location>.rss?units=<units>
+//	GET /healthz
+//
+// The units parameter is optional and defaults to the Server's Units. The
+// feed routes serve the current conditions, daily forecasts and alerts for
+// the location as an Atom or RSS feed.
+type Server struct {
+	Provider Provider
+	Units    Units
+
+	mux *http.ServeMux
+}
+
+// NewServer accepts a Provider and returns a Server serving its data, with
+// "imperial" as the default units.
+func NewServer(p Provider) *Server {
+	s := &Server{
+		Provider: p,
+		Units:    Imperial,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/v1/current", s.handleCurrent)
+	s.mux.HandleFunc("/v1/forecast", s.handleForecast)
+	s.mux.HandleFunc("/v1/geocode", s.handleGeocode)
+	s.mux.HandleFunc("/v1/alerts", s.handleAlerts)
+	s.mux.HandleFunc("/feed/", s.handleFeed)
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	return s
+}
+
+// ServeHTTP dispatches the request to the handler for its route, responding
+// with 405 Method Not Allowed to anything other than GET or HEAD requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, errors.New("method must be GET"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	location, units, ok := s.params(w, r)
+	if !ok {
+		return
+	}
+	obs, err := s.Provider.CurrentObservation(location, units)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, obs)
+}
+
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	location, units, ok := s.params(w, r)
+	if !ok {
+		return
+	}
+	forecasts, err := s.Provider.DailyForecast(location, units)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, forecasts)
+}
+
+func (s *Server) handleGeocode(w http.ResponseWriter, r *http.Request) {
+	location, _, ok := s.params(w, r)
+	if !ok {
+		return
+	}
+	loc, err := s.Provider.Geocode(location)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, loc)
+}
+
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	location, _, ok := s.params(w, r)
+	if !ok {
+		return
+	}
+	ap, ok := s.Provider.(AlertProvider)
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errors.New("provider does not support alerts"))
+		return
+	}
+	alerts, err := ap.Alerts(location)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/feed/")
+	format := path.Ext(name)
+	location := strings.TrimSuffix(name, format)
+	if format != ".atom" && format != ".rss" {
+		writeJSONError(w, http.StatusNotFound, errors.New("feed must end in .atom or .rss"))
+		return
+	}
+	if location == "" {
+		writeJSONError(w, http.StatusBadRequest, errEmptyLocation)
+		return
+	}
+	// The location is part of the path rather than the query, so it is
+	// added to the query of a copy of the request for params to read.
+	pr := r.Clone(r.Context())
+	q := pr.URL.Query()
+	q.Set("location", location)
+	pr.URL.RawQuery = q.Encode()
+	location, units, ok := s.params(w, pr)
+	if !ok {
+		return
+	}
+
+	obs, err := s.Provider.CurrentObservation(location, units)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	forecasts, err := s.Provider.DailyForecast(location, units)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	var alerts []Alert
+	if ap, ok := s.Provider.(AlertProvider); ok {
+		if alerts, err = ap.Alerts(location); err != nil {
+			writeProviderError(w, err)
+			return
+		}
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	feed := Feed{
+		Location:  location,
+		Link:      scheme + "://" + r.Host + r.URL.RequestURI(),
+		Current:   &obs,
+		Forecasts: forecasts,
+		Alerts:    alerts,
+		Units:     units,
+	}
+	var buf bytes.Buffer
+	encode, contentType := feed.EncodeAtom, "application/atom+xml; charset=utf-8"
+	if format == ".rss" {
+		encode, contentType = feed.EncodeRSS, "application/rss+xml; charset=utf-8"
+	}
+	if err := encode(&buf); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// params returns the location and units query parameters of the request.
+// If either is invalid, it writes a 400 Bad Request response and returns
+// false.
+func (s *Server) params(w http.ResponseWriter, r *http.Request) (string, Units, bool) {
+	q := r.URL.Query()
+	location := q.Get("location")
+	if location == "" {
+		writeJSONError(w, http.StatusBadRequest, errEmptyLocation)
+		return "", "", false
+	}
+	units := s.Units
+	if name := q.Get("units"); name != "" {
+		var err error
+		if units, err = ParseUnits(name); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return "", "", false
+		}
+	}
+	if !units.Valid() {
+		writeJSONError(w, http.StatusBadRequest, ErrInvalidUnits)
+		return "", "", false
+	}
+	return location, units, true
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("error encoding response: %v", err)
+	}
+}
+
+// writeJSONError writes err as a JSON response of the form
+// {"error": "<message>"} with the given status code.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// errUpstreamUnavailable is the error clients are sent when a Provider
+// fails, instead of its own error, which may describe the upstream request.
+var errUpstreamUnavailable = errors.New("upstream unavailable")
+
+// writeProviderError writes an error returned by a Provider as a JSON
+// response, using 429 Too Many Requests if the provider was rate limited
+// and 502 Bad Gateway with a generic message otherwise, logging the error
+// itself.
+func writeProviderError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrRateLimited) {
+		writeJSONError(w, http.StatusTooManyRequests, ErrRateLimited)
+		return
+	}
+	log.Printf("provider error: %v", err)
+	writeJSONError(w, http.StatusBadGateway, errUpstreamUnavailable)
+}
+
+// ServeCLI accepts a slice of command line flags and arguments, creates a
+// Client with a shared cache and rate limit, and serves its data over HTTP
+// until the process is interrupted, at which point in-flight requests are
+// allowed to finish before it returns. An error is returned if the
+// OPENWEATHER_API_KEY environment variable is not set, if the command line
+// flags are invalid, or if the server fails.
+func ServeCLI(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather serve [-addr=:8080] [-cache-ttl=10m] [-rate=60]\n\n"))
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", ":8080", "the address to listen on")
+	ttl := fs.Duration("cache-ttl", 10*time.Minute, "how long to cache OpenWeather responses")
+	rate := fs.Int("rate", 60, "the maximum number of OpenWeather requests per minute")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rate < 1 {
+		return errors.New("rate flag must be at least 1")
+	}
+
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		return errors.New("environment variable OPENWEATHER_API_KEY must be set")
+	}
+	client, err := NewClient(apiKey)
+	if err != nil {
+		return err
+	}
+	client.Cache = NewCache(*ttl)
+	client.Limiter = NewRateLimiter(*rate, time.Minute)
+
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      NewServer(client),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving weather API on %s", *addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	select {
+	case err := <-errs:
+		return err
+	case <-stop:
+	}
+
+	log.Print("shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
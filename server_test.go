@@ -0,0 +1,253 @@
+package weather_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+// alertingProvider is a weather.Provider and weather.AlertProvider that
+// returns canned data.
+type alertingProvider struct {
+	fakeProvider
+	alerts []weather.Alert
+}
+
+func (a alertingProvider) Alerts(location string) ([]weather.Alert, error) {
+	return a.alerts, a.err
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	provider := alertingProvider{
+		fakeProvider: fakeProvider{
+			obs: weather.Observation{Summary: "few clouds", Temp: 52.72, Humidity: 47},
+			forecasts: []weather.DayForecast{
+				{Date: time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC), Low: 40, High: 60},
+			},
+			loc: weather.Location{Name: "London", Country: "GB", Lat: 51.5085, Lon: -0.1257},
+		},
+		alerts: []weather.Alert{{ID: "1", Event: "Heat Advisory"}},
+	}
+	testCases := map[string]struct {
+		provider   weather.Provider
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		"healthz returns ok": {
+			provider:   provider,
+			target:     "/healthz",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		"current returns the observation": {
+			provider:   provider,
+			target:     "/v1/current?location=London&units=imperial",
+			wantStatus: http.StatusOK,
+			wantBody: `{"time":"0001-01-01T00:00:00Z","summary":"few clouds","temp":52.72,"feels_like":0,` +
+				`"humidity":47,"pressure":0,"wind_speed":0,"wind_gust":0,"wind_deg":0,"clouds":0,"visibility":0,"precip":0}`,
+		},
+		"forecast returns the daily forecasts": {
+			provider:   provider,
+			target:     "/v1/forecast?location=London",
+			wantStatus: http.StatusOK,
+			wantBody: `[{"date":"2021-05-18T00:00:00Z","summary":"","low":40,"high":60,"humidity":0,` +
+				`"precip_prob":0,"precip":0}]`,
+		},
+		"geocode returns the location": {
+			provider:   provider,
+			target:     "/v1/geocode?location=London",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"name":"London","country":"GB","lat":51.5085,"lon":-0.1257}`,
+		},
+		"alerts returns the alerts": {
+			provider:   provider,
+			target:     "/v1/alerts?location=London",
+			wantStatus: http.StatusOK,
+			wantBody: `[{"id":"1","event":"Heat Advisory","headline":"","description":"","sender":"",` +
+				`"start":"0001-01-01T00:00:00Z","end":"0001-01-01T00:00:00Z"}]`,
+		},
+		"alerts from a provider without alerts is not implemented": {
+			provider:   provider.fakeProvider,
+			target:     "/v1/alerts?location=London",
+			wantStatus: http.StatusNotImplemented,
+		},
+		"missing location is a bad request": {
+			provider:   provider,
+			target:     "/v1/current",
+			wantStatus: http.StatusBadRequest,
+		},
+		"invalid units is a bad request": {
+			provider:   provider,
+			target:     "/v1/current?location=London&units=martian",
+			wantStatus: http.StatusBadRequest,
+		},
+		"provider error is a bad gateway hiding the error": {
+			provider:   fakeProvider{err: errors.New("error getting data from https://api.example.com?appid=SECRETKEY")},
+			target:     "/v1/current?location=London",
+			wantStatus: http.StatusBadGateway,
+			wantBody:   `{"error":"upstream unavailable"}`,
+		},
+		"rate limited provider is too many requests": {
+			provider:   fakeProvider{err: weather.ErrRateLimited},
+			target:     "/v1/forecast?location=London",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		"POST is not allowed": {
+			provider:   provider,
+			method:     http.MethodPost,
+			target:     "/v1/current?location=London",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		"unknown route is not found": {
+			provider:   provider,
+			target:     "/v2/current",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			weather.NewServer(tc.provider).ServeHTTP(rec, httptest.NewRequest(method, tc.target, nil))
+
+			if tc.wantStatus != rec.Code {
+				t.Fatalf("want status %d, got %d with body %s", tc.wantStatus, rec.Code, rec.Body)
+			}
+			if tc.wantBody == "" {
+				return
+			}
+			var want, got interface{}
+			if err := json.Unmarshal([]byte(tc.wantBody), &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("got invalid JSON response %s: %v", rec.Body, err)
+			}
+			if !cmp.Equal(want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestServerFeed(t *testing.T) {
+	t.Parallel()
+	provider := alertingProvider{
+		fakeProvider: fakeProvider{
+			obs: weather.Observation{
+				Time:    time.Date(2021, 5, 18, 12, 0, 0, 0, time.UTC),
+				Summary: "few clouds",
+				Temp:    52.72,
+				Units:   weather.Imperial,
+			},
+			forecasts: []weather.DayForecast{
+				{Date: time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC), Low: 40, High: 60, Units: weather.Imperial},
+			},
+		},
+		alerts: []weather.Alert{{ID: "1", Event: "Heat Advisory", Start: time.Date(2021, 5, 18, 15, 0, 0, 0, time.UTC)}},
+	}
+	testCases := map[string]struct {
+		provider        weather.Provider
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantIDs         []string
+	}{
+		"Atom feed has an entry per observation, forecast day and alert": {
+			provider:        provider,
+			target:          "/feed/tampa%2Cfl%2Cus.atom",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/atom+xml; charset=utf-8",
+			wantIDs: []string{
+				"tag:weather.aculclasure.github.com,2021:tampa-fl-us/alert/6b86b273ff34fce1",
+				"tag:weather.aculclasure.github.com,2021:tampa-fl-us/conditions/1621339200",
+				"tag:weather.aculclasure.github.com,2021:tampa-fl-us/forecast/2021-05-18",
+			},
+		},
+		"RSS feed is served": {
+			provider:        provider,
+			target:          "/feed/London.rss?units=metric",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/rss+xml; charset=utf-8",
+		},
+		"feed from a provider without alerts has no alert entries": {
+			provider:        provider.fakeProvider,
+			target:          "/feed/London.atom",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/atom+xml; charset=utf-8",
+			wantIDs: []string{
+				"tag:weather.aculclasure.github.com,2021:london/conditions/1621339200",
+				"tag:weather.aculclasure.github.com,2021:london/forecast/2021-05-18",
+			},
+		},
+		"unknown feed format is not found": {
+			provider:   provider,
+			target:     "/feed/London.json",
+			wantStatus: http.StatusNotFound,
+		},
+		"missing location is a bad request": {
+			provider:   provider,
+			target:     "/feed/.atom",
+			wantStatus: http.StatusBadRequest,
+		},
+		"invalid units is a bad request": {
+			provider:   provider,
+			target:     "/feed/London.atom?units=martian",
+			wantStatus: http.StatusBadRequest,
+		},
+		"provider error is a bad gateway": {
+			provider:   fakeProvider{err: errors.New("boom")},
+			target:     "/feed/London.atom",
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			weather.NewServer(tc.provider).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if tc.wantStatus != rec.Code {
+				t.Fatalf("want status %d, got %d with body %s", tc.wantStatus, rec.Code, rec.Body)
+			}
+			if tc.wantContentType == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); tc.wantContentType != got {
+				t.Fatalf("want content type %q, got %q", tc.wantContentType, got)
+			}
+			if tc.wantIDs == nil {
+				return
+			}
+			var feed struct {
+				Links []struct {
+					Href string `xml:"href,attr"`
+				} `xml:"link"`
+				IDs []string `xml:"entry>id"`
+			}
+			if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+				t.Fatalf("got invalid XML response %s: %v", rec.Body, err)
+			}
+			if !cmp.Equal(tc.wantIDs, feed.IDs) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.wantIDs, feed.IDs))
+			}
+			if len(feed.Links) != 1 || feed.Links[0].Href != "http://example.com"+tc.target {
+				t.Fatalf("want a self link to the feed, got %+v", feed.Links)
+			}
+		})
+	}
+}
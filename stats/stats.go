@@ -0,0 +1,254 @@
+// Package stats computes summary statistics, like daily, weekly and monthly
+// temperature ranges, degree days and precipitation totals, over a series of
+// weather observations.
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample represents a single observation in a series.
+type Sample struct {
+	Time time.Time
+	// Temp is the temperature in degrees Celsius.
+	Temp float64
+	// Precip is the precipitation rate in mm per hour.
+	Precip float64
+}
+
+// Period represents the length of time summarized by a Summary.
+type Period string
+
+// The periods samples can be summarized over. Weeks start on Monday.
+const (
+	Day   Period = "day"
+	Week  Period = "week"
+	Month Period = "month"
+)
+
+// ParsePeriod accepts the name of a Period ("day", "week" or "month") and
+// returns it. An error is returned if the name is unknown.
+func ParsePeriod(name string) (Period, error) {
+	switch p := Period(name); p {
+	case Day, Week, Month:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown period %q, must be one of: day, week, month", name)
+}
+
+// Start returns the start of the Period containing t, in t's location.
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch p {
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Options configures how samples are summarized.
+type Options struct {
+	Period Period
+	// Location is the time zone periods start and end in. UTC is used if
+	// it is nil.
+	Location *time.Location
+	// Base is the temperature in degrees Celsius that heating and cooling
+	// degree days are counted from.
+	Base float64
+	// Above and Below are the temperatures in degrees Celsius to count the
+	// hours above and below. They are ignored if nil.
+	Above, Below *float64
+	// MaxGap is the longest time a sample is taken to represent. Time
+	// between samples beyond it counts towards neither the hours above or
+	// below the thresholds nor the precipitation total.
+	MaxGap time.Duration
+}
+
+// DefaultOptions returns Options summarizing by day in UTC, with an 18 C
+// degree day base and samples representing up to an hour.
+func DefaultOptions() Options {
+	return Options{
+		Period:   Day,
+		Location: time.UTC,
+		Base:     18,
+		MaxGap:   time.Hour,
+	}
+}
+
+// Summary represents the statistics for the samples in one period.
+type Summary struct {
+	Start   time.Time `json:"start"`
+	Period  Period    `json:"period"`
+	Samples int       `json:"samples"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Mean    float64   `json:"mean"`
+	// HeatingDegreeDays and CoolingDegreeDays are the sums, over each day
+	// of the period, of how far that day's mean temperature was below or
+	// above the base temperature.
+	HeatingDegreeDays float64 `json:"heating_degree_days"`
+	CoolingDegreeDays float64 `json:"cooling_degree_days"`
+	// Precip is the total precipitation in mm.
+	Precip     float64  `json:"precip"`
+	HoursAbove *float64 `json:"hours_above,omitempty"`
+	HoursBelow *float64 `json:"hours_below,omitempty"`
+	// MeanRank and PrecipRank are the percentile ranks of the period's mean
+	// temperature and total precipitation among all the summarized periods.
+	MeanRank   float64 `json:"mean_rank"`
+	PrecipRank float64 `json:"precip_rank"`
+}
+
+// ErrNoSamples is returned when there are no samples to summarize.
+var ErrNoSamples = errors.New("no samples to summarize")
+
+// Summarize accepts a series of samples and Options and returns a Summary
+// for each period containing samples, in time order. An error is returned if
+// there are no samples or if the Options are invalid.
+func Summarize(samples []Sample, opts Options) ([]Summary, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+	if _, err := ParsePeriod(string(opts.Period)); err != nil {
+		return nil, err
+	}
+	if opts.MaxGap <= 0 {
+		return nil, errors.New("max gap must be positive")
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	weights := sampleHours(sorted, opts.MaxGap)
+
+	var summaries []Summary
+	var days map[time.Time][]float64
+	var dayOrder []time.Time
+	var sum float64
+	finish := func() {
+		s := &summaries[len(summaries)-1]
+		s.Mean = sum / float64(s.Samples)
+		for _, day := range dayOrder {
+			mean := Mean(days[day])
+			s.HeatingDegreeDays += math.Max(0, opts.Base-mean)
+			s.CoolingDegreeDays += math.Max(0, mean-opts.Base)
+		}
+	}
+	for i, smp := range sorted {
+		t := smp.Time.In(loc)
+		start := opts.Period.Start(t)
+		if len(summaries) == 0 || !summaries[len(summaries)-1].Start.Equal(start) {
+			if len(summaries) > 0 {
+				finish()
+			}
+			summaries = append(summaries, Summary{
+				Start:  start,
+				Period: opts.Period,
+				Min:    smp.Temp,
+				Max:    smp.Temp,
+			})
+			days = map[time.Time][]float64{}
+			dayOrder = nil
+			sum = 0
+			if opts.Above != nil {
+				summaries[len(summaries)-1].HoursAbove = new(float64)
+			}
+			if opts.Below != nil {
+				summaries[len(summaries)-1].HoursBelow = new(float64)
+			}
+		}
+		s := &summaries[len(summaries)-1]
+		s.Samples++
+		s.Min = math.Min(s.Min, smp.Temp)
+		s.Max = math.Max(s.Max, smp.Temp)
+		sum += smp.Temp
+		day := Day.Start(t)
+		if _, ok := days[day]; !ok {
+			dayOrder = append(dayOrder, day)
+		}
+		days[day] = append(days[day], smp.Temp)
+		s.Precip += smp.Precip * weights[i]
+		if opts.Above != nil && smp.Temp > *opts.Above {
+			*s.HoursAbove += weights[i]
+		}
+		if opts.Below != nil && smp.Temp < *opts.Below {
+			*s.HoursBelow += weights[i]
+		}
+	}
+	finish()
+
+	means := make([]float64, len(summaries))
+	precips := make([]float64, len(summaries))
+	for i, s := range summaries {
+		means[i], precips[i] = s.Mean, s.Precip
+	}
+	for i := range summaries {
+		summaries[i].MeanRank = PercentileRank(means, summaries[i].Mean)
+		summaries[i].PrecipRank = PercentileRank(precips, summaries[i].Precip)
+	}
+	return summaries, nil
+}
+
+// sampleHours returns the number of hours each of the sorted samples
+// represents: the time until the next sample, or for the last sample the
+// time since the one before, capped at maxGap.
+func sampleHours(sorted []Sample, maxGap time.Duration) []float64 {
+	hours := make([]float64, len(sorted))
+	for i := range sorted {
+		var gap time.Duration
+		switch {
+		case i+1 < len(sorted):
+			gap = sorted[i+1].Time.Sub(sorted[i].Time)
+		case i > 0:
+			gap = sorted[i].Time.Sub(sorted[i-1].Time)
+		default:
+			gap = maxGap
+		}
+		if gap > maxGap {
+			gap = maxGap
+		}
+		hours[i] = gap.Hours()
+	}
+	return hours
+}
+
+// Mean returns the arithmetic mean of values, or 0 if there are none.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// PercentileRank returns the percentage, from 0 to 100, of values that are
+// less than v, counting values equal to v as half. It returns 0 if there are
+// no values.
+func PercentileRank(values []float64, v float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var below, equal int
+	for _, x := range values {
+		switch {
+		case x < v:
+			below++
+		case x == v:
+			equal++
+		}
+	}
+	return 100 * (float64(below) + 0.5*float64(equal)) / float64(len(values))
+}
@@ -0,0 +1,177 @@
+package stats_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather/stats"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func float(v float64) *float64 { return &v }
+
+// testSamples returns hourly samples over part of two days, given out of
+// order.
+func testSamples() []stats.Sample {
+	at := func(day, hour int) time.Time { return time.Date(2021, 5, day, hour, 0, 0, 0, time.UTC) }
+	return []stats.Sample{
+		{Time: at(18, 1), Temp: 22, Precip: 1},
+		{Time: at(17, 0), Temp: 10},
+		{Time: at(17, 1), Temp: 12, Precip: 2},
+		{Time: at(17, 2), Temp: 14},
+		{Time: at(17, 3), Temp: 16},
+		{Time: at(18, 0), Temp: 20, Precip: 1},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		period stats.Period
+		want   []stats.Summary
+	}{
+		"daily": {
+			period: stats.Day,
+			want: []stats.Summary{
+				{
+					Start: time.Date(2021, 5, 17, 0, 0, 0, 0, time.UTC), Period: stats.Day, Samples: 4,
+					Min: 10, Max: 16, Mean: 13, HeatingDegreeDays: 5, Precip: 2,
+					HoursAbove: float(1), HoursBelow: float(1), MeanRank: 25, PrecipRank: 50,
+				},
+				{
+					Start: time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC), Period: stats.Day, Samples: 2,
+					Min: 20, Max: 22, Mean: 21, CoolingDegreeDays: 3, Precip: 2,
+					HoursAbove: float(2), HoursBelow: float(0), MeanRank: 75, PrecipRank: 50,
+				},
+			},
+		},
+		"weekly": {
+			period: stats.Week,
+			want: []stats.Summary{{
+				Start: time.Date(2021, 5, 17, 0, 0, 0, 0, time.UTC), Period: stats.Week, Samples: 6,
+				Min: 10, Max: 22, Mean: 94.0 / 6, HeatingDegreeDays: 5, CoolingDegreeDays: 3, Precip: 4,
+				HoursAbove: float(3), HoursBelow: float(1), MeanRank: 50, PrecipRank: 50,
+			}},
+		},
+		"monthly": {
+			period: stats.Month,
+			want: []stats.Summary{{
+				Start: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), Period: stats.Month, Samples: 6,
+				Min: 10, Max: 22, Mean: 94.0 / 6, HeatingDegreeDays: 5, CoolingDegreeDays: 3, Precip: 4,
+				HoursAbove: float(3), HoursBelow: float(1), MeanRank: 50, PrecipRank: 50,
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := stats.DefaultOptions()
+			opts.Period = tc.period
+			opts.Above, opts.Below = float(15), float(11)
+			got, err := stats.Summarize(testSamples(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tc.want, got, cmpopts.EquateApprox(0, 1e-9)) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)))
+			}
+		})
+	}
+}
+
+func TestSummarizeUsesTimeZone(t *testing.T) {
+	t.Parallel()
+	opts := stats.DefaultOptions()
+	opts.Location = time.FixedZone("UTC-5", -5*60*60)
+	got, err := stats.Summarize(testSamples(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Shifted back 5 hours, every sample falls on May 16 or 17 local time.
+	var starts []string
+	for _, s := range got {
+		starts = append(starts, s.Start.Format("2006-01-02"))
+	}
+	if want := []string{"2021-05-16", "2021-05-17"}; !cmp.Equal(want, starts) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, starts))
+	}
+}
+
+func TestSummarizeCapsGaps(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2021, 5, 17, 0, 0, 0, 0, time.UTC)
+	samples := []stats.Sample{
+		{Time: start, Temp: 20, Precip: 2},
+		{Time: start.Add(6 * time.Hour), Temp: 20, Precip: 0},
+	}
+	opts := stats.DefaultOptions()
+	opts.Above = float(15)
+	got, err := stats.Summarize(samples, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Precip != 2 || *got[0].HoursAbove != 2 {
+		t.Fatalf("want a 6 hour gap counted as 1 hour per sample, got precip %v and %v hours above", got[0].Precip, *got[0].HoursAbove)
+	}
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := stats.Summarize(nil, stats.DefaultOptions()); !errors.Is(err, stats.ErrNoSamples) {
+		t.Fatalf("want ErrNoSamples, got %v", err)
+	}
+	opts := stats.DefaultOptions()
+	opts.Period = "year"
+	if _, err := stats.Summarize(testSamples(), opts); err == nil {
+		t.Fatal("want error for unknown period")
+	}
+	opts = stats.DefaultOptions()
+	opts.MaxGap = 0
+	if _, err := stats.Summarize(testSamples(), opts); err == nil {
+		t.Fatal("want error for zero max gap")
+	}
+}
+
+func TestPercentileRank(t *testing.T) {
+	t.Parallel()
+	values := []float64{1, 2, 2, 3, 4}
+	testCases := map[string]struct {
+		v    float64
+		want float64
+	}{
+		"below all":   {v: 0, want: 0},
+		"smallest":    {v: 1, want: 10},
+		"tied values": {v: 2, want: 40},
+		"above all":   {v: 5, want: 100},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := stats.PercentileRank(values, tc.v); tc.want != got {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+	if got := stats.PercentileRank(nil, 1); got != 0 {
+		t.Fatalf("want 0 for no values, got %v", got)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	t.Parallel()
+	sunday := time.Date(2021, 5, 23, 18, 30, 0, 0, time.UTC)
+	testCases := map[stats.Period]time.Time{
+		stats.Day:   time.Date(2021, 5, 23, 0, 0, 0, 0, time.UTC),
+		stats.Week:  time.Date(2021, 5, 17, 0, 0, 0, 0, time.UTC),
+		stats.Month: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for p, want := range testCases {
+		if got := p.Start(sunday); !want.Equal(got) {
+			t.Fatalf("%s: want %v, got %v", p, want, got)
+		}
+	}
+}
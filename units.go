@@ -0,0 +1,451 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidUnits is returned when measurement units other than those of
+// the supported systems are given.
+var ErrInvalidUnits = errors.New("units must be one of: standard, metric, imperial")
+
+// Units represents a system of measurement units weather data can be
+// requested and reported in.
+type Units string
+
+// The systems of measurement units supported by providers. Whatever the
+// system, pressures are reported in hPa, visibilities in meters and
+// precipitation in mm.
+const (
+	// Standard reports temperatures in Kelvin and speeds in meters per
+	// second.
+	Standard Units = "standard"
+	// Metric reports temperatures in Celsius and speeds in meters per
+	// second.
+	Metric Units = "metric"
+	// Imperial reports temperatures in Fahrenheit and speeds in miles per
+	// hour.
+	Imperial Units = "imperial"
+)
+
+// ParseUnits accepts the name of a system of measurement units ("standard",
+// "metric" or "imperial"), in any case, and returns it. An error is returned
+// if the name is unknown.
+func ParseUnits(name string) (Units, error) {
+	u := Units(strings.ToLower(strings.TrimSpace(name)))
+	if !u.Valid() {
+		return "", ErrInvalidUnits
+	}
+	return u, nil
+}
+
+// Valid returns true if u is one of the supported systems of measurement
+// units, in any case.
+func (u Units) Valid() bool {
+	u = u.normal()
+	return u == Standard || u == Metric || u == Imperial
+}
+
+// normal returns u in lower case, so that Units spelled in any case (e.g.
+// "Metric") behave like the constants.
+func (u Units) normal() Units {
+	return Units(strings.ToLower(string(u)))
+}
+
+// Temperature returns the unit temperatures are reported in when requested
+// in u.
+func (u Units) Temperature() TemperatureUnit {
+	switch u.normal() {
+	case Standard:
+		return Kelvin
+	case Imperial:
+		return Fahrenheit
+	}
+	return Celsius
+}
+
+// Speed returns the unit speeds are reported in when requested in u.
+func (u Units) Speed() SpeedUnit {
+	if u.normal() == Imperial {
+		return MilesPerHour
+	}
+	return MetersPerSecond
+}
+
+// Preferences returns the units values are conventionally shown in for u,
+// which, unlike the units data is reported in, include imperial pressures,
+// distances and precipitation.
+func (u Units) Preferences() Preferences {
+	p := Preferences{
+		Temperature:   u.Temperature(),
+		Speed:         u.Speed(),
+		Pressure:      Hectopascals,
+		Distance:      Kilometers,
+		Precipitation: Millimeters,
+	}
+	switch u.normal() {
+	case Standard:
+		p.Distance = Meters
+	case Imperial:
+		p.Pressure = InchesOfMercury
+		p.Distance = Miles
+		p.Precipitation = Inches
+	}
+	return p
+}
+
+// TemperatureUnit represents a unit of temperature.
+type TemperatureUnit string
+
+// The supported units of temperature.
+const (
+	Kelvin     TemperatureUnit = "K"
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+)
+
+// Temperature represents a temperature in a particular unit.
+type Temperature struct {
+	Value float64
+	Unit  TemperatureUnit
+}
+
+// In returns t converted to unit u. A Temperature in an unknown unit is
+// returned unchanged.
+func (t Temperature) In(u TemperatureUnit) Temperature {
+	var c float64
+	switch t.Unit {
+	case Kelvin:
+		c = t.Value - 273.15
+	case Celsius:
+		c = t.Value
+	case Fahrenheit:
+		c = (t.Value - 32) * 5 / 9
+	default:
+		return t
+	}
+	switch u {
+	case Kelvin:
+		return Temperature{c + 273.15, u}
+	case Celsius:
+		return Temperature{c, u}
+	case Fahrenheit:
+		return Temperature{c*9/5 + 32, u}
+	}
+	return t
+}
+
+// String returns t to two decimal places followed by its unit (e.g. "52.72
+// F").
+func (t Temperature) String() string {
+	return fmt.Sprintf("%.2f %s", t.Value, t.Unit)
+}
+
+// SpeedUnit represents a unit of speed.
+type SpeedUnit string
+
+// The supported units of speed.
+const (
+	MetersPerSecond   SpeedUnit = "m/s"
+	KilometersPerHour SpeedUnit = "km/h"
+	MilesPerHour      SpeedUnit = "mph"
+	Knots             SpeedUnit = "kn"
+)
+
+// metersPerSecond is the number of meters per second in each SpeedUnit.
+var metersPerSecond = map[SpeedUnit]float64{
+	MetersPerSecond:   1,
+	KilometersPerHour: 1000.0 / 3600,
+	MilesPerHour:      0.44704,
+	Knots:             1852.0 / 3600,
+}
+
+// Speed represents a speed in a particular unit.
+type Speed struct {
+	Value float64
+	Unit  SpeedUnit
+}
+
+// In returns s converted to unit u. A Speed in an unknown unit is returned
+// unchanged.
+func (s Speed) In(u SpeedUnit) Speed {
+	from, ok := metersPerSecond[s.Unit]
+	to, ok2 := metersPerSecond[u]
+	if !ok || !ok2 {
+		return s
+	}
+	return Speed{s.Value * from / to, u}
+}
+
+// String returns s to two decimal places followed by its unit (e.g. "3.60
+// m/s").
+func (s Speed) String() string {
+	return fmt.Sprintf("%.2f %s", s.Value, s.Unit)
+}
+
+// PressureUnit represents a unit of pressure.
+type PressureUnit string
+
+// The supported units of pressure.
+const (
+	Hectopascals    PressureUnit = "hPa"
+	InchesOfMercury PressureUnit = "inHg"
+)
+
+// hectopascals is the number of hPa in each PressureUnit.
+var hectopascals = map[PressureUnit]float64{
+	Hectopascals:    1,
+	InchesOfMercury: 33.8639,
+}
+
+// Pressure represents an atmospheric pressure in a particular unit.
+type Pressure struct {
+	Value float64
+	Unit  PressureUnit
+}
+
+// In returns p converted to unit u. A Pressure in an unknown unit is
+// returned unchanged.
+func (p Pressure) In(u PressureUnit) Pressure {
+	from, ok := hectopascals[p.Unit]
+	to, ok2 := hectopascals[u]
+	if !ok || !ok2 {
+		return p
+	}
+	return Pressure{p.Value * from / to, u}
+}
+
+// String returns p to two decimal places followed by its unit (e.g.
+// "1013.00 hPa").
+func (p Pressure) String() string {
+	return fmt.Sprintf("%.2f %s", p.Value, p.Unit)
+}
+
+// DistanceUnit represents a unit of distance.
+type DistanceUnit string
+
+// The supported units of distance.
+const (
+	Meters     DistanceUnit = "m"
+	Kilometers DistanceUnit = "km"
+	Miles      DistanceUnit = "mi"
+)
+
+// meters is the number of meters in each DistanceUnit.
+var meters = map[DistanceUnit]float64{
+	Meters:     1,
+	Kilometers: 1000,
+	Miles:      1609.344,
+}
+
+// Distance represents a distance in a particular unit.
+type Distance struct {
+	Value float64
+	Unit  DistanceUnit
+}
+
+// In returns d converted to unit u. A Distance in an unknown unit is
+// returned unchanged.
+func (d Distance) In(u DistanceUnit) Distance {
+	from, ok := meters[d.Unit]
+	to, ok2 := meters[u]
+	if !ok || !ok2 {
+		return d
+	}
+	return Distance{d.Value * from / to, u}
+}
+
+// String returns d to two decimal places followed by its unit (e.g. "10.00
+// km").
+func (d Distance) String() string {
+	return fmt.Sprintf("%.2f %s", d.Value, d.Unit)
+}
+
+// PrecipitationUnit represents a unit of precipitation depth.
+type PrecipitationUnit string
+
+// The supported units of precipitation depth.
+const (
+	Millimeters PrecipitationUnit = "mm"
+	Inches      PrecipitationUnit = "in"
+)
+
+// millimeters is the number of mm in each PrecipitationUnit.
+var millimeters = map[PrecipitationUnit]float64{
+	Millimeters: 1,
+	Inches:      25.4,
+}
+
+// Precipitation represents a depth of precipitation in a particular unit.
+type Precipitation struct {
+	Value float64
+	Unit  PrecipitationUnit
+}
+
+// In returns p converted to unit u. A Precipitation in an unknown unit is
+// returned unchanged.
+func (p Precipitation) In(u PrecipitationUnit) Precipitation {
+	from, ok := millimeters[p.Unit]
+	to, ok2 := millimeters[u]
+	if !ok || !ok2 {
+		return p
+	}
+	return Precipitation{p.Value * from / to, u}
+}
+
+// String returns p to two decimal places followed by its unit (e.g. "0.50
+// mm").
+func (p Precipitation) String() string {
+	return fmt.Sprintf("%.2f %s", p.Value, p.Unit)
+}
+
+// Preferences represents the units each kind of quantity is shown in,
+// allowing systems to be mixed, like temperatures in Celsius with speeds in
+// miles per hour.
+type Preferences struct {
+	Temperature   TemperatureUnit
+	Speed         SpeedUnit
+	Pressure      PressureUnit
+	Distance      DistanceUnit
+	Precipitation PrecipitationUnit
+}
+
+// ParsePreferences accepts a comma-separated list starting with the name of
+// a system of measurement units and followed by any units to show in place
+// of the system's own (e.g. "metric", "metric,mph" or "imperial,C,hPa"), and
+// returns the system, which data should be requested in, and the
+// Preferences. Unit symbols are matched in any case. An error is returned if
+// the system or any of the units are unknown.
+func ParsePreferences(s string) (Units, Preferences, error) {
+	parts := strings.Split(s, ",")
+	u, err := ParseUnits(parts[0])
+	if err != nil {
+		return "", Preferences{}, err
+	}
+	p := u.Preferences()
+	for _, part := range parts[1:] {
+		sym := strings.TrimSpace(part)
+		if !p.set(sym) {
+			return "", Preferences{}, fmt.Errorf("unknown unit %q, must be one of: K, C, F, m/s, km/h, mph, kn, hPa, inHg, m, km, mi, mm, in", sym)
+		}
+	}
+	return u, p, nil
+}
+
+// set sets the unit of whichever kind of quantity has the symbol sym,
+// matched in any case, and returns false if there is no such unit.
+func (p *Preferences) set(sym string) bool {
+	for _, u := range []TemperatureUnit{Kelvin, Celsius, Fahrenheit} {
+		if strings.EqualFold(sym, string(u)) {
+			p.Temperature = u
+			return true
+		}
+	}
+	for u := range metersPerSecond {
+		if strings.EqualFold(sym, string(u)) {
+			p.Speed = u
+			return true
+		}
+	}
+	for u := range hectopascals {
+		if strings.EqualFold(sym, string(u)) {
+			p.Pressure = u
+			return true
+		}
+	}
+	for u := range meters {
+		if strings.EqualFold(sym, string(u)) {
+			p.Distance = u
+			return true
+		}
+	}
+	for u := range millimeters {
+		if strings.EqualFold(sym, string(u)) {
+			p.Precipitation = u
+			return true
+		}
+	}
+	return false
+}
+
+// Quantities represents the measurements of an Observation as quantities
+// that know their units.
+type Quantities struct {
+	Temp       Temperature
+	FeelsLike  Temperature
+	WindSpeed  Speed
+	WindGust   Speed
+	Pressure   Pressure
+	Visibility Distance
+	// Precip is the precipitation over the last hour.
+	Precip Precipitation
+}
+
+// In returns q with each quantity converted to the unit given for its kind
+// in p.
+func (q Quantities) In(p Preferences) Quantities {
+	return Quantities{
+		Temp:       q.Temp.In(p.Temperature),
+		FeelsLike:  q.FeelsLike.In(p.Temperature),
+		WindSpeed:  q.WindSpeed.In(p.Speed),
+		WindGust:   q.WindGust.In(p.Speed),
+		Pressure:   q.Pressure.In(p.Pressure),
+		Visibility: q.Visibility.In(p.Distance),
+		Precip:     q.Precip.In(p.Precipitation),
+	}
+}
+
+// Quantities returns the measurements of o as quantities in the units o was
+// reported in.
+func (o Observation) Quantities() Quantities {
+	return Quantities{
+		Temp:       Temperature{o.Temp, o.Units.Temperature()},
+		FeelsLike:  Temperature{o.FeelsLike, o.Units.Temperature()},
+		WindSpeed:  Speed{o.WindSpeed, o.Units.Speed()},
+		WindGust:   Speed{o.WindGust, o.Units.Speed()},
+		Pressure:   Pressure{o.Pressure, Hectopascals},
+		Visibility: Distance{o.Visibility, Meters},
+		Precip:     Precipitation{o.Precip, Millimeters},
+	}
+}
+
+// In returns o with its temperatures and speeds converted to the
+// measurement units u. An Observation whose units are unknown is returned
+// unchanged.
+func (o Observation) In(u Units) Observation {
+	if o.Units == u || !o.Units.Valid() || !u.Valid() {
+		return o
+	}
+	o.Temp = Temperature{o.Temp, o.Units.Temperature()}.In(u.Temperature()).Value
+	o.FeelsLike = Temperature{o.FeelsLike, o.Units.Temperature()}.In(u.Temperature()).Value
+	o.WindSpeed = Speed{o.WindSpeed, o.Units.Speed()}.In(u.Speed()).Value
+	o.WindGust = Speed{o.WindGust, o.Units.Speed()}.In(u.Speed()).Value
+	o.Units = u
+	return o
+}
+
+// In returns f with its temperatures converted to the measurement units u.
+// A DayForecast whose units are unknown is returned unchanged.
+func (f DayForecast) In(u Units) DayForecast {
+	if f.Units == u || !f.Units.Valid() || !u.Valid() {
+		return f
+	}
+	f.Low = Temperature{f.Low, f.Units.Temperature()}.In(u.Temperature()).Value
+	f.High = Temperature{f.High, f.Units.Temperature()}.In(u.Temperature()).Value
+	f.Units = u
+	return f
+}
+
+// In returns f with its temperature and wind speed converted to the
+// measurement units u. An HourlyForecast whose units are unknown is returned
+// unchanged.
+func (f HourlyForecast) In(u Units) HourlyForecast {
+	if f.Units == u || !f.Units.Valid() || !u.Valid() {
+		return f
+	}
+	f.Temp = Temperature{f.Temp, f.Units.Temperature()}.In(u.Temperature()).Value
+	f.WindSpeed = Speed{f.WindSpeed, f.Units.Speed()}.In(u.Speed()).Value
+	f.Units = u
+	return f
+}
@@ -0,0 +1,302 @@
+package weather_test
+
+import (
+	"testing"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseUnits(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		name        string
+		want        weather.Units
+		errExpected bool
+	}{
+		"standard":               {name: "standard", want: weather.Standard},
+		"metric":                 {name: "metric", want: weather.Metric},
+		"imperial in any case":   {name: "Imperial", want: weather.Imperial},
+		"unknown system":         {name: "martian", errExpected: true},
+		"empty name":             {name: "", errExpected: true},
+		"unit instead of system": {name: "C", errExpected: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := weather.ParseUnits(tc.name)
+			if tc.errExpected {
+				if err == nil {
+					t.Fatalf("ParseUnits(%q) did not return an expected error", tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.want != got {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestUnitsInAnyCase(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		units weather.Units
+		want  weather.Units
+	}{
+		"capitalized metric":  {units: "Metric", want: weather.Metric},
+		"upper case imperial": {units: "IMPERIAL", want: weather.Imperial},
+		"mixed case standard": {units: "StanDard", want: weather.Standard},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if !tc.units.Valid() {
+				t.Fatalf("%q is not valid", tc.units)
+			}
+			want, got := tc.want.Preferences(), tc.units.Preferences()
+			if !cmp.Equal(want, got) {
+				t.Error(cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestTemperatureIn(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		from weather.Temperature
+		to   weather.TemperatureUnit
+		want float64
+	}{
+		"Kelvin to Celsius":     {from: weather.Temperature{Value: 273.15, Unit: weather.Kelvin}, to: weather.Celsius, want: 0},
+		"Celsius to Fahrenheit": {from: weather.Temperature{Value: 100, Unit: weather.Celsius}, to: weather.Fahrenheit, want: 212},
+		"Fahrenheit to Kelvin":  {from: weather.Temperature{Value: 32, Unit: weather.Fahrenheit}, to: weather.Kelvin, want: 273.15},
+		"same unit":             {from: weather.Temperature{Value: 21.5, Unit: weather.Celsius}, to: weather.Celsius, want: 21.5},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.from.In(tc.to)
+			if got.Unit != tc.to || !closeEnough(tc.want, got.Value) {
+				t.Fatalf("want %.2f %s, got %s", tc.want, tc.to, got)
+			}
+		})
+	}
+}
+
+func TestQuantityConversions(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		got  func() float64
+		want float64
+	}{
+		"m/s to mph": {got: func() float64 {
+			return weather.Speed{Value: 0.44704, Unit: weather.MetersPerSecond}.In(weather.MilesPerHour).Value
+		}, want: 1},
+		"km/h to m/s": {got: func() float64 {
+			return weather.Speed{Value: 36, Unit: weather.KilometersPerHour}.In(weather.MetersPerSecond).Value
+		}, want: 10},
+		"kn to km/h": {got: func() float64 {
+			return weather.Speed{Value: 1, Unit: weather.Knots}.In(weather.KilometersPerHour).Value
+		}, want: 1.852},
+		"hPa to inHg": {got: func() float64 {
+			return weather.Pressure{Value: 1013.25, Unit: weather.Hectopascals}.In(weather.InchesOfMercury).Value
+		}, want: 29.9212},
+		"m to mi": {got: func() float64 { return weather.Distance{Value: 1609.344, Unit: weather.Meters}.In(weather.Miles).Value }, want: 1},
+		"km to m": {got: func() float64 { return weather.Distance{Value: 10, Unit: weather.Kilometers}.In(weather.Meters).Value }, want: 10000},
+		"in to mm": {got: func() float64 {
+			return weather.Precipitation{Value: 1, Unit: weather.Inches}.In(weather.Millimeters).Value
+		}, want: 25.4},
+		"unknown unit": {got: func() float64 {
+			return weather.Speed{Value: 3, Unit: "furlongs/fortnight"}.In(weather.MetersPerSecond).Value
+		}, want: 3},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.got(); !closeEnough(tc.want, got) {
+				t.Fatalf("want %.4f, got %.4f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	t.Parallel()
+	want := "52.72 F"
+	got := weather.Temperature{Value: 52.72, Unit: weather.Fahrenheit}.String()
+	if want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestParsePreferences(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		s           string
+		wantUnits   weather.Units
+		want        weather.Preferences
+		errExpected bool
+	}{
+		"system only": {
+			s:         "metric",
+			wantUnits: weather.Metric,
+			want: weather.Preferences{
+				Temperature:   weather.Celsius,
+				Speed:         weather.MetersPerSecond,
+				Pressure:      weather.Hectopascals,
+				Distance:      weather.Kilometers,
+				Precipitation: weather.Millimeters,
+			},
+		},
+		"Celsius with mph": {
+			s:         "metric,mph",
+			wantUnits: weather.Metric,
+			want: weather.Preferences{
+				Temperature:   weather.Celsius,
+				Speed:         weather.MilesPerHour,
+				Pressure:      weather.Hectopascals,
+				Distance:      weather.Kilometers,
+				Precipitation: weather.Millimeters,
+			},
+		},
+		"imperial with metric overrides in any case": {
+			s:         "imperial, c, HPA, mm",
+			wantUnits: weather.Imperial,
+			want: weather.Preferences{
+				Temperature:   weather.Celsius,
+				Speed:         weather.MilesPerHour,
+				Pressure:      weather.Hectopascals,
+				Distance:      weather.Miles,
+				Precipitation: weather.Millimeters,
+			},
+		},
+		"unknown system": {
+			s:           "mph,metric",
+			errExpected: true,
+		},
+		"unknown unit": {
+			s:           "metric,furlongs",
+			errExpected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			gotUnits, got, err := weather.ParsePreferences(tc.s)
+			if tc.errExpected {
+				if err == nil {
+					t.Fatalf("ParsePreferences(%q) did not return an expected error", tc.s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantUnits != gotUnits {
+				t.Fatalf("want units %q, got %q", tc.wantUnits, gotUnits)
+			}
+			if !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestObservationInConvertsTemperaturesAndSpeeds(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{
+		Summary:    "few clouds",
+		Temp:       284.15,
+		FeelsLike:  283.15,
+		Humidity:   47,
+		Pressure:   1009,
+		WindSpeed:  4.4704,
+		WindGust:   8.9408,
+		Visibility: 10000,
+		Units:      weather.Standard,
+	}
+	want := weather.Observation{
+		Summary:    "few clouds",
+		Temp:       51.8,
+		FeelsLike:  50,
+		Humidity:   47,
+		Pressure:   1009,
+		WindSpeed:  10,
+		WindGust:   20,
+		Visibility: 10000,
+		Units:      weather.Imperial,
+	}
+	got := obs.In(weather.Imperial)
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestObservationInWithUnknownUnitsIsUnchanged(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{Temp: 10, WindSpeed: 3}
+	got := obs.In(weather.Imperial)
+	if !cmp.Equal(obs, got) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(obs, got))
+	}
+}
+
+func TestForecastsIn(t *testing.T) {
+	t.Parallel()
+	day := weather.DayForecast{Low: 10, High: 20, Units: weather.Metric}.In(weather.Imperial)
+	wantDay := weather.DayForecast{Low: 50, High: 68, Units: weather.Imperial}
+	if !cmp.Equal(wantDay, day, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(wantDay, day))
+	}
+	hour := weather.HourlyForecast{Temp: 50, WindSpeed: 10, Units: weather.Imperial}.In(weather.Metric)
+	wantHour := weather.HourlyForecast{Temp: 10, WindSpeed: 4.4704, Units: weather.Metric}
+	if !cmp.Equal(wantHour, hour, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(wantHour, hour))
+	}
+}
+
+func TestQuantitiesInMixedPreferences(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{
+		Temp:       293.15,
+		FeelsLike:  293.15,
+		WindSpeed:  4.4704,
+		Pressure:   1013.25,
+		Visibility: 16093.44,
+		Precip:     25.4,
+		Units:      weather.Standard,
+	}
+	_, prefs, err := weather.ParsePreferences("metric,mph,inHg,mi,in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weather.Quantities{
+		Temp:       weather.Temperature{Value: 20, Unit: weather.Celsius},
+		FeelsLike:  weather.Temperature{Value: 20, Unit: weather.Celsius},
+		WindSpeed:  weather.Speed{Value: 10, Unit: weather.MilesPerHour},
+		WindGust:   weather.Speed{Value: 0, Unit: weather.MilesPerHour},
+		Pressure:   weather.Pressure{Value: 29.9212, Unit: weather.InchesOfMercury},
+		Visibility: weather.Distance{Value: 10, Unit: weather.Miles},
+		Precip:     weather.Precipitation{Value: 1, Unit: weather.Inches},
+	}
+	got := obs.Quantities().In(prefs)
+	if !cmp.Equal(want, got, cmpopts.EquateApprox(0, 0.001)) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got))
+	}
+}
+
+func TestFormatConditionsUsesPreferredTemperatureUnit(t *testing.T) {
+	t.Parallel()
+	obs := weather.Observation{Summary: "few clouds", Temp: 284.65, Humidity: 47, Units: weather.Standard}
+	want := "few clouds, 11.50 C, humidity 47%"
+	got := weather.FormatConditions(obs, weather.Metric.Preferences())
+	if want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,177 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// ANSI escape sequences used to redraw and highlight the watched conditions
+// on a terminal.
+const (
+	ansiClearLine = "\r\x1b[2K"
+	ansiHighlight = "\x1b[1;33m"
+	ansiReset     = "\x1b[0m"
+)
+
+// Watch represents a periodically refreshed view of the current weather
+// conditions for a location. On a terminal each reading redraws the previous
+// one in place with the values that changed highlighted, otherwise each
+// reading is appended as a timestamped line with the changes shown as
+// deltas.
+type Watch struct {
+	Provider Provider
+	Location string
+	// Units are the measurement units readings are requested in, and
+	// Preferences the units they are shown in.
+	Units       Units
+	Preferences Preferences
+	// Locale is the language and number format readings are shown in.
+	Locale Locale
+	Output io.Writer
+	TTY    bool
+
+	prev *Observation
+}
+
+// NewWatch accepts a Provider, a location (e.g. "london", "tampa,us", etc.)
+// and a measurement unit ("standard", "metric" or "imperial") and returns a
+// Watch showing readings in those units and writing to standard output,
+// redrawing in place if standard output is a terminal. An error is returned
+// if the location is empty or the units are invalid.
+func NewWatch(p Provider, location string, units Units) (*Watch, error) {
+	if location == "" {
+		return nil, errEmptyLocation
+	}
+	if !units.Valid() {
+		return nil, ErrInvalidUnits
+	}
+	return &Watch{
+		Provider:    p,
+		Location:    location,
+		Units:       units,
+		Preferences: units.Preferences(),
+		Output:      os.Stdout,
+		TTY:         isTerminal(os.Stdout),
+	}, nil
+}
+
+// Refresh requests the current observation for the Watch's location and
+// writes it, marking the values that changed since the previous reading. If
+// the request fails, the error is written in place of the reading and
+// returned, and the previous reading is kept for comparison.
+func (w *Watch) Refresh() error {
+	stamp := w.Locale.FormatTime(time.Now())
+
+	obs, err := w.Provider.CurrentObservation(w.Location, w.Units)
+	if err != nil {
+		msg := w.Locale.Sprintf("%s error: %v", stamp, err)
+		if errors.Is(err, ErrRateLimited) {
+			msg = w.Locale.Sprintf("%s rate limited, keeping the previous reading", stamp)
+		}
+		w.write(msg)
+		return err
+	}
+	w.write(stamp + " " + w.format(obs))
+	w.prev = &obs
+	return nil
+}
+
+// Run refreshes the Watch immediately and then every interval until stop is
+// closed. An error is returned only if the first refresh fails, since later
+// failures are written and retried on the next interval.
+func (w *Watch) Run(interval time.Duration, stop <-chan struct{}) error {
+	if err := w.Refresh(); err != nil {
+		if w.TTY {
+			fmt.Fprintln(w.Output)
+		}
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Refresh()
+		case <-stop:
+			if w.TTY {
+				fmt.Fprintln(w.Output)
+			}
+			return nil
+		}
+	}
+}
+
+// write writes a line of output, replacing the previous line on a terminal.
+func (w *Watch) write(line string) {
+	if w.TTY {
+		fmt.Fprint(w.Output, ansiClearLine+line)
+		return
+	}
+	fmt.Fprintln(w.Output, line)
+}
+
+// format returns a summary of an observation, marking the values that
+// changed since the previous reading.
+func (w *Watch) format(obs Observation) string {
+	q := w.quantities(obs)
+	summary := strings.TrimSpace(obs.Summary)
+	temp := w.Locale.quantity(q.Temp.Value, string(q.Temp.Unit))
+	humidity := w.Locale.Sprintf("humidity %d%%", obs.Humidity)
+	wind := w.Locale.Sprintf("wind %s", w.Locale.quantity(q.WindSpeed.Value, string(q.WindSpeed.Unit)))
+	if w.prev != nil {
+		prev := w.quantities(*w.prev)
+		summary = w.mark(summary, summary != strings.TrimSpace(w.prev.Summary), "")
+		temp = w.mark(temp, q.Temp != prev.Temp, w.delta(q.Temp.Value-prev.Temp.Value))
+		humidity = w.mark(humidity, obs.Humidity != w.prev.Humidity, fmt.Sprintf("%+d", obs.Humidity-w.prev.Humidity))
+		wind = w.mark(wind, q.WindSpeed != prev.WindSpeed, w.delta(q.WindSpeed.Value-prev.WindSpeed.Value))
+	}
+	return strings.Join([]string{summary, temp, humidity, wind}, ", ")
+}
+
+// quantities returns the measurements of obs in the Watch's Preferences.
+// Observations that do not report their units are taken to be in the units
+// they were requested in.
+func (w *Watch) quantities(obs Observation) Quantities {
+	if obs.Units == "" {
+		obs.Units = w.Units
+	}
+	return obs.Quantities().In(w.Preferences)
+}
+
+// mark returns s highlighted on a terminal, or followed by the change
+// otherwise, if changed is true. A summary with no change to show is
+// prefixed with an asterisk when not on a terminal.
+func (w *Watch) mark(s string, changed bool, change string) string {
+	switch {
+	case !changed:
+		return s
+	case w.TTY:
+		return ansiHighlight + s + ansiReset
+	case change == "":
+		return "*" + s
+	}
+	return fmt.Sprintf("%s (%s)", s, change)
+}
+
+// delta returns a signed difference rounded to two decimal places.
+func (w *Watch) delta(d float64) string {
+	s := w.Locale.FormatFloat(math.Round(d*100)/100, 2)
+	if !strings.HasPrefix(s, "-") {
+		s = "+" + s
+	}
+	return s
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
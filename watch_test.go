@@ -0,0 +1,181 @@
+package weather_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/google/go-cmp/cmp"
+)
+
+// sequenceProvider is a weather.Provider that returns its observations, or
+// errors, one after the other on each call to CurrentObservation.
+type sequenceProvider struct {
+	fakeProvider
+	obs  []weather.Observation
+	errs []error
+	i    int
+}
+
+func (s *sequenceProvider) CurrentObservation(location string, units weather.Units) (weather.Observation, error) {
+	i := s.i
+	s.i++
+	if i < len(s.errs) && s.errs[i] != nil {
+		return weather.Observation{}, s.errs[i]
+	}
+	return s.obs[i], nil
+}
+
+// stripTimestamps removes the leading timestamp from each line of output.
+func stripTimestamps(out string) []string {
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		lines = append(lines, l[len("15:04:05 "):])
+	}
+	return lines
+}
+
+func TestWatchRefresh(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		units weather.Units
+		obs   []weather.Observation
+		errs  []error
+		want  []string
+	}{
+		"unchanged readings are not marked": {
+			units: "imperial",
+			obs: []weather.Observation{
+				{Summary: "few clouds", Temp: 52.72, Humidity: 47, WindSpeed: 4.61},
+				{Summary: "few clouds", Temp: 52.72, Humidity: 47, WindSpeed: 4.61},
+			},
+			want: []string{
+				"few clouds, 52.72 F, humidity 47%, wind 4.61 mph",
+				"few clouds, 52.72 F, humidity 47%, wind 4.61 mph",
+			},
+		},
+		"changed readings are shown with their deltas": {
+			units: "metric",
+			obs: []weather.Observation{
+				{Summary: "few clouds", Temp: 11.51, Humidity: 47, WindSpeed: 2.06},
+				{Summary: "light rain", Temp: 10.3, Humidity: 61, WindSpeed: 2.06},
+			},
+			want: []string{
+				"few clouds, 11.51 C, humidity 47%, wind 2.06 m/s",
+				"*light rain, 10.30 C (-1.21), humidity 61% (+14), wind 2.06 m/s",
+			},
+		},
+		"errors are written and the previous reading is kept": {
+			units: "metric",
+			obs: []weather.Observation{
+				{Summary: "few clouds", Temp: 11.51, Humidity: 47},
+				{},
+				{Summary: "few clouds", Temp: 12.01, Humidity: 47},
+			},
+			errs: []error{nil, weather.ErrRateLimited},
+			want: []string{
+				"few clouds, 11.51 C, humidity 47%, wind 0.00 m/s",
+				"rate limited, keeping the previous reading",
+				"few clouds, 12.01 C (+0.50), humidity 47%, wind 0.00 m/s",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			w, err := weather.NewWatch(&sequenceProvider{obs: tc.obs, errs: tc.errs}, "London", tc.units)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			w.Output, w.TTY = &out, false
+			for range tc.obs {
+				w.Refresh()
+			}
+			got := stripTimestamps(out.String())
+			if !cmp.Equal(tc.want, got) {
+				t.Fatalf("want != got\ndiff=%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestWatchRedrawsAndHighlightsOnTerminal(t *testing.T) {
+	t.Parallel()
+	p := &sequenceProvider{obs: []weather.Observation{
+		{Summary: "few clouds", Temp: 52.72, Humidity: 47},
+		{Summary: "few clouds", Temp: 53.1, Humidity: 47},
+	}}
+	w, err := weather.NewWatch(p, "London", "imperial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w.Output, w.TTY = &out, true
+	w.Refresh()
+	w.Refresh()
+
+	got := out.String()
+	if strings.Contains(got, "\n") {
+		t.Fatalf("want readings redrawn in place without newlines, got %q", got)
+	}
+	if n := strings.Count(got, "\r\x1b[2K"); n != 2 {
+		t.Fatalf("want the line cleared before each of 2 readings, got %d clears in %q", n, got)
+	}
+	if !strings.Contains(got, "\x1b[1;33m53.10 F\x1b[0m") {
+		t.Fatalf("want changed temperature highlighted, got %q", got)
+	}
+	if strings.Contains(got, "\x1b[1;33mhumidity") {
+		t.Fatalf("want unchanged humidity not highlighted, got %q", got)
+	}
+}
+
+func TestWatchRunReturnsFirstError(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	w, err := weather.NewWatch(&sequenceProvider{errs: []error{boom}}, "London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Output = &bytes.Buffer{}
+	if err := w.Run(time.Millisecond, make(chan struct{})); !errors.Is(err, boom) {
+		t.Fatalf("want error %v, got %v", boom, err)
+	}
+}
+
+func TestWatchRunStops(t *testing.T) {
+	t.Parallel()
+	obs := make([]weather.Observation, 1000)
+	w, err := weather.NewWatch(&sequenceProvider{obs: obs}, "London", "metric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w.Output, w.TTY = &out, false
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- w.Run(time.Millisecond, stop) }()
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "\n"); n < 2 {
+		t.Fatalf("want at least 2 readings written, got %d", n)
+	}
+}
+
+func TestNewWatchInvalidArgs(t *testing.T) {
+	t.Parallel()
+	if _, err := weather.NewWatch(fakeProvider{}, "", "metric"); err == nil {
+		t.Fatal("want error for empty location")
+	}
+	if _, err := weather.NewWatch(fakeProvider{}, "London", "kelvin"); err == nil {
+		t.Fatal("want error for invalid units")
+	}
+}
@@ -2,17 +2,6 @@
 // the OpenWeatherMap API.
 package weather
 
-import (
-	"fmt"
-	"strings"
-)
-
-var temperatureInitials = map[string]string{
-	"standard": "K",
-	"metric":   "C",
-	"imperial": "F",
-}
-
 // Conditions accepts a location (e.g. "london", "tampa,us", etc.), a
 // measurement unit for describing weather metrics (e.g. "metric",
 // "standard", "imperial"), and an OpenWeatherMap API key, makes a
@@ -21,26 +10,29 @@ var temperatureInitials = map[string]string{
 // error is returned if the Client struct cannot be created, if
 // the request to the OpenWeatherMap current weather API fails, or
 // if the API response cannot be decoded properly.
-func Conditions(location, units, apiKey string) (string, error) {
+func Conditions(location string, units Units, apiKey string) (string, error) {
 	client, err := NewClient(apiKey)
 	if err != nil {
 		return "", err
 	}
-	data, err := client.Current(location, units)
+	return CurrentConditions(client, location, units)
+}
+
+// Rain accepts a location (e.g. "london", "tampa,fl,us", etc.) and an
+// OpenWeatherMap API key, looks up the coordinates of the location, requests
+// the minutely precipitation forecast for those coordinates from the One Call
+// API and returns a string describing when rain is expected to start and stop
+// over the next hour. An error is returned if the Client struct cannot be
+// created, if either API request fails, or if an API response cannot be
+// decoded properly.
+func Rain(location, apiKey string) (string, error) {
+	client, err := NewClient(apiKey)
 	if err != nil {
 		return "", err
 	}
-	resp, err := DecodeCurrent(data)
+	n, err := client.Nowcast(location)
 	if err != nil {
 		return "", err
 	}
-	desc := ""
-	if len(resp.Summaries) > 0 {
-		desc = resp.Summaries[0].Desc + " "
-	}
-	ti := temperatureInitials[units]
-	return fmt.Sprintf("%s, %.2f %s, humidity %d%%",
-		strings.TrimSpace(desc),
-		resp.Metrics.Temp, ti,
-		resp.Metrics.Humidity), nil
+	return n.String(), nil
 }
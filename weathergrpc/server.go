@@ -0,0 +1,276 @@
+// Package weathergrpc implements the gRPC WeatherService defined in package
+// weatherpb on top of a weather.Provider, such as weather.Client.
+package weathergrpc
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/aculclasure/weather/weatherpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server represents a WeatherService serving data from a weather.Provider.
+type Server struct {
+	weatherpb.UnimplementedWeatherServiceServer
+
+	Provider weather.Provider
+	// Units are used for requests that do not specify any.
+	Units weather.Units
+	// MinInterval is the shortest interval StreamUpdates sends updates on,
+	// to protect the provider's API quota.
+	MinInterval time.Duration
+
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+// NewServer accepts a weather.Provider and returns a Server serving its data
+// with "imperial" as the default units and a minimum streaming interval of
+// one minute.
+func NewServer(p weather.Provider) *Server {
+	return &Server{
+		Provider:    p,
+		Units:       weather.Imperial,
+		MinInterval: time.Minute,
+	}
+}
+
+// GetCurrent returns the current weather conditions for the requested
+// location.
+func (s *Server) GetCurrent(ctx context.Context, req *weatherpb.GetCurrentRequest) (*weatherpb.Observation, error) {
+	if req.GetLocation() == "" {
+		return nil, status.Error(codes.InvalidArgument, "location must not be empty")
+	}
+	obs, err := s.Provider.CurrentObservation(req.GetLocation(), s.units(req.GetUnits()))
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return observationToProto(obs), nil
+}
+
+// GetForecast returns the daily forecasts for the requested location.
+func (s *Server) GetForecast(ctx context.Context, req *weatherpb.GetForecastRequest) (*weatherpb.GetForecastResponse, error) {
+	if req.GetLocation() == "" {
+		return nil, status.Error(codes.InvalidArgument, "location must not be empty")
+	}
+	forecasts, err := s.Provider.DailyForecast(req.GetLocation(), s.units(req.GetUnits()))
+	if err != nil {
+		return nil, providerError(err)
+	}
+
+	resp := &weatherpb.GetForecastResponse{}
+	for _, f := range forecasts {
+		resp.Days = append(resp.Days, &weatherpb.DayForecast{
+			Date:       timestamppb.New(f.Date),
+			Summary:    f.Summary,
+			Low:        f.Low,
+			High:       f.High,
+			Humidity:   int32(f.Humidity),
+			PrecipProb: f.PrecipProb,
+			Precip:     f.Precip,
+		})
+	}
+	return resp, nil
+}
+
+// Geocode returns the geographical data for the requested location.
+func (s *Server) Geocode(ctx context.Context, req *weatherpb.GeocodeRequest) (*weatherpb.Location, error) {
+	if req.GetLocation() == "" {
+		return nil, status.Error(codes.InvalidArgument, "location must not be empty")
+	}
+	loc, err := s.Provider.Geocode(req.GetLocation())
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return &weatherpb.Location{
+		Name:    loc.Name,
+		Country: loc.Country,
+		Lat:     loc.Lat,
+		Lon:     loc.Lon,
+	}, nil
+}
+
+// StreamUpdates sends the current weather conditions for the requested
+// location immediately and then on every requested interval, until the
+// client cancels the call, the provider returns an error or the Server is
+// closed.
+func (s *Server) StreamUpdates(req *weatherpb.StreamUpdatesRequest, stream weatherpb.WeatherService_StreamUpdatesServer) error {
+	if req.GetLocation() == "" {
+		return status.Error(codes.InvalidArgument, "location must not be empty")
+	}
+	interval := req.GetInterval().AsDuration()
+	if interval < s.MinInterval {
+		interval = s.MinInterval
+	}
+	if interval <= 0 {
+		return status.Error(codes.InvalidArgument, "interval must be positive")
+	}
+
+	units := s.units(req.GetUnits())
+	done := s.closed()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		obs, err := s.Provider.CurrentObservation(req.GetLocation(), units)
+		if err != nil {
+			return providerError(err)
+		}
+		if err := stream.Send(observationToProto(obs)); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		case <-done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+// Close ends every StreamUpdates call in progress, and any started after
+// it, so that stopping the grpc.Server gracefully does not wait for clients
+// to cancel them.
+func (s *Server) Close() {
+	done := s.closed()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+}
+
+// closed returns the channel that is closed when the Server is closed.
+func (s *Server) closed() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	return s.done
+}
+
+// units returns the weather.Units for u, or the Server's default units if u
+// is unspecified.
+func (s *Server) units(u weatherpb.Units) weather.Units {
+	switch u {
+	case weatherpb.Units_UNITS_STANDARD:
+		return weather.Standard
+	case weatherpb.Units_UNITS_METRIC:
+		return weather.Metric
+	case weatherpb.Units_UNITS_IMPERIAL:
+		return weather.Imperial
+	}
+	return s.Units
+}
+
+// observationToProto converts a weather.Observation to its protocol buffer
+// representation.
+func observationToProto(obs weather.Observation) *weatherpb.Observation {
+	return &weatherpb.Observation{
+		Time:       timestamppb.New(obs.Time),
+		Summary:    obs.Summary,
+		Temp:       obs.Temp,
+		FeelsLike:  obs.FeelsLike,
+		Humidity:   int32(obs.Humidity),
+		Pressure:   obs.Pressure,
+		WindSpeed:  obs.WindSpeed,
+		WindGust:   obs.WindGust,
+		WindDeg:    int32(obs.WindDeg),
+		Clouds:     int32(obs.Clouds),
+		Visibility: obs.Visibility,
+		Precip:     obs.Precip,
+	}
+}
+
+// providerError converts an error returned by a weather.Provider into a gRPC
+// status error, using ResourceExhausted if the provider was rate limited and
+// Unavailable otherwise. Provider errors can contain request URLs, so the
+// details are logged rather than sent to the caller.
+func providerError(err error) error {
+	if errors.Is(err, weather.ErrRateLimited) {
+		return status.Error(codes.ResourceExhausted, weather.ErrRateLimited.Error())
+	}
+	log.Printf("provider error: %v", err)
+	return status.Error(codes.Unavailable, "upstream unavailable")
+}
+
+// ServeCLI accepts a slice of command line flags and arguments, creates a
+// Client with a shared cache and rate limit, and serves the WeatherService
+// backed by it over gRPC until the process is interrupted, at which point
+// streams of updates are ended and other in-flight calls are allowed to
+// finish before it returns. An error is
+// returned if the OPENWEATHER_API_KEY environment variable is not set, if
+// the command line flags are invalid, or if the server fails.
+func ServeCLI(args []string) error {
+	fs := flag.NewFlagSet("grpc", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("USAGE: weather grpc [-addr=:9000] [-cache-ttl=10m] [-rate=60]\n\n"))
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", ":9000", "the address to listen on")
+	ttl := fs.Duration("cache-ttl", 10*time.Minute, "how long to cache OpenWeather responses")
+	rate := fs.Int("rate", 60, "the maximum number of OpenWeather requests per minute")
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rate < 1 {
+		return errors.New("rate flag must be at least 1")
+	}
+
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		return errors.New("environment variable OPENWEATHER_API_KEY must be set")
+	}
+	client, err := weather.NewClient(apiKey)
+	if err != nil {
+		return err
+	}
+	client.Cache = weather.NewCache(*ttl)
+	client.Limiter = weather.NewRateLimiter(*rate, time.Minute)
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	s := NewServer(client)
+	weatherpb.RegisterWeatherServiceServer(srv, s)
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving weather gRPC API on %s", lis.Addr())
+		errs <- srv.Serve(lis)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	select {
+	case err := <-errs:
+		return err
+	case <-stop:
+	}
+	log.Print("shutting down")
+	s.Close()
+	srv.GracefulStop()
+	return nil
+}
@@ -0,0 +1,252 @@
+package weathergrpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aculclasure/weather"
+	"github.com/aculclasure/weather/weathergrpc"
+	"github.com/aculclasure/weather/weatherpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeProvider is a weather.Provider that returns canned data and records
+// the units it was last asked for.
+type fakeProvider struct {
+	obs       weather.Observation
+	forecasts []weather.DayForecast
+	loc       weather.Location
+	err       error
+	units     chan weather.Units
+}
+
+func (f fakeProvider) CurrentObservation(location string, units weather.Units) (weather.Observation, error) {
+	if f.units != nil {
+		f.units <- units
+	}
+	return f.obs, f.err
+}
+
+func (f fakeProvider) DailyForecast(location string, units weather.Units) ([]weather.DayForecast, error) {
+	return f.forecasts, f.err
+}
+
+func (f fakeProvider) Geocode(location string) (weather.Location, error) {
+	return f.loc, f.err
+}
+
+// newTestClient serves a weathergrpc.Server for p over an in-process
+// bufconn listener and returns a client connected to it.
+func newTestClient(t *testing.T, p weather.Provider) weatherpb.WeatherServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	s := weathergrpc.NewServer(p)
+	s.MinInterval = 10 * time.Millisecond
+	weatherpb.RegisterWeatherServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return weatherpb.NewWeatherServiceClient(dialTestServer(t, lis))
+}
+
+// dialTestServer returns a client connection to the server listening on
+// lis.
+func dialTestServer(t *testing.T, lis *bufconn.Listener) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("got error dialing bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGetCurrent(t *testing.T) {
+	t.Parallel()
+	units := make(chan weather.Units, 1)
+	obsTime := time.Date(2021, 5, 3, 15, 36, 37, 0, time.UTC)
+	client := newTestClient(t, fakeProvider{
+		obs:   weather.Observation{Time: obsTime, Summary: "few clouds", Temp: 11.51, Humidity: 47, WindDeg: 220},
+		units: units,
+	})
+
+	got, err := client.GetCurrent(context.Background(), &weatherpb.GetCurrentRequest{
+		Location: "London",
+		Units:    weatherpb.Units_UNITS_METRIC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := <-units; u != "metric" {
+		t.Fatalf("want provider to be asked for metric units, got %q", u)
+	}
+	want := &weatherpb.Observation{
+		Time:     timestamppb.New(obsTime),
+		Summary:  "few clouds",
+		Temp:     11.51,
+		Humidity: 47,
+		WindDeg:  220,
+	}
+	if !cmp.Equal(want, got, protocmp.Transform()) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got, protocmp.Transform()))
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	t.Parallel()
+	date := time.Date(2021, 5, 18, 0, 0, 0, 0, time.UTC)
+	client := newTestClient(t, fakeProvider{forecasts: []weather.DayForecast{
+		{Date: date, Summary: "very heavy rain", Low: 290.44, High: 298.72, Humidity: 72, PrecipProb: 1, Precip: 63.24},
+	}})
+
+	got, err := client.GetForecast(context.Background(), &weatherpb.GetForecastRequest{Location: "London"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &weatherpb.GetForecastResponse{Days: []*weatherpb.DayForecast{{
+		Date:       timestamppb.New(date),
+		Summary:    "very heavy rain",
+		Low:        290.44,
+		High:       298.72,
+		Humidity:   72,
+		PrecipProb: 1,
+		Precip:     63.24,
+	}}}
+	if !cmp.Equal(want, got, protocmp.Transform()) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got, protocmp.Transform()))
+	}
+}
+
+func TestGeocode(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(t, fakeProvider{
+		loc: weather.Location{Name: "London", Country: "GB", Lat: 51.5085, Lon: -0.1257},
+	})
+
+	got, err := client.Geocode(context.Background(), &weatherpb.GeocodeRequest{Location: "London"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &weatherpb.Location{Name: "London", Country: "GB", Lat: 51.5085, Lon: -0.1257}
+	if !cmp.Equal(want, got, protocmp.Transform()) {
+		t.Fatalf("want != got\ndiff=%s", cmp.Diff(want, got, protocmp.Transform()))
+	}
+}
+
+func TestErrorsAreMappedToStatusCodes(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		provider weather.Provider
+		location string
+		want     codes.Code
+	}{
+		"empty location is an invalid argument": {
+			provider: fakeProvider{},
+			want:     codes.InvalidArgument,
+		},
+		"rate limited provider is resource exhausted": {
+			provider: fakeProvider{err: weather.ErrRateLimited},
+			location: "London",
+			want:     codes.ResourceExhausted,
+		},
+		"failing provider is unavailable": {
+			provider: fakeProvider{err: errors.New("boom")},
+			location: "London",
+			want:     codes.Unavailable,
+		},
+		"provider error details are not returned": {
+			provider: fakeProvider{err: errors.New("error getting data from https://example.com/?appid=SECRET")},
+			location: "London",
+			want:     codes.Unavailable,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, tc.provider)
+			_, err := client.GetCurrent(context.Background(), &weatherpb.GetCurrentRequest{Location: tc.location})
+			if got := status.Code(err); tc.want != got {
+				t.Fatalf("want status code %s, got %s", tc.want, got)
+			}
+			if msg := status.Convert(err).Message(); strings.Contains(msg, "appid") {
+				t.Errorf("status message reveals request details: %q", msg)
+			}
+		})
+	}
+}
+
+func TestStreamUpdates(t *testing.T) {
+	t.Parallel()
+	client := newTestClient(t, fakeProvider{obs: weather.Observation{Summary: "few clouds"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamUpdates(ctx, &weatherpb.StreamUpdatesRequest{
+		Location: "London",
+		Interval: durationpb.New(time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		obs, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("got error receiving update %d: %v", i+1, err)
+		}
+		if obs.GetSummary() != "few clouds" {
+			t.Fatalf("want summary %q, got %q", "few clouds", obs.GetSummary())
+		}
+	}
+}
+
+func TestCloseEndsStreamsSoGracefulStopReturns(t *testing.T) {
+	t.Parallel()
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	s := weathergrpc.NewServer(fakeProvider{obs: weather.Observation{Summary: "few clouds"}})
+	weatherpb.RegisterWeatherServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	stream, err := weatherpb.NewWeatherServiceClient(dialTestServer(t, lis)).StreamUpdates(context.Background(), &weatherpb.StreamUpdatesRequest{
+		Location: "London",
+		Interval: durationpb.New(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Close()
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("GracefulStop did not return while a stream was open")
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("want Unavailable once the server is closed, got %v", err)
+	}
+}
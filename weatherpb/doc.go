@@ -0,0 +1,6 @@
+// Package weatherpb contains the protocol buffer definitions and generated
+// gRPC client and server code for the weather service defined in
+// weather.proto.
+package weatherpb
+
+//go:generate buf generate
@@ -0,0 +1,780 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: weather.proto
+
+package weatherpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Units selects the measurement units weather metrics are reported in.
+type Units int32
+
+const (
+	// UNITS_UNSPECIFIED uses the server's default units.
+	Units_UNITS_UNSPECIFIED Units = 0
+	// UNITS_STANDARD reports temperatures in Kelvin and speeds in m/s.
+	Units_UNITS_STANDARD Units = 1
+	// UNITS_METRIC reports temperatures in Celsius and speeds in m/s.
+	Units_UNITS_METRIC Units = 2
+	// UNITS_IMPERIAL reports temperatures in Fahrenheit and speeds in mph.
+	Units_UNITS_IMPERIAL Units = 3
+)
+
+// Enum value maps for Units.
+var (
+	Units_name = map[int32]string{
+		0: "UNITS_UNSPECIFIED",
+		1: "UNITS_STANDARD",
+		2: "UNITS_METRIC",
+		3: "UNITS_IMPERIAL",
+	}
+	Units_value = map[string]int32{
+		"UNITS_UNSPECIFIED": 0,
+		"UNITS_STANDARD":    1,
+		"UNITS_METRIC":      2,
+		"UNITS_IMPERIAL":    3,
+	}
+)
+
+func (x Units) Enum() *Units {
+	p := new(Units)
+	*p = x
+	return p
+}
+
+func (x Units) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Units) Descriptor() protoreflect.EnumDescriptor {
+	return file_weather_proto_enumTypes[0].Descriptor()
+}
+
+func (Units) Type() protoreflect.EnumType {
+	return &file_weather_proto_enumTypes[0]
+}
+
+func (x Units) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Units.Descriptor instead.
+func (Units) EnumDescriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{0}
+}
+
+type GetCurrentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A location like "london" or "tampa,fl,us".
+	Location      string `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Units         Units  `protobuf:"varint,2,opt,name=units,proto3,enum=weather.v1.Units" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentRequest) Reset() {
+	*x = GetCurrentRequest{}
+	mi := &file_weather_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentRequest) ProtoMessage() {}
+
+func (x *GetCurrentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentRequest) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetCurrentRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *GetCurrentRequest) GetUnits() Units {
+	if x != nil {
+		return x.Units
+	}
+	return Units_UNITS_UNSPECIFIED
+}
+
+type GetForecastRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A location like "london" or "tampa,fl,us".
+	Location      string `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Units         Units  `protobuf:"varint,2,opt,name=units,proto3,enum=weather.v1.Units" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetForecastRequest) Reset() {
+	*x = GetForecastRequest{}
+	mi := &file_weather_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetForecastRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetForecastRequest) ProtoMessage() {}
+
+func (x *GetForecastRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetForecastRequest.ProtoReflect.Descriptor instead.
+func (*GetForecastRequest) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetForecastRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *GetForecastRequest) GetUnits() Units {
+	if x != nil {
+		return x.Units
+	}
+	return Units_UNITS_UNSPECIFIED
+}
+
+type GetForecastResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Days          []*DayForecast         `protobuf:"bytes,1,rep,name=days,proto3" json:"days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetForecastResponse) Reset() {
+	*x = GetForecastResponse{}
+	mi := &file_weather_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetForecastResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetForecastResponse) ProtoMessage() {}
+
+func (x *GetForecastResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetForecastResponse.ProtoReflect.Descriptor instead.
+func (*GetForecastResponse) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetForecastResponse) GetDays() []*DayForecast {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+type GeocodeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A location like "london" or "tampa,fl,us".
+	Location      string `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeocodeRequest) Reset() {
+	*x = GeocodeRequest{}
+	mi := &file_weather_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeocodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeocodeRequest) ProtoMessage() {}
+
+func (x *GeocodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeocodeRequest.ProtoReflect.Descriptor instead.
+func (*GeocodeRequest) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GeocodeRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+type StreamUpdatesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A location like "london" or "tampa,fl,us".
+	Location string `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Units    Units  `protobuf:"varint,2,opt,name=units,proto3,enum=weather.v1.Units" json:"units,omitempty"`
+	// How often to send updates. Intervals shorter than the server's minimum
+	// are raised to it.
+	Interval      *durationpb.Duration `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamUpdatesRequest) Reset() {
+	*x = StreamUpdatesRequest{}
+	mi := &file_weather_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamUpdatesRequest) ProtoMessage() {}
+
+func (x *StreamUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamUpdatesRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *StreamUpdatesRequest) GetUnits() Units {
+	if x != nil {
+		return x.Units
+	}
+	return Units_UNITS_UNSPECIFIED
+}
+
+func (x *StreamUpdatesRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+// Observation represents the weather conditions observed at a location at a
+// particular time.
+type Observation struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Summary   string                 `protobuf:"bytes,2,opt,name=summary,proto3" json:"summary,omitempty"`
+	Temp      float64                `protobuf:"fixed64,3,opt,name=temp,proto3" json:"temp,omitempty"`
+	FeelsLike float64                `protobuf:"fixed64,4,opt,name=feels_like,json=feelsLike,proto3" json:"feels_like,omitempty"`
+	Humidity  int32                  `protobuf:"varint,5,opt,name=humidity,proto3" json:"humidity,omitempty"`
+	// Atmospheric pressure in hPa.
+	Pressure  float64 `protobuf:"fixed64,6,opt,name=pressure,proto3" json:"pressure,omitempty"`
+	WindSpeed float64 `protobuf:"fixed64,7,opt,name=wind_speed,json=windSpeed,proto3" json:"wind_speed,omitempty"`
+	WindGust  float64 `protobuf:"fixed64,8,opt,name=wind_gust,json=windGust,proto3" json:"wind_gust,omitempty"`
+	WindDeg   int32   `protobuf:"varint,9,opt,name=wind_deg,json=windDeg,proto3" json:"wind_deg,omitempty"`
+	// Cloudiness in percent.
+	Clouds int32 `protobuf:"varint,10,opt,name=clouds,proto3" json:"clouds,omitempty"`
+	// Visibility in meters.
+	Visibility float64 `protobuf:"fixed64,11,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// Precipitation over the last hour in mm.
+	Precip        float64 `protobuf:"fixed64,12,opt,name=precip,proto3" json:"precip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Observation) Reset() {
+	*x = Observation{}
+	mi := &file_weather_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Observation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Observation) ProtoMessage() {}
+
+func (x *Observation) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Observation.ProtoReflect.Descriptor instead.
+func (*Observation) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Observation) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Observation) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Observation) GetTemp() float64 {
+	if x != nil {
+		return x.Temp
+	}
+	return 0
+}
+
+func (x *Observation) GetFeelsLike() float64 {
+	if x != nil {
+		return x.FeelsLike
+	}
+	return 0
+}
+
+func (x *Observation) GetHumidity() int32 {
+	if x != nil {
+		return x.Humidity
+	}
+	return 0
+}
+
+func (x *Observation) GetPressure() float64 {
+	if x != nil {
+		return x.Pressure
+	}
+	return 0
+}
+
+func (x *Observation) GetWindSpeed() float64 {
+	if x != nil {
+		return x.WindSpeed
+	}
+	return 0
+}
+
+func (x *Observation) GetWindGust() float64 {
+	if x != nil {
+		return x.WindGust
+	}
+	return 0
+}
+
+func (x *Observation) GetWindDeg() int32 {
+	if x != nil {
+		return x.WindDeg
+	}
+	return 0
+}
+
+func (x *Observation) GetClouds() int32 {
+	if x != nil {
+		return x.Clouds
+	}
+	return 0
+}
+
+func (x *Observation) GetVisibility() float64 {
+	if x != nil {
+		return x.Visibility
+	}
+	return 0
+}
+
+func (x *Observation) GetPrecip() float64 {
+	if x != nil {
+		return x.Precip
+	}
+	return 0
+}
+
+// DayForecast represents the forecasted weather for a single day.
+type DayForecast struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Date     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Summary  string                 `protobuf:"bytes,2,opt,name=summary,proto3" json:"summary,omitempty"`
+	Low      float64                `protobuf:"fixed64,3,opt,name=low,proto3" json:"low,omitempty"`
+	High     float64                `protobuf:"fixed64,4,opt,name=high,proto3" json:"high,omitempty"`
+	Humidity int32                  `protobuf:"varint,5,opt,name=humidity,proto3" json:"humidity,omitempty"`
+	// Probability of precipitation from 0 to 1.
+	PrecipProb float64 `protobuf:"fixed64,6,opt,name=precip_prob,json=precipProb,proto3" json:"precip_prob,omitempty"`
+	// Precipitation in mm.
+	Precip        float64 `protobuf:"fixed64,7,opt,name=precip,proto3" json:"precip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DayForecast) Reset() {
+	*x = DayForecast{}
+	mi := &file_weather_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DayForecast) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DayForecast) ProtoMessage() {}
+
+func (x *DayForecast) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DayForecast.ProtoReflect.Descriptor instead.
+func (*DayForecast) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DayForecast) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *DayForecast) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *DayForecast) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *DayForecast) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *DayForecast) GetHumidity() int32 {
+	if x != nil {
+		return x.Humidity
+	}
+	return 0
+}
+
+func (x *DayForecast) GetPrecipProb() float64 {
+	if x != nil {
+		return x.PrecipProb
+	}
+	return 0
+}
+
+func (x *DayForecast) GetPrecip() float64 {
+	if x != nil {
+		return x.Precip
+	}
+	return 0
+}
+
+// Location represents the geographical data for a location.
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Country       string                 `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+	Lat           float64                `protobuf:"fixed64,3,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,4,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_weather_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_weather_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_weather_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Location) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Location) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Location) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Location) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+var File_weather_proto protoreflect.FileDescriptor
+
+const file_weather_proto_rawDesc = "" +
+	"\n" +
+	"\rweather.proto\x12\n" +
+	"weather.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"X\n" +
+	"\x11GetCurrentRequest\x12\x1a\n" +
+	"\blocation\x18\x01 \x01(\tR\blocation\x12'\n" +
+	"\x05units\x18\x02 \x01(\x0e2\x11.weather.v1.UnitsR\x05units\"Y\n" +
+	"\x12GetForecastRequest\x12\x1a\n" +
+	"\blocation\x18\x01 \x01(\tR\blocation\x12'\n" +
+	"\x05units\x18\x02 \x01(\x0e2\x11.weather.v1.UnitsR\x05units\"B\n" +
+	"\x13GetForecastResponse\x12+\n" +
+	"\x04days\x18\x01 \x03(\v2\x17.weather.v1.DayForecastR\x04days\",\n" +
+	"\x0eGeocodeRequest\x12\x1a\n" +
+	"\blocation\x18\x01 \x01(\tR\blocation\"\x92\x01\n" +
+	"\x14StreamUpdatesRequest\x12\x1a\n" +
+	"\blocation\x18\x01 \x01(\tR\blocation\x12'\n" +
+	"\x05units\x18\x02 \x01(\x0e2\x11.weather.v1.UnitsR\x05units\x125\n" +
+	"\binterval\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\binterval\"\xe9\x02\n" +
+	"\vObservation\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x18\n" +
+	"\asummary\x18\x02 \x01(\tR\asummary\x12\x12\n" +
+	"\x04temp\x18\x03 \x01(\x01R\x04temp\x12\x1d\n" +
+	"\n" +
+	"feels_like\x18\x04 \x01(\x01R\tfeelsLike\x12\x1a\n" +
+	"\bhumidity\x18\x05 \x01(\x05R\bhumidity\x12\x1a\n" +
+	"\bpressure\x18\x06 \x01(\x01R\bpressure\x12\x1d\n" +
+	"\n" +
+	"wind_speed\x18\a \x01(\x01R\twindSpeed\x12\x1b\n" +
+	"\twind_gust\x18\b \x01(\x01R\bwindGust\x12\x19\n" +
+	"\bwind_deg\x18\t \x01(\x05R\awindDeg\x12\x16\n" +
+	"\x06clouds\x18\n" +
+	" \x01(\x05R\x06clouds\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\v \x01(\x01R\n" +
+	"visibility\x12\x16\n" +
+	"\x06precip\x18\f \x01(\x01R\x06precip\"\xd2\x01\n" +
+	"\vDayForecast\x12.\n" +
+	"\x04date\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x18\n" +
+	"\asummary\x18\x02 \x01(\tR\asummary\x12\x10\n" +
+	"\x03low\x18\x03 \x01(\x01R\x03low\x12\x12\n" +
+	"\x04high\x18\x04 \x01(\x01R\x04high\x12\x1a\n" +
+	"\bhumidity\x18\x05 \x01(\x05R\bhumidity\x12\x1f\n" +
+	"\vprecip_prob\x18\x06 \x01(\x01R\n" +
+	"precipProb\x12\x16\n" +
+	"\x06precip\x18\a \x01(\x01R\x06precip\"\\\n" +
+	"\bLocation\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acountry\x18\x02 \x01(\tR\acountry\x12\x10\n" +
+	"\x03lat\x18\x03 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x04 \x01(\x01R\x03lon*X\n" +
+	"\x05Units\x12\x15\n" +
+	"\x11UNITS_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eUNITS_STANDARD\x10\x01\x12\x10\n" +
+	"\fUNITS_METRIC\x10\x02\x12\x12\n" +
+	"\x0eUNITS_IMPERIAL\x10\x032\xb1\x02\n" +
+	"\x0eWeatherService\x12D\n" +
+	"\n" +
+	"GetCurrent\x12\x1d.weather.v1.GetCurrentRequest\x1a\x17.weather.v1.Observation\x12N\n" +
+	"\vGetForecast\x12\x1e.weather.v1.GetForecastRequest\x1a\x1f.weather.v1.GetForecastResponse\x12;\n" +
+	"\aGeocode\x12\x1a.weather.v1.GeocodeRequest\x1a\x14.weather.v1.Location\x12L\n" +
+	"\rStreamUpdates\x12 .weather.v1.StreamUpdatesRequest\x1a\x17.weather.v1.Observation0\x01B*Z(github.com/aculclasure/weather/weatherpbb\x06proto3"
+
+var (
+	file_weather_proto_rawDescOnce sync.Once
+	file_weather_proto_rawDescData []byte
+)
+
+func file_weather_proto_rawDescGZIP() []byte {
+	file_weather_proto_rawDescOnce.Do(func() {
+		file_weather_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_weather_proto_rawDesc), len(file_weather_proto_rawDesc)))
+	})
+	return file_weather_proto_rawDescData
+}
+
+var file_weather_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_weather_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_weather_proto_goTypes = []any{
+	(Units)(0),                    // 0: weather.v1.Units
+	(*GetCurrentRequest)(nil),     // 1: weather.v1.GetCurrentRequest
+	(*GetForecastRequest)(nil),    // 2: weather.v1.GetForecastRequest
+	(*GetForecastResponse)(nil),   // 3: weather.v1.GetForecastResponse
+	(*GeocodeRequest)(nil),        // 4: weather.v1.GeocodeRequest
+	(*StreamUpdatesRequest)(nil),  // 5: weather.v1.StreamUpdatesRequest
+	(*Observation)(nil),           // 6: weather.v1.Observation
+	(*DayForecast)(nil),           // 7: weather.v1.DayForecast
+	(*Location)(nil),              // 8: weather.v1.Location
+	(*durationpb.Duration)(nil),   // 9: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_weather_proto_depIdxs = []int32{
+	0,  // 0: weather.v1.GetCurrentRequest.units:type_name -> weather.v1.Units
+	0,  // 1: weather.v1.GetForecastRequest.units:type_name -> weather.v1.Units
+	7,  // 2: weather.v1.GetForecastResponse.days:type_name -> weather.v1.DayForecast
+	0,  // 3: weather.v1.StreamUpdatesRequest.units:type_name -> weather.v1.Units
+	9,  // 4: weather.v1.StreamUpdatesRequest.interval:type_name -> google.protobuf.Duration
+	10, // 5: weather.v1.Observation.time:type_name -> google.protobuf.Timestamp
+	10, // 6: weather.v1.DayForecast.date:type_name -> google.protobuf.Timestamp
+	1,  // 7: weather.v1.WeatherService.GetCurrent:input_type -> weather.v1.GetCurrentRequest
+	2,  // 8: weather.v1.WeatherService.GetForecast:input_type -> weather.v1.GetForecastRequest
+	4,  // 9: weather.v1.WeatherService.Geocode:input_type -> weather.v1.GeocodeRequest
+	5,  // 10: weather.v1.WeatherService.StreamUpdates:input_type -> weather.v1.StreamUpdatesRequest
+	6,  // 11: weather.v1.WeatherService.GetCurrent:output_type -> weather.v1.Observation
+	3,  // 12: weather.v1.WeatherService.GetForecast:output_type -> weather.v1.GetForecastResponse
+	8,  // 13: weather.v1.WeatherService.Geocode:output_type -> weather.v1.Location
+	6,  // 14: weather.v1.WeatherService.StreamUpdates:output_type -> weather.v1.Observation
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_weather_proto_init() }
+func file_weather_proto_init() {
+	if File_weather_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_weather_proto_rawDesc), len(file_weather_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_weather_proto_goTypes,
+		DependencyIndexes: file_weather_proto_depIdxs,
+		EnumInfos:         file_weather_proto_enumTypes,
+		MessageInfos:      file_weather_proto_msgTypes,
+	}.Build()
+	File_weather_proto = out.File
+	file_weather_proto_goTypes = nil
+	file_weather_proto_depIdxs = nil
+}